@@ -3,13 +3,29 @@ package main
 import (
 	"classroom-service/config"
 	"classroom-service/internal/assign"
+	"classroom-service/internal/attendance"
 	"classroom-service/internal/classroom"
+	"classroom-service/internal/closure"
+	"classroom-service/internal/coverage"
+	"classroom-service/internal/event"
+	"classroom-service/internal/history"
 	"classroom-service/internal/language"
 	"classroom-service/internal/leader"
+	"classroom-service/internal/lease"
+	"classroom-service/internal/notification"
+	"classroom-service/internal/planner"
+	"classroom-service/internal/ratio"
 	"classroom-service/internal/region"
+	"classroom-service/internal/rollover"
 	"classroom-service/internal/room"
+	"classroom-service/internal/rule"
+	"classroom-service/internal/scheduler"
+	"classroom-service/internal/session"
+	"classroom-service/internal/teacher"
 	"classroom-service/internal/term"
 	"classroom-service/internal/user"
+	"classroom-service/internal/waitlist"
+	"classroom-service/pkg/constants"
 	"classroom-service/pkg/consul"
 	"classroom-service/pkg/zap"
 	"context"
@@ -40,6 +56,9 @@ func main() {
 	}
 
 	cfg := config.LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	logger, err := zap.New(cfg)
 	if err != nil {
@@ -61,36 +80,154 @@ func main() {
 	consulClient := consulConn.Connect()
 	defer consulConn.Deregister()
 
-	// c := cron.New(cron.WithSeconds())
-
 	roomService := room.NewRoomService(consulClient)
 	userService := user.NewUserService(consulClient)
 	languageService := language.NewUserService(consulClient)
 	termService := term.NewTermService(consulClient)
 
+	var notifier notification.Notifier
+	switch cfg.Notify.Sink {
+	case "memory":
+		notifier = notification.NewMemoryNotifier()
+	case "file":
+		notifier = notification.NewFileNotifier(cfg.Notify.FilePath)
+	default:
+		notifier = notification.NewNotificationGateway(consulClient)
+	}
+
 	regionCollection := mongoClient.Database(cfg.MongoDB).Collection("region")
 	classroomCollection := mongoClient.Database(cfg.MongoDB).Collection("classroom")
 	assignCollection := mongoClient.Database(cfg.MongoDB).Collection("assign")
 	leaderCollection := mongoClient.Database(cfg.MongoDB).Collection("leader")
 	assignTemplateCollection := mongoClient.Database(cfg.MongoDB).Collection("assign_template")
 	leaderTemplateCollection := mongoClient.Database(cfg.MongoDB).Collection("leader_template")
+	leaderRotaCollection := mongoClient.Database(cfg.MongoDB).Collection("leader_rota")
+	templateProposalCollection := mongoClient.Database(cfg.MongoDB).Collection("template_proposal")
+	eventOutboxCollection := mongoClient.Database(cfg.MongoDB).Collection("event_outbox")
+	leaseCollection := mongoClient.Database(cfg.MongoDB).Collection("lease")
+	teacherProfileCollection := mongoClient.Database(cfg.MongoDB).Collection("teacher_profile")
+	sessionCollection := mongoClient.Database(cfg.MongoDB).Collection("session")
+	waitlistCollection := mongoClient.Database(cfg.MongoDB).Collection("waitlist")
+	studentTransferCollection := mongoClient.Database(cfg.MongoDB).Collection("student_transfer")
+	templatePublicationCollection := mongoClient.Database(cfg.MongoDB).Collection("template_publication")
+	ruleCollection := mongoClient.Database(cfg.MongoDB).Collection("placement_rule")
+	ratioRuleCollection := mongoClient.Database(cfg.MongoDB).Collection("ratio_rule")
+	attendanceCollection := mongoClient.Database(cfg.MongoDB).Collection("attendance")
+	locationOverrideCollection := mongoClient.Database(cfg.MongoDB).Collection("location_override")
+	closureCollection := mongoClient.Database(cfg.MongoDB).Collection("closure")
+	rolloverRuleCollection := mongoClient.Database(cfg.MongoDB).Collection("rollover_rule")
+	rolloverCollection := mongoClient.Database(cfg.MongoDB).Collection("rollover")
+	templateVersionCollection := mongoClient.Database(cfg.MongoDB).Collection("template_version")
+	migrationCollection := mongoClient.Database(cfg.MongoDB).Collection("migration")
+
+	outboxRepository := event.NewOutboxRepository(eventOutboxCollection)
+	if err := outboxRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: failed to create event outbox indexes: %v", err)
+	}
+	outboxPublisher := event.NewOutboxPublisher(outboxRepository)
+
+	var eventSinks []event.Sink
+	for _, url := range cfg.Events.WebhookURLs {
+		eventSinks = append(eventSinks, event.NewWebhookSink(url, cfg.Events.WebhookSecret))
+	}
+	eventDispatcher := event.NewDispatcher(outboxRepository, eventSinks...)
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go eventDispatcher.Run(backgroundCtx)
+
+	sessionRepository := session.NewSessionRepository(sessionCollection, classroomCollection)
+	sessionService := session.NewSessionService(sessionRepository, userService)
+	sessionHandler := session.NewSessionHandler(sessionService)
 
-	leaderRepository := leader.NewLeaderRepository(leaderCollection, leaderTemplateCollection)
-	leaderService := leader.NewLeaderService(leaderRepository)
+	leaderRepository := leader.NewLeaderRepository(leaderCollection, leaderTemplateCollection, leaderRotaCollection, classroomCollection)
+	if err := leaderRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: failed to create leader indexes: %v", err)
+	}
+	assignRepository := assign.NewAssignRepository(assignCollection, assignTemplateCollection, classroomCollection, studentTransferCollection, templatePublicationCollection, migrationCollection)
+	if err := assignRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: failed to create template publication indexes: %v", err)
+	}
+
+	// Template history is recorded as changes are published with
+	// event.Record, in the writer's transaction, so every version holds the
+	// templates the change left and a failed snapshot fails the change.
+	historyRepository := history.NewHistoryRepository(templateVersionCollection)
+	if err := historyRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: failed to create template version indexes: %v", err)
+	}
+	historyService := history.NewHistoryService(historyRepository, assignRepository, leaderRepository)
+	historyHandler := history.NewHistoryHandler(historyService)
+	eventPublisher := event.NewMultiPublisher(outboxPublisher, historyService)
+
+	leaderService := leader.NewLeaderService(leaderRepository, userService, termService, eventPublisher, sessionRepository)
 	leaderHandler := leader.NewLeaderHandler(leaderService)
 
-	assignRepository := assign.NewAssignRepository(assignCollection, assignTemplateCollection, classroomCollection)
-	assignService := assign.NewAssignService(assignRepository)
+	teacherRepository := teacher.NewTeacherRepository(teacherProfileCollection, classroomCollection)
+	teacherService := teacher.NewTeacherService(teacherRepository, userService)
+	teacherHandler := teacher.NewTeacherHandler(teacherService)
+
+	ruleRepository := rule.NewRuleRepository(ruleCollection, classroomCollection)
+	ruleService := rule.NewRuleService(ruleRepository, assignRepository, userService)
+	ruleHandler := rule.NewRuleHandler(ruleService)
+
+	assignService := assign.NewAssignService(assignRepository, eventPublisher, teacherRepository, sessionRepository, ruleService, userService)
+	// Gateway readers only see published templates; classrooms that were
+	// live before publications existed are published once.
+	if err := assignService.PublishExistingTemplates(context.Background()); err != nil {
+		log.Printf("Warning: failed to publish existing templates: %v", err)
+	}
 	assignHandler := assign.NewAssignHandler(assignService)
 
-	classroomRepository := classroom.NewClassroomRepository(classroomCollection)
-	classroomService := classroom.NewClassroomService(classroomRepository, assignRepository, userService, leaderRepository, languageService, termService, roomService)
+	waitlistRepository := waitlist.NewWaitlistRepository(waitlistCollection, classroomCollection)
+	if err := waitlistRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: failed to create waitlist indexes: %v", err)
+	}
+	waitlistService := waitlist.NewWaitlistService(waitlistRepository, assignService, assignRepository, userService, termService, eventPublisher, time.Duration(cfg.Waitlist.OfferHours)*time.Hour)
+	waitlistHandler := waitlist.NewWaitlistHandler(waitlistService)
+	assignService.AddSlotReleaseListener(waitlistService)
+
+	classroomRepository := classroom.NewClassroomRepository(classroomCollection, locationOverrideCollection)
+	classroomService := classroom.NewClassroomService(classroomRepository, assignRepository, userService, leaderRepository, languageService, termService, roomService, eventPublisher, notifier, teacherRepository, sessionRepository, assignService)
 	classroomHandler := classroom.NewClassroomHandler(classroomService)
+	assignService.AddAssignmentGuard(classroomService)
 
 	regionRepository := region.NewRegionRepository(regionCollection)
-	regionService := region.NewRegionService(regionRepository, classroomRepository, assignRepository, userService, roomService, leaderRepository, languageService)
+	regionService := region.NewRegionService(regionRepository, classroomRepository, assignRepository, userService, roomService, leaderRepository, languageService, eventPublisher)
 	regionHandler := region.NewRegionHandler(regionService)
 
+	proposalRepository := planner.NewProposalRepository(templateProposalCollection)
+	plannerService := planner.NewPlannerService(proposalRepository, classroomRepository, assignRepository, leaderRepository, teacherRepository, eventPublisher, sessionRepository)
+	plannerHandler := planner.NewPlannerHandler(plannerService)
+
+	ratioRepository := ratio.NewRatioRepository(ratioRuleCollection)
+	ratioService := ratio.NewRatioService(ratioRepository, classroomRepository, assignRepository, leaderRepository, userService)
+	ratioHandler := ratio.NewRatioHandler(ratioService)
+	assignService.AddAssignmentGuard(ratioService)
+
+	attendanceRepository := attendance.NewAttendanceRepository(attendanceCollection)
+	if err := attendanceRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Printf("Warning: failed to create attendance indexes: %v", err)
+	}
+	attendanceService := attendance.NewAttendanceService(attendanceRepository, assignRepository, sessionRepository, userService, termService, eventPublisher, cfg.Attendance.DayStart, cfg.Attendance.LateGraceMinutes, cfg.Attendance.TokenSecret, cfg.Attendance.TokenMinutes)
+	attendanceHandler := attendance.NewAttendanceHandler(attendanceService)
+
+	closureRepository := closure.NewClosureRepository(closureCollection)
+	closureService := closure.NewClosureService(closureRepository, assignRepository, classroomRepository, sessionRepository, teacherRepository, eventPublisher, assignService)
+	closureHandler := closure.NewClosureHandler(closureService)
+	assignService.AddAssignmentGuard(closureService)
+
+	rolloverRepository := rollover.NewRolloverRepository(rolloverRuleCollection, rolloverCollection)
+	rolloverService := rollover.NewRolloverService(rolloverRepository, classroomRepository, assignRepository, assignService, sessionRepository, userService, termService, eventPublisher)
+	rolloverHandler := rollover.NewRolloverHandler(rolloverService)
+
+	coverageService := coverage.NewCoverageService(classroomRepository, assignRepository, leaderRepository, userService, notifier, ratioService, cfg.Coverage.AlertDays)
+	coverageHandler := coverage.NewCoverageHandler(coverageService)
+
+	leaseRepository := lease.NewLeaseRepository(leaseCollection)
+	leaseService := lease.NewLeaseService(leaseRepository, lease.DefaultTTL)
+	leaseHandler := lease.NewLeaseHandler(leaseService)
+
 	// classroomRepository := class.NewClassRepository(assginCollection, systemConfig, notification, leader, classCollection)
 	// classroomService := class.NewClassService(classroomRepository, roomService, userService)
 	// classroomHandler := class.NewClassHandler(classroomService)
@@ -101,20 +238,74 @@ func main() {
 	assign.RegisterRoutes(r, assignHandler)
 	classroom.RegisterRoutes(r, classroomHandler)
 	region.RegisterRoutes(r, regionHandler)
+	planner.RegisterRoutes(r, plannerHandler)
+	lease.RegisterRoutes(r, leaseHandler)
+	coverage.RegisterRoutes(r, coverageHandler)
+	teacher.RegisterRoutes(r, teacherHandler)
+	session.RegisterRoutes(r, sessionHandler)
+	waitlist.RegisterRoutes(r, waitlistHandler)
+	rule.RegisterRoutes(r, ruleHandler)
+	ratio.RegisterRoutes(r, ratioHandler)
+	attendance.RegisterRoutes(r, attendanceHandler)
+	closure.RegisterRoutes(r, closureHandler)
+	rollover.RegisterRoutes(r, rolloverHandler)
+	history.RegisterRoutes(r, historyHandler)
 
-	// _, err = c.AddFunc("0 0 0 * * *", func() {
-	// 	log.Println("🔄 Cron master running...")
-	// 	ctx := context.WithValue(context.Background(), constants.TokenKey, os.Getenv("CRON_SERVICE_TOKEN"))
-	// 	if err := classroomService.CronNotifications(ctx); err != nil {
-	// 		log.Printf("CronEventNotifications failed: %v", err)
-	// 	}
-	// })
-	// if err != nil {
-	// 	log.Fatalf("AddFunc error: %v", err)
-	// }
+	jobScheduler := scheduler.NewScheduler(time.Local, leaseService)
+	err = jobScheduler.AddDailyJob(&scheduler.Job{
+		Name:   "daily-notifications",
+		Hour:   cfg.Notify.DailyHour,
+		Minute: cfg.Notify.DailyMinute,
+		Run: func(ctx context.Context) error {
+			ctx = context.WithValue(ctx, constants.TokenKey, cfg.Cron.ServiceToken)
+			return classroomService.CronNotifications(ctx)
+		},
+	})
+	if err != nil {
+		log.Fatalf("AddDailyJob error: %v", err)
+	}
+
+	err = jobScheduler.AddDailyJob(&scheduler.Job{
+		Name:   "coverage-alerts",
+		Hour:   cfg.Coverage.AlertHour,
+		Minute: cfg.Coverage.AlertMinute,
+		Run: func(ctx context.Context) error {
+			ctx = context.WithValue(ctx, constants.TokenKey, cfg.Cron.ServiceToken)
+			return coverageService.CronCoverageAlerts(ctx)
+		},
+	})
+	if err != nil {
+		log.Fatalf("AddDailyJob error: %v", err)
+	}
+
+	// Offer expiry and the closure sweep run on one replica at a time, like
+	// the daily jobs.
+	err = jobScheduler.AddIntervalJob(&scheduler.Job{
+		Name:  "waitlist-offer-expiry",
+		Every: time.Duration(cfg.Waitlist.SweepMinutes) * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := waitlistService.ExpireOffers(ctx)
+			return err
+		},
+	})
+	if err != nil {
+		log.Fatalf("AddIntervalJob error: %v", err)
+	}
+
+	err = jobScheduler.AddIntervalJob(&scheduler.Job{
+		Name:  "closure-sweep",
+		Every: time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := closureService.EndClosures(ctx)
+			return err
+		},
+	})
+	if err != nil {
+		log.Fatalf("AddIntervalJob error: %v", err)
+	}
 
-	// c.Start()
-	// defer c.Stop()
+	jobScheduler.Start(context.Background())
+	defer jobScheduler.Stop()
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -138,6 +329,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopBackground()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
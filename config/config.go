@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
 
 type Consul struct {
 	Host string `mapstructure:"host" validate:"required"`
@@ -47,14 +52,56 @@ type ZapConfig struct {
 	} `mapstructure:"cores"`
 }
 
+type EventConfig struct {
+	WebhookURLs   []string
+	WebhookSecret string
+}
+
+type NotificationConfig struct {
+	Sink        string
+	FilePath    string
+	DailyHour   int
+	DailyMinute int
+}
+
+type CoverageConfig struct {
+	AlertDays   int
+	AlertHour   int
+	AlertMinute int
+}
+
+type AttendanceConfig struct {
+	DayStart         string
+	LateGraceMinutes int
+	TokenSecret      string
+	TokenMinutes     int
+}
+
+// CronConfig holds the gateway token the scheduled jobs send, since they
+// run without a request to take one from.
+type CronConfig struct {
+	ServiceToken string
+}
+
+type WaitlistConfig struct {
+	OfferHours   int
+	SweepMinutes int
+}
+
 type Config struct {
-	Port     string
-	MongoURI string
-	MongoDB  string
-	Consul   Consul           `mapstructure:"consul" validate:"required"`
-	Registry Registry         `mapstructure:"registry" validate:"required"`
-	App      AppConfiguration `mapstructure:"app"`
-	Zap      ZapConfig        `mapstructure:"zap"`
+	Port       string
+	MongoURI   string
+	MongoDB    string
+	Consul     Consul           `mapstructure:"consul" validate:"required"`
+	Registry   Registry         `mapstructure:"registry" validate:"required"`
+	App        AppConfiguration `mapstructure:"app"`
+	Zap        ZapConfig        `mapstructure:"zap"`
+	Events     EventConfig
+	Notify     NotificationConfig
+	Coverage   CoverageConfig
+	Waitlist   WaitlistConfig
+	Attendance AttendanceConfig
+	Cron       CronConfig
 }
 
 func LoadConfig() *Config {
@@ -69,6 +116,34 @@ func LoadConfig() *Config {
 		Registry: Registry{
 			Host: getEnv("REGISTRY_HOST", "localhost"),
 		},
+		Events: EventConfig{
+			WebhookURLs:   getEnvList("EVENT_WEBHOOK_URLS"),
+			WebhookSecret: getEnv("EVENT_WEBHOOK_SECRET", ""),
+		},
+		Notify: NotificationConfig{
+			Sink:        getEnv("NOTIFICATION_SINK", "gateway"),
+			FilePath:    getEnv("NOTIFICATION_FILE", "notifications.log"),
+			DailyHour:   getEnvInt("NOTIFICATION_HOUR", 18),
+			DailyMinute: getEnvInt("NOTIFICATION_MINUTE", 0),
+		},
+		Coverage: CoverageConfig{
+			AlertDays:   getEnvInt("COVERAGE_ALERT_DAYS", 2),
+			AlertHour:   getEnvInt("COVERAGE_ALERT_HOUR", 17),
+			AlertMinute: getEnvInt("COVERAGE_ALERT_MINUTE", 0),
+		},
+		Attendance: AttendanceConfig{
+			DayStart:         getEnv("ATTENDANCE_DAY_START", "08:00"),
+			LateGraceMinutes: getEnvInt("ATTENDANCE_LATE_GRACE_MINUTES", 10),
+			TokenSecret:      getEnv("ATTENDANCE_TOKEN_SECRET", ""),
+			TokenMinutes:     getEnvInt("ATTENDANCE_TOKEN_MINUTES", 10),
+		},
+		Cron: CronConfig{
+			ServiceToken: getEnv("CRON_SERVICE_TOKEN", ""),
+		},
+		Waitlist: WaitlistConfig{
+			OfferHours:   getEnvInt("WAITLIST_OFFER_HOURS", 48),
+			SweepMinutes: getEnvInt("WAITLIST_SWEEP_MINUTES", 5),
+		},
 		App: AppConfiguration{
 			API: APIConfig{
 				Rest: RestConfig{
@@ -102,9 +177,43 @@ func LoadConfig() *Config {
 	return config
 }
 
+// Validate rejects settings the service cannot start with.
+func (c *Config) Validate() error {
+	if c.Attendance.TokenSecret == "" {
+		return fmt.Errorf("ATTENDANCE_TOKEN_SECRET is required")
+	}
+	if c.Cron.ServiceToken == "" {
+		return fmt.Errorf("CRON_SERVICE_TOKEN is required")
+	}
+	if c.Waitlist.SweepMinutes <= 0 {
+		return fmt.Errorf("WAITLIST_SWEEP_MINUTES must be positive, got %d", c.Waitlist.SweepMinutes)
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
 	}
 	return defaultValue
 }
+
+// getEnvList reads a comma separated list, ignoring empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
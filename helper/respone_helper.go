@@ -7,6 +7,9 @@ import (
 const (
 	ErrInvalidOperation = "ERR_INVALID_OPERATION"
 	ErrInvalidRequest   = "ERR_INVALID_REQUEST"
+	ErrNotFound         = "ERR_NOT_FOUND"
+	ErrConflict         = "ERR_CONFLICT"
+	ErrForbidden        = "ERR_FORBIDDEN"
 )
 
 type APIResponse struct {
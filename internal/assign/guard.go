@@ -0,0 +1,30 @@
+package assign
+
+import "context"
+
+// AssignmentGuard can refuse a change to a daily assignment before it is
+// stored. Before is the stored assignment, nil when the slot had none yet;
+// after is the assignment as it would be saved.
+type AssignmentGuard interface {
+	CheckAssignment(ctx context.Context, before, after *TeacherStudentAssignment) error
+}
+
+func (s *assignService) AddAssignmentGuard(guard AssignmentGuard) {
+	s.AssignmentGuards = append(s.AssignmentGuards, guard)
+}
+
+// CheckAssignment runs every registered guard, so that writers outside this
+// package, such as template expansion and closures, are held to the same
+// checks as the assignment endpoints.
+func (s *assignService) CheckAssignment(ctx context.Context, before, after *TeacherStudentAssignment) error {
+	return s.checkGuards(ctx, before, after)
+}
+
+func (s *assignService) checkGuards(ctx context.Context, before, after *TeacherStudentAssignment) error {
+	for _, g := range s.AssignmentGuards {
+		if err := g.CheckAssignment(ctx, before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
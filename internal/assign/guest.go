@@ -0,0 +1,156 @@
+package assign
+
+import (
+	"classroom-service/internal/event"
+	"classroom-service/internal/rule"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrGuestWithoutStudent = errors.New("guest and trial assignments need a student")
+	ErrNotGuestAssignment  = errors.New("assignment is not a guest or trial visit")
+	ErrGuestConverted      = errors.New("guest assignment has already been converted")
+)
+
+func validateAssignmentType(assignmentType string) error {
+	switch assignmentType {
+	case "", AssignmentTypeRegular, AssignmentTypeGuest, AssignmentTypeTrial:
+		return nil
+	default:
+		return fmt.Errorf("invalid assignment type %q", assignmentType)
+	}
+}
+
+// ConvertGuestAssignment turns a guest or trial visit into a regular
+// template assignment for the term, with the same checks as creating the
+// template directly. The visit itself stays as it was and records the
+// template it became.
+func (s *assignService) ConvertGuestAssignment(ctx context.Context, request *ConvertGuestRequest, userID string) ([]*rule.Violation, error) {
+
+	objID, err := primitive.ObjectIDFromHex(request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := primitive.ObjectIDFromHex(request.TermID); err != nil {
+		return nil, fmt.Errorf("invalid term id: %v", err)
+	}
+
+	guest, err := s.AssignRepository.GetAssignmentByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if guest == nil {
+		return nil, errors.New("assign not found")
+	}
+
+	if !guest.IsGuest() {
+		return nil, ErrNotGuestAssignment
+	}
+	if guest.StudentID == nil {
+		return nil, ErrGuestWithoutStudent
+	}
+	if guest.ConvertedTemplateID != nil {
+		return nil, ErrGuestConverted
+	}
+
+	slotNumber := request.SlotNumber
+	if slotNumber == 0 {
+		slotNumber = guest.SlotNumber
+	}
+
+	sessionID := ""
+	if request.SessionID != nil {
+		sessionID = *request.SessionID
+	} else if guest.SessionID != nil {
+		sessionID = guest.SessionID.Hex()
+	}
+
+	teacherID := request.TeacherID
+	if teacherID == nil {
+		teacherID = guest.TeacherID
+	}
+
+	templateRequest := &UpdateAssginRequest{
+		TeacherID:         teacherID,
+		StudentID:         guest.StudentID,
+		ClassroomID:       guest.ClassRoomID.Hex(),
+		TermID:            request.TermID,
+		SlotNumber:        slotNumber,
+		SessionID:         sessionID,
+		Override:          request.Override,
+		Weekdays:          request.Weekdays,
+		AlternateWeekdays: request.AlternateWeekdays,
+		AnchorDate:        request.AnchorDate,
+	}
+
+	var warnings []*rule.Violation
+
+	err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		warnings, err = s.CreateAssignmentTemplate(ctx, templateRequest, userID)
+		if err != nil {
+			return err
+		}
+
+		template, err := s.findStudentTemplate(ctx, templateRequest)
+		if err != nil {
+			return err
+		}
+
+		guest.ConvertedTemplateID = &template.ID
+		guest.UpdatedAt = time.Now()
+
+		if err := s.AssignRepository.UpdateAssgin(ctx, guest.ID, guest); err != nil {
+			return err
+		}
+
+		return event.Record(ctx, s.EventPublisher, assignmentEvent(event.AssignmentChanged, guest, userID))
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return warnings, nil
+
+}
+
+// findStudentTemplate returns the template of the request's slot that
+// holds its student.
+func (s *assignService) findStudentTemplate(ctx context.Context, request *UpdateAssginRequest) (*ClassRoomTemplateAssignment, error) {
+
+	classroomObjID, err := primitive.ObjectIDFromHex(request.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	termObjID, err := primitive.ObjectIDFromHex(request.TermID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := s.resolveSession(ctx, classroomObjID, request.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, classroomObjID, termObjID, request.SlotNumber, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range templates {
+		if t.StudentID != nil && *t.StudentID == *request.StudentID {
+			return t, nil
+		}
+	}
+
+	return nil, errors.New("converted template not found")
+
+}
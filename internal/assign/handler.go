@@ -4,8 +4,10 @@ import (
 	"classroom-service/helper"
 	"classroom-service/pkg/constants"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -43,13 +45,13 @@ func (h *AssignHandler) AssignSlot(c *gin.Context) {
 
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
-	err := h.AssignService.AssignSlot(ctx, &req, userID.(string))
+	warnings, err := h.AssignService.AssignSlot(ctx, &req, userID.(string))
 	if err != nil {
 		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
 		return
 	}
 
-	helper.SendSuccess(c, http.StatusOK, "Create assign successfully", nil)
+	helper.SendSuccess(c, http.StatusOK, "Create assign successfully", &AssignResultResponse{Warnings: warnings})
 }
 
 func (h *AssignHandler) UnAssignSlot(c *gin.Context) {
@@ -108,14 +110,14 @@ func (h *AssignHandler) CreateAssignmentTemplate(c *gin.Context) {
 
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
-	err := h.AssignService.CreateAssignmentTemplate(ctx, &req, userID.(string))
+	warnings, err := h.AssignService.CreateAssignmentTemplate(ctx, &req, userID.(string))
 
 	if err != nil {
 		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
 		return
 	}
 
-	helper.SendSuccess(c, http.StatusOK, "Create assignment template successfully", nil)
+	helper.SendSuccess(c, http.StatusOK, "Create assignment template successfully", &AssignResultResponse{Warnings: warnings})
 
 }
 
@@ -152,3 +154,340 @@ func (h *AssignHandler) DeleteAssignmentTemplate(c *gin.Context) {
 	helper.SendSuccess(c, http.StatusOK, "Delete assignment template successfully", nil)
 
 }
+
+func (h *AssignHandler) GetEligibleTeachers(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	if classroomID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("classroom_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	date := c.Query("date")
+	if date == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("date is required"), "INVALID_REQUEST")
+		return
+	}
+
+	slotNumber, err := strconv.Atoi(c.Query("slot_number"))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, fmt.Errorf("invalid slot_number: %v", err), "INVALID_REQUEST")
+		return
+	}
+
+	sessionID := c.Query("session_id")
+
+	teachers, err := h.AssignService.GetEligibleTeachers(c, classroomID, date, sessionID, slotNumber)
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get eligible teachers successfully", teachers)
+}
+
+func (h *AssignHandler) TransferStudent(c *gin.Context) {
+
+	var req TransferStudentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	transfer, err := h.AssignService.TransferStudent(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Transfer student successfully", transfer)
+
+}
+
+func (h *AssignHandler) GetStudentTransfers(c *gin.Context) {
+
+	studentID := c.Query("student_id")
+	if studentID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("student_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	transfers, err := h.AssignService.GetStudentTransfers(c, studentID, c.Query("term_id"))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get student transfers successfully", transfers)
+
+}
+
+func (h *AssignHandler) SwapAssignments(c *gin.Context) {
+
+	var req SwapSlotsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	warnings, err := h.AssignService.SwapAssignments(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Swap assigns successfully", &AssignResultResponse{Warnings: warnings})
+
+}
+
+func (h *AssignHandler) MoveAssignment(c *gin.Context) {
+
+	var req MoveSlotRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	warnings, err := h.AssignService.MoveAssignment(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Move assign successfully", &AssignResultResponse{Warnings: warnings})
+
+}
+
+func (h *AssignHandler) SwapAssignmentTemplates(c *gin.Context) {
+
+	var req SwapSlotsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	warnings, err := h.AssignService.SwapAssignmentTemplates(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Swap assignment templates successfully", &AssignResultResponse{Warnings: warnings})
+
+}
+
+func (h *AssignHandler) MoveAssignmentTemplate(c *gin.Context) {
+
+	var req MoveSlotRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	warnings, err := h.AssignService.MoveAssignmentTemplate(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Move assignment template successfully", &AssignResultResponse{Warnings: warnings})
+
+}
+
+func (h *AssignHandler) ConvertGuestAssignment(c *gin.Context) {
+
+	var req ConvertGuestRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	warnings, err := h.AssignService.ConvertGuestAssignment(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Convert guest assignment successfully", &AssignResultResponse{Warnings: warnings})
+
+}
+
+func (h *AssignHandler) PublishAssignmentTemplates(c *gin.Context) {
+
+	var req PublishTemplateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	publication, err := h.AssignService.PublishAssignmentTemplates(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Publish assignment templates successfully", publication)
+
+}
+
+func (h *AssignHandler) RollbackAssignmentTemplates(c *gin.Context) {
+
+	var req RollbackTemplateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	publication, err := h.AssignService.RollbackAssignmentTemplates(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Rollback assignment templates successfully", publication)
+
+}
+
+func (h *AssignHandler) GetTemplatePublications(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	if classroomID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("classroom_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	termID := c.Query("term_id")
+	if termID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("term_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	publications, err := h.AssignService.GetTemplatePublications(c, classroomID, termID)
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get template publications successfully", publications)
+
+}
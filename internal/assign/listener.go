@@ -0,0 +1,36 @@
+package assign
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SlotRelease describes a slot that lost its student. Date is set when a
+// single day was freed; otherwise the slot of the term template was.
+type SlotRelease struct {
+	ClassRoomID primitive.ObjectID
+	TermID      *primitive.ObjectID
+	SlotNumber  int
+	SessionID   *primitive.ObjectID
+	Date        *time.Time
+	ReleasedBy  string
+}
+
+// SlotReleaseListener is told about slots freed by UnAssignSlot and
+// DeleteAssignmentTemplate. It runs after the change is stored, so it
+// handles its own errors.
+type SlotReleaseListener interface {
+	SlotReleased(ctx context.Context, release *SlotRelease)
+}
+
+func (s *assignService) AddSlotReleaseListener(listener SlotReleaseListener) {
+	s.SlotReleaseListeners = append(s.SlotReleaseListeners, listener)
+}
+
+func (s *assignService) notifySlotReleased(ctx context.Context, release *SlotRelease) {
+	for _, l := range s.SlotReleaseListeners {
+		l.SlotReleased(ctx, release)
+	}
+}
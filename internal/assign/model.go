@@ -1,31 +1,202 @@
 package assign
 
 import (
+	"classroom-service/internal/rule"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Daily assignment types. Guest and trial assignments are one-off visits
+// that do not make the student part of the classroom for the term.
+const (
+	AssignmentTypeRegular = "regular"
+	AssignmentTypeGuest   = "guest"
+	AssignmentTypeTrial   = "trial"
+)
+
 type TeacherStudentAssignment struct {
-	ID             primitive.ObjectID `json:"id" bson:"_id"`
-	ClassRoomID    primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
-	SlotNumber     int                `json:"slot_number" bson:"slot_number"`
-	AssignDate     time.Time          `json:"assign_date" bson:"assign_date"`
-	TeacherID      *string            `json:"teacher_id" bson:"teacher_id"`
-	StudentID      *string            `json:"student_id" bson:"student_id"`
-	CreatedBy      string             `json:"created_by" bson:"created_by"`
-	IsNotification bool               `json:"is_notification" bson:"is_notification"`
-	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+	ID          primitive.ObjectID  `json:"id" bson:"_id"`
+	ClassRoomID primitive.ObjectID  `json:"class_room_id" bson:"class_room_id"`
+	SlotNumber  int                 `json:"slot_number" bson:"slot_number"`
+	AssignDate  time.Time           `json:"assign_date" bson:"assign_date"`
+	SessionID   *primitive.ObjectID `json:"session_id" bson:"session_id"`
+	TeacherID   *string             `json:"teacher_id" bson:"teacher_id"`
+	StudentID   *string             `json:"student_id" bson:"student_id"`
+	// Type is one of the AssignmentType values; empty means regular.
+	Type string `json:"type,omitempty" bson:"type,omitempty"`
+	// ConvertedTemplateID is the template a guest or trial visit was turned
+	// into.
+	ConvertedTemplateID *primitive.ObjectID `json:"converted_template_id,omitempty" bson:"converted_template_id"`
+	// EligibilityOverride is set when the teacher was placed although they
+	// do not meet the classroom's requirements.
+	EligibilityOverride *EligibilityOverride `json:"eligibility_override,omitempty" bson:"eligibility_override,omitempty"`
+	// ClosureID is set on both ends of a closure placement: the slot the
+	// student left and the one they were moved to.
+	ClosureID      *primitive.ObjectID `json:"closure_id,omitempty" bson:"closure_id,omitempty"`
+	CreatedBy      string              `json:"created_by" bson:"created_by"`
+	IsNotification bool                `json:"is_notification" bson:"is_notification"`
+	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// EligibilityOverride records an admin placing a teacher in spite of the
+// reasons that made them ineligible.
+type EligibilityOverride struct {
+	TeacherID    string    `json:"teacher_id" bson:"teacher_id"`
+	Reasons      []string  `json:"reasons" bson:"reasons"`
+	OverriddenBy string    `json:"overridden_by" bson:"overridden_by"`
+	OverriddenAt time.Time `json:"overridden_at" bson:"overridden_at"`
+}
+
+// IsGuest reports whether the assignment is a guest or trial visit.
+func (a *TeacherStudentAssignment) IsGuest() bool {
+	return a.Type == AssignmentTypeGuest || a.Type == AssignmentTypeTrial
 }
+
 type ClassRoomTemplateAssignment struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id"`
-	ClassRoomID primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
-	TermID      primitive.ObjectID `json:"term_id" bson:"term_id"`
-	SlotNumber  int                `json:"slot_number" bson:"slot_number"`
-	TeacherID   *string            `json:"teacher_id" bson:"teacher_id"`
-	StudentID   *string            `json:"student_id" bson:"student_id"`
-	CreatedBy   string             `json:"created_by" bson:"created_by"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ID          primitive.ObjectID  `json:"id" bson:"_id"`
+	ClassRoomID primitive.ObjectID  `json:"class_room_id" bson:"class_room_id"`
+	TermID      primitive.ObjectID  `json:"term_id" bson:"term_id"`
+	SlotNumber  int                 `json:"slot_number" bson:"slot_number"`
+	SessionID   *primitive.ObjectID `json:"session_id" bson:"session_id"`
+	TeacherID   *string             `json:"teacher_id" bson:"teacher_id"`
+	StudentID   *string             `json:"student_id" bson:"student_id"`
+	Attendance  *AttendancePattern  `json:"attendance" bson:"attendance"`
+	// StartDate and EndDate limit the template to part of the term, both
+	// days included. Nil means from the start or until the end of the term.
+	StartDate *time.Time `json:"start_date" bson:"start_date"`
+	EndDate   *time.Time `json:"end_date" bson:"end_date"`
+	// EligibilityOverride, see TeacherStudentAssignment.
+	EligibilityOverride *EligibilityOverride `json:"eligibility_override,omitempty" bson:"eligibility_override,omitempty"`
+	CreatedBy           string               `json:"created_by" bson:"created_by"`
+	CreatedAt           time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt           time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// ActiveOn reports whether date falls inside the template's period.
+func (a *ClassRoomTemplateAssignment) ActiveOn(date time.Time) bool {
+
+	if a.StartDate != nil && date.Before(*a.StartDate) {
+		return false
+	}
+	if a.EndDate != nil && date.After(*a.EndDate) {
+		return false
+	}
+
+	return true
+
+}
+
+// periodOverlaps reports whether both templates run on at least one common
+// day of the term.
+func (a *ClassRoomTemplateAssignment) periodOverlaps(other *ClassRoomTemplateAssignment) bool {
+
+	if a.EndDate != nil && other.StartDate != nil && a.EndDate.Before(*other.StartDate) {
+		return false
+	}
+	if other.EndDate != nil && a.StartDate != nil && other.EndDate.Before(*a.StartDate) {
+		return false
+	}
+
+	return true
+
+}
+
+// TemplatePublication is a published copy of a classroom's templates for
+// a term. Templates are edited as a draft; gateway consumers read the
+// current publication. Older publications are kept so the classroom can be
+// rolled back to one of them.
+type TemplatePublication struct {
+	ID          primitive.ObjectID             `json:"id" bson:"_id"`
+	ClassRoomID primitive.ObjectID             `json:"class_room_id" bson:"class_room_id"`
+	TermID      primitive.ObjectID             `json:"term_id" bson:"term_id"`
+	Version     int                            `json:"version" bson:"version"`
+	Assignments []*ClassRoomTemplateAssignment `json:"assignments" bson:"assignments"`
+	Current     bool                           `json:"current" bson:"current"`
+	// RolledBackFrom is the version this publication was copied from by a
+	// rollback.
+	RolledBackFrom *int      `json:"rolled_back_from,omitempty" bson:"rolled_back_from,omitempty"`
+	Note           *string   `json:"note" bson:"note"`
+	PublishedBy    string    `json:"published_by" bson:"published_by"`
+	PublishedAt    time.Time `json:"published_at" bson:"published_at"`
+}
+
+// StudentTransfer records a student moving to another classroom slot from
+// EffectiveDate on.
+type StudentTransfer struct {
+	ID               primitive.ObjectID  `json:"id" bson:"_id"`
+	StudentID        string              `json:"student_id" bson:"student_id"`
+	TermID           primitive.ObjectID  `json:"term_id" bson:"term_id"`
+	FromClassRoomID  primitive.ObjectID  `json:"from_class_room_id" bson:"from_class_room_id"`
+	FromSlotNumber   int                 `json:"from_slot_number" bson:"from_slot_number"`
+	ToClassRoomID    primitive.ObjectID  `json:"to_class_room_id" bson:"to_class_room_id"`
+	ToSlotNumber     int                 `json:"to_slot_number" bson:"to_slot_number"`
+	SessionID        *primitive.ObjectID `json:"session_id" bson:"session_id"`
+	EffectiveDate    time.Time           `json:"effective_date" bson:"effective_date"`
+	MovedAssignments int                 `json:"moved_assignments" bson:"moved_assignments"`
+	Note             *string             `json:"note" bson:"note"`
+	CreatedBy        string              `json:"created_by" bson:"created_by"`
+	CreatedAt        time.Time           `json:"created_at" bson:"created_at"`
+	// Warnings lists the placement rules the transfer breaks without being
+	// rejected. It is returned with the transfer, not stored.
+	Warnings []*rule.Violation `json:"warnings,omitempty" bson:"-"`
+}
+
+// AttendancePattern limits a template assignment to some weekdays. With
+// AlternateWeekdays set, weeks alternate between Weekdays (the week that
+// contains AnchorDate and every second week from it) and AlternateWeekdays.
+// A nil pattern means the student attends every day.
+type AttendancePattern struct {
+	Weekdays          []time.Weekday `json:"weekdays" bson:"weekdays"`
+	AlternateWeekdays []time.Weekday `json:"alternate_weekdays" bson:"alternate_weekdays"`
+	AnchorDate        *time.Time     `json:"anchor_date" bson:"anchor_date"`
+}
+
+// AttendsOn reports whether the pattern includes date.
+func (p *AttendancePattern) AttendsOn(date time.Time) bool {
+
+	if p == nil {
+		return true
+	}
+
+	weekdays := p.Weekdays
+	if len(p.AlternateWeekdays) > 0 && p.AnchorDate != nil {
+		weeks := int(startOfWeek(date).Sub(startOfWeek(*p.AnchorDate)).Hours() / (24 * 7))
+		if ((weeks%2)+2)%2 == 1 {
+			weekdays = p.AlternateWeekdays
+		}
+	}
+
+	for _, w := range weekdays {
+		if w == date.Weekday() {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// Overlaps reports whether both patterns share at least one day. Patterns
+// repeat every two weeks, so checking one fortnight is enough.
+func (p *AttendancePattern) Overlaps(other *AttendancePattern) bool {
+
+	start := startOfWeek(time.Now().UTC())
+	for i := 0; i < 14; i++ {
+		d := start.AddDate(0, 0, i)
+		if p.AttendsOn(d) && other.AttendsOn(d) {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// startOfWeek returns the Monday of the week containing t, at midnight.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -offset)
 }
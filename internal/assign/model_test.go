@@ -0,0 +1,45 @@
+package assign
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodOverlaps(t *testing.T) {
+
+	day := func(d int) *time.Time {
+		date := time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+
+	period := func(start, end *time.Time) *ClassRoomTemplateAssignment {
+		return &ClassRoomTemplateAssignment{StartDate: start, EndDate: end}
+	}
+
+	tests := []struct {
+		name string
+		a, b *ClassRoomTemplateAssignment
+		want bool
+	}{
+		{"whole terms", period(nil, nil), period(nil, nil), true},
+		{"whole term and part", period(nil, nil), period(day(10), day(20)), true},
+		{"ends before the other starts", period(nil, day(9)), period(day(10), nil), false},
+		{"starts after the other ends", period(day(10), nil), period(nil, day(9)), false},
+		{"last day shared", period(nil, day(10)), period(day(10), nil), true},
+		{"disjoint periods", period(day(1), day(5)), period(day(6), day(9)), false},
+		{"nested periods", period(day(1), day(20)), period(day(5), day(6)), true},
+		{"crossing periods", period(day(1), day(10)), period(day(5), day(15)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.periodOverlaps(tt.b); got != tt.want {
+				t.Errorf("a.periodOverlaps(b) = %v, want %v", got, tt.want)
+			}
+			if got := tt.b.periodOverlaps(tt.a); got != tt.want {
+				t.Errorf("b.periodOverlaps(a) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+}
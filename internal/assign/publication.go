@@ -0,0 +1,308 @@
+package assign
+
+import (
+	"classroom-service/internal/event"
+	"classroom-service/internal/session"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrDraftInvalid        = errors.New("template draft cannot be published")
+	ErrPublicationNotFound = errors.New("template publication not found")
+)
+
+func parseClassroomTerm(classroomID, termID string) (primitive.ObjectID, primitive.ObjectID, error) {
+
+	classroomObjID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fmt.Errorf("invalid classroom id: %v", err)
+	}
+
+	termObjID, err := primitive.ObjectIDFromHex(termID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fmt.Errorf("invalid term id: %v", err)
+	}
+
+	return classroomObjID, termObjID, nil
+
+}
+
+// validateDraft returns what keeps the classroom's templates from being
+// published: students without a teacher, sessions the classroom no longer
+// has, shared slots whose attendance overlaps and students placed twice in
+// the term for the same session.
+func (s *assignService) validateDraft(ctx context.Context, classroomID, termID primitive.ObjectID, templates []*ClassRoomTemplateAssignment) ([]string, error) {
+
+	var problems []string
+
+	slots := make(map[string][]*ClassRoomTemplateAssignment)
+	for _, t := range templates {
+		key := fmt.Sprintf("%d/%s", t.SlotNumber, keyOfSession(t.SessionID))
+		slots[key] = append(slots[key], t)
+	}
+
+	for _, t := range templates {
+		if t.SessionID != nil {
+			if _, err := session.ResolveForClassroom(ctx, s.SessionRepository, classroomID, t.SessionID.Hex()); err != nil {
+				problems = append(problems, fmt.Sprintf("slot %d: %v", t.SlotNumber, err))
+			}
+		}
+
+		if t.StudentID == nil {
+			continue
+		}
+
+		if t.TeacherID == nil || *t.TeacherID == "" {
+			problems = append(problems, fmt.Sprintf("slot %d: student %s has no teacher", t.SlotNumber, *t.StudentID))
+		}
+
+		if err := checkAttendanceOverlap(t, slots[fmt.Sprintf("%d/%s", t.SlotNumber, keyOfSession(t.SessionID))]); err != nil {
+			problems = append(problems, err.Error())
+		}
+
+		others, err := s.AssignRepository.GetAssignmentTemplateByTermIDAndStudentID(ctx, *t.StudentID, termID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Templates ended by a transfer do not overlap the ones that
+		// replaced them.
+		sessionIDs := make([]*primitive.ObjectID, 0, len(others))
+		for _, o := range others {
+			if o.ID == t.ID || !t.periodOverlaps(o) {
+				continue
+			}
+			sessionIDs = append(sessionIDs, o.SessionID)
+		}
+
+		i, err := session.FindConflict(ctx, s.SessionRepository, t.SessionID, sessionIDs)
+		if err != nil {
+			return nil, err
+		}
+		if i >= 0 {
+			problems = append(problems, fmt.Sprintf("slot %d: student %s is placed twice in the term for the same session", t.SlotNumber, *t.StudentID))
+		}
+	}
+
+	return problems, nil
+
+}
+
+func keyOfSession(id *primitive.ObjectID) string {
+	if id == nil {
+		return ""
+	}
+	return id.Hex()
+}
+
+// PublishAssignmentTemplates validates the classroom's draft templates and
+// publishes a copy of them as the next version.
+func (s *assignService) PublishAssignmentTemplates(ctx context.Context, request *PublishTemplateRequest, userID string) (*TemplatePublication, error) {
+
+	classroomObjID, termObjID, err := parseClassroomTerm(request.ClassroomID, request.TermID)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := s.AssignRepository.GetAssignmentTemplateByClassroomID(ctx, classroomObjID, termObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	problems, err := s.validateDraft(ctx, classroomObjID, termObjID, templates)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrDraftInvalid, strings.Join(problems, "; "))
+	}
+
+	if templates == nil {
+		templates = make([]*ClassRoomTemplateAssignment, 0)
+	}
+
+	publication := &TemplatePublication{
+		ClassRoomID: classroomObjID,
+		TermID:      termObjID,
+		Assignments: templates,
+		Note:        request.Note,
+	}
+
+	if err := s.publish(ctx, publication, userID); err != nil {
+		return nil, err
+	}
+
+	return publication, nil
+
+}
+
+// RollbackAssignmentTemplates publishes the templates of an earlier version
+// again, as a new version.
+func (s *assignService) RollbackAssignmentTemplates(ctx context.Context, request *RollbackTemplateRequest, userID string) (*TemplatePublication, error) {
+
+	classroomObjID, termObjID, err := parseClassroomTerm(request.ClassroomID, request.TermID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.AssignRepository.GetTemplatePublicationByVersion(ctx, classroomObjID, termObjID, request.Version)
+	if err != nil {
+		return nil, err
+	}
+	if previous == nil {
+		return nil, fmt.Errorf("%w: version %d", ErrPublicationNotFound, request.Version)
+	}
+
+	version := previous.Version
+	publication := &TemplatePublication{
+		ClassRoomID:    classroomObjID,
+		TermID:         termObjID,
+		Assignments:    previous.Assignments,
+		RolledBackFrom: &version,
+		Note:           request.Note,
+	}
+
+	if err := s.publish(ctx, publication, userID); err != nil {
+		return nil, err
+	}
+
+	return publication, nil
+
+}
+
+// publishExistingMigration names the one-off publication of the templates
+// that were live before gateway readers switched to publications.
+const publishExistingMigration = "publish-existing-templates"
+
+// PublishExistingTemplates publishes the current templates of every
+// classroom that has never been published, once. Before publications
+// existed gateway readers saw the templates directly, so they are published
+// as they are, without validating them as a draft. Classrooms set up later
+// stay unpublished until someone publishes them. It is safe to run again
+// after a failure; classrooms published in the meantime are skipped.
+func (s *assignService) PublishExistingTemplates(ctx context.Context) error {
+
+	applied, err := s.AssignRepository.IsMigrationApplied(ctx, publishExistingMigration)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	termIDs, err := s.AssignRepository.GetTemplateTermIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	note := "published when publications were introduced"
+	for _, termID := range termIDs {
+		templates, err := s.AssignRepository.GetAssignmentTemplateByTermID(ctx, termID)
+		if err != nil {
+			return err
+		}
+
+		byClassroom := make(map[primitive.ObjectID][]*ClassRoomTemplateAssignment)
+		var order []primitive.ObjectID
+		for _, t := range templates {
+			if _, ok := byClassroom[t.ClassRoomID]; !ok {
+				order = append(order, t.ClassRoomID)
+			}
+			byClassroom[t.ClassRoomID] = append(byClassroom[t.ClassRoomID], t)
+		}
+
+		for _, classroomID := range order {
+			publications, err := s.AssignRepository.GetTemplatePublications(ctx, classroomID, termID)
+			if err != nil {
+				return err
+			}
+			if len(publications) > 0 {
+				continue
+			}
+
+			publication := &TemplatePublication{
+				ClassRoomID: classroomID,
+				TermID:      termID,
+				Assignments: byClassroom[classroomID],
+				Note:        &note,
+			}
+			if err := s.publish(ctx, publication, ""); err != nil {
+				return fmt.Errorf("publish classroom %s in term %s: %w", classroomID.Hex(), termID.Hex(), err)
+			}
+		}
+	}
+
+	return s.AssignRepository.RecordMigration(ctx, publishExistingMigration)
+
+}
+
+// publish numbers the publication after the latest one of the classroom and
+// makes it the current one.
+func (s *assignService) publish(ctx context.Context, publication *TemplatePublication, userID string) error {
+
+	return s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		publications, err := s.AssignRepository.GetTemplatePublications(ctx, publication.ClassRoomID, publication.TermID)
+		if err != nil {
+			return err
+		}
+
+		publication.ID = primitive.NewObjectID()
+		publication.Version = 1
+		if len(publications) > 0 {
+			publication.Version = publications[0].Version + 1
+		}
+		publication.Current = true
+		publication.PublishedBy = userID
+		publication.PublishedAt = time.Now()
+
+		if err := s.AssignRepository.ClearCurrentTemplatePublication(ctx, publication.ClassRoomID, publication.TermID); err != nil {
+			return err
+		}
+
+		if err := s.AssignRepository.CreateTemplatePublication(ctx, publication); err != nil {
+			return err
+		}
+
+		eventType := event.TemplatePublished
+		if publication.RolledBackFrom != nil {
+			eventType = event.TemplateRolledBack
+		}
+
+		return event.Record(ctx, s.EventPublisher, event.New(eventType, "template_publication", publication.ID.Hex(), map[string]interface{}{
+			"class_room_id":    publication.ClassRoomID.Hex(),
+			"term_id":          publication.TermID.Hex(),
+			"version":          publication.Version,
+			"rolled_back_from": publication.RolledBackFrom,
+			"assignments":      len(publication.Assignments),
+		}).WithActor(userID))
+
+	})
+
+}
+
+func (s *assignService) GetTemplatePublications(ctx context.Context, classroomID, termID string) ([]*TemplatePublication, error) {
+
+	classroomObjID, termObjID, err := parseClassroomTerm(classroomID, termID)
+	if err != nil {
+		return nil, err
+	}
+
+	publications, err := s.AssignRepository.GetTemplatePublications(ctx, classroomObjID, termObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if publications == nil {
+		publications = make([]*TemplatePublication, 0)
+	}
+
+	return publications, nil
+
+}
@@ -0,0 +1,415 @@
+package assign
+
+import (
+	"classroom-service/internal/event"
+	"classroom-service/internal/rule"
+	"classroom-service/internal/session"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// slotPosition is a resolved SlotRef.
+type slotPosition struct {
+	ClassRoomID primitive.ObjectID
+	SlotNumber  int
+	SessionID   *primitive.ObjectID
+}
+
+func (p *slotPosition) equals(other *slotPosition) bool {
+	return p.ClassRoomID == other.ClassRoomID && p.SlotNumber == other.SlotNumber && sameSession(p.SessionID, other.SessionID)
+}
+
+func (s *assignService) resolvePosition(ctx context.Context, ref *SlotRef) (*slotPosition, error) {
+
+	if ref.SlotNumber < 1 || ref.SlotNumber > 15 {
+		return nil, errors.New("slot number must be between 1 and 15")
+	}
+
+	classroomObjID, err := primitive.ObjectIDFromHex(ref.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := s.resolveSession(ctx, classroomObjID, ref.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &slotPosition{
+		ClassRoomID: classroomObjID,
+		SlotNumber:  ref.SlotNumber,
+		SessionID:   sessionID,
+	}, nil
+
+}
+
+func (s *assignService) SwapAssignments(ctx context.Context, request *SwapSlotsRequest, userID string) ([]*rule.Violation, error) {
+	return s.rearrangeAssignments(ctx, request.Date, &request.First, &request.Second, false, request.Override, userID)
+}
+
+func (s *assignService) MoveAssignment(ctx context.Context, request *MoveSlotRequest, userID string) ([]*rule.Violation, error) {
+	return s.rearrangeAssignments(ctx, request.Date, &request.From, &request.To, true, request.Override, userID)
+}
+
+func (s *assignService) SwapAssignmentTemplates(ctx context.Context, request *SwapSlotsRequest, userID string) ([]*rule.Violation, error) {
+	return s.rearrangeTemplates(ctx, request.TermID, &request.First, &request.Second, false, request.Override, userID)
+}
+
+func (s *assignService) MoveAssignmentTemplate(ctx context.Context, request *MoveSlotRequest, userID string) ([]*rule.Violation, error) {
+	return s.rearrangeTemplates(ctx, request.TermID, &request.From, &request.To, true, request.Override, userID)
+}
+
+// assignmentOccupied reports whether a daily assignment has a teacher or a
+// student.
+func assignmentOccupied(a *TeacherStudentAssignment) bool {
+	return a != nil && (a.TeacherID != nil || a.StudentID != nil)
+}
+
+// rearrangeAssignments swaps the daily assignments of two slots on a date by
+// relocating the documents, so teacher and student stay together. A move is
+// a swap with an empty slot. The final state is validated as a whole,
+// including the placement rules for both positions, and written in one
+// transaction that runs the assignment guards for every relocated document.
+func (s *assignService) rearrangeAssignments(ctx context.Context, date string, first, second *SlotRef, move, override bool, userID string) ([]*rule.Violation, error) {
+
+	if date == "" {
+		return nil, errors.New("date is required")
+	}
+
+	dateParse, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := s.resolvePosition(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.resolvePosition(ctx, second)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.equals(to) {
+		return nil, errors.New("both slots are the same")
+	}
+
+	fromAssign, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, from.ClassRoomID, from.SlotNumber, &dateParse, from.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	toAssign, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, to.ClassRoomID, to.SlotNumber, &dateParse, to.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if move {
+		if !assignmentOccupied(fromAssign) {
+			return nil, fmt.Errorf("slot %d has nothing to move", from.SlotNumber)
+		}
+		if assignmentOccupied(toAssign) {
+			return nil, fmt.Errorf("slot %d is not empty", to.SlotNumber)
+		}
+	} else if !assignmentOccupied(fromAssign) && !assignmentOccupied(toAssign) {
+		return nil, errors.New("assign not found")
+	}
+
+	type placement struct {
+		assignment  *TeacherStudentAssignment
+		dest        *slotPosition
+		eligibility *EligibilityOverride
+	}
+
+	var placements []placement
+	moved := make(map[primitive.ObjectID]bool, 2)
+	if fromAssign != nil {
+		placements = append(placements, placement{fromAssign, to, fromAssign.EligibilityOverride})
+		moved[fromAssign.ID] = true
+	}
+	if toAssign != nil {
+		placements = append(placements, placement{toAssign, from, toAssign.EligibilityOverride})
+		moved[toAssign.ID] = true
+	}
+
+	var warnings []*rule.Violation
+	for i := range placements {
+		p := &placements[i]
+		a := p.assignment
+
+		if a.TeacherID != nil && a.ClassRoomID != p.dest.ClassRoomID {
+			eligibility, err := s.checkTeacherEligible(ctx, p.dest.ClassRoomID, *a.TeacherID, &dateParse, override, userID)
+			if err != nil {
+				return nil, err
+			}
+			p.eligibility = eligibility
+		}
+
+		if err := s.checkSlotFreeOnDate(ctx, p.dest.ClassRoomID, p.dest.SlotNumber, dateParse, p.dest.SessionID, moved); err != nil {
+			return nil, err
+		}
+
+		if a.StudentID == nil {
+			continue
+		}
+
+		violations, err := s.checkRules(ctx, &rule.Placement{
+			StudentID:   *a.StudentID,
+			ClassRoomID: p.dest.ClassRoomID,
+			TeacherID:   a.TeacherID,
+		}, &dateParse, nil)
+		if err != nil {
+			return nil, err
+		}
+		warnings = mergeViolations(warnings, violations)
+
+		if !sameSession(a.SessionID, p.dest.SessionID) {
+			dayAssignments, err := s.AssignRepository.GetAssignmentsByStudentAndDate(ctx, dateParse, *a.StudentID)
+			if err != nil {
+				return nil, err
+			}
+
+			sessionIDs := make([]*primitive.ObjectID, 0, len(dayAssignments))
+			for _, d := range dayAssignments {
+				if !moved[d.ID] {
+					sessionIDs = append(sessionIDs, d.SessionID)
+				}
+			}
+
+			i, err := session.FindConflict(ctx, s.SessionRepository, p.dest.SessionID, sessionIDs)
+			if err != nil {
+				return nil, err
+			}
+			if i >= 0 {
+				return nil, errors.New("student already assigned to another class in this session")
+			}
+		}
+
+		if a.TeacherID != nil && (a.ClassRoomID != p.dest.ClassRoomID || !sameSession(a.SessionID, p.dest.SessionID)) {
+			classAssignments, err := s.AssignRepository.GetAssignmentsByClassroomAndDate(ctx, p.dest.ClassRoomID, &dateParse)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, c := range classAssignments {
+				if moved[c.ID] || !sameSession(c.SessionID, p.dest.SessionID) || c.TeacherID == nil || c.StudentID == nil {
+					continue
+				}
+				if *c.TeacherID == *a.TeacherID && *c.StudentID == *a.StudentID {
+					return nil, errors.New("teacher already assigned to student")
+				}
+			}
+		}
+	}
+
+	err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		// The stored documents are left untouched so a retried transaction
+		// starts from the same state.
+		for _, p := range placements {
+			a := *p.assignment
+			a.ClassRoomID = p.dest.ClassRoomID
+			a.SlotNumber = p.dest.SlotNumber
+			a.SessionID = p.dest.SessionID
+			a.EligibilityOverride = p.eligibility
+			a.UpdatedAt = time.Now()
+
+			if err := s.checkGuards(ctx, p.assignment, &a); err != nil {
+				return err
+			}
+
+			if err := s.AssignRepository.UpdateAssgin(ctx, a.ID, &a); err != nil {
+				return err
+			}
+
+			if err := event.Record(ctx, s.EventPublisher, assignmentEvent(event.AssignmentChanged, &a, userID)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return warnings, nil
+
+}
+
+// templatesOccupied reports whether any template of a slot has a teacher or
+// a student.
+func templatesOccupied(templates []*ClassRoomTemplateAssignment) bool {
+	for _, t := range templates {
+		if t.TeacherID != nil || t.StudentID != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rearrangeTemplates is the template counterpart of rearrangeAssignments.
+// Every template of a slot moves, so part-time students sharing a slot keep
+// sharing it with their attendance patterns.
+func (s *assignService) rearrangeTemplates(ctx context.Context, termID string, first, second *SlotRef, move, override bool, userID string) ([]*rule.Violation, error) {
+
+	termObjID, err := primitive.ObjectIDFromHex(termID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := s.resolvePosition(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.resolvePosition(ctx, second)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.equals(to) {
+		return nil, errors.New("both slots are the same")
+	}
+
+	fromTemplates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, from.ClassRoomID, termObjID, from.SlotNumber, from.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	toTemplates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, to.ClassRoomID, termObjID, to.SlotNumber, to.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if move {
+		if !templatesOccupied(fromTemplates) {
+			return nil, fmt.Errorf("slot %d has nothing to move", from.SlotNumber)
+		}
+		if templatesOccupied(toTemplates) {
+			return nil, fmt.Errorf("slot %d is not empty", to.SlotNumber)
+		}
+	} else if !templatesOccupied(fromTemplates) && !templatesOccupied(toTemplates) {
+		return nil, errors.New("assign not found")
+	}
+
+	type placement struct {
+		template    *ClassRoomTemplateAssignment
+		dest        *slotPosition
+		eligibility *EligibilityOverride
+	}
+
+	placements := make([]placement, 0, len(fromTemplates)+len(toTemplates))
+	moved := make(map[primitive.ObjectID]bool, len(fromTemplates)+len(toTemplates))
+	for _, t := range fromTemplates {
+		placements = append(placements, placement{t, to, t.EligibilityOverride})
+		moved[t.ID] = true
+	}
+	for _, t := range toTemplates {
+		placements = append(placements, placement{t, from, t.EligibilityOverride})
+		moved[t.ID] = true
+	}
+
+	var warnings []*rule.Violation
+	for i := range placements {
+		p := &placements[i]
+		t := p.template
+
+		// A template spans the whole term, so only qualifications are
+		// checked, as in CreateAssignmentTemplate.
+		if t.TeacherID != nil && t.ClassRoomID != p.dest.ClassRoomID {
+			eligibility, err := s.checkTeacherEligible(ctx, p.dest.ClassRoomID, *t.TeacherID, nil, override, userID)
+			if err != nil {
+				return nil, err
+			}
+			p.eligibility = eligibility
+		}
+
+		if t.StudentID == nil {
+			continue
+		}
+
+		violations, err := s.checkRules(ctx, &rule.Placement{
+			StudentID:   *t.StudentID,
+			ClassRoomID: p.dest.ClassRoomID,
+			TeacherID:   t.TeacherID,
+		}, nil, &termObjID)
+		if err != nil {
+			return nil, err
+		}
+		warnings = mergeViolations(warnings, violations)
+
+		if !sameSession(t.SessionID, p.dest.SessionID) {
+			studentTemplates, err := s.AssignRepository.GetAssignmentTemplateByTermIDAndStudentID(ctx, *t.StudentID, termObjID)
+			if err != nil {
+				return nil, err
+			}
+
+			sessionIDs := make([]*primitive.ObjectID, 0, len(studentTemplates))
+			for _, other := range studentTemplates {
+				if !moved[other.ID] && other.periodOverlaps(t) {
+					sessionIDs = append(sessionIDs, other.SessionID)
+				}
+			}
+
+			i, err := session.FindConflict(ctx, s.SessionRepository, p.dest.SessionID, sessionIDs)
+			if err != nil {
+				return nil, err
+			}
+			if i >= 0 {
+				return nil, errors.New("student already assigned to another class in this region for the same term and session")
+			}
+		}
+
+		if t.TeacherID != nil && (t.ClassRoomID != p.dest.ClassRoomID || !sameSession(t.SessionID, p.dest.SessionID)) {
+			classTemplates, err := s.AssignRepository.GetAssignmentTemplateByClassroomID(ctx, p.dest.ClassRoomID, termObjID)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, c := range classTemplates {
+				if moved[c.ID] || !sameSession(c.SessionID, p.dest.SessionID) || c.TeacherID == nil || c.StudentID == nil {
+					continue
+				}
+				if *c.TeacherID == *t.TeacherID && *c.StudentID == *t.StudentID && c.periodOverlaps(t) {
+					return nil, errors.New("teacher already assigned to this student")
+				}
+			}
+		}
+	}
+
+	err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		for _, p := range placements {
+			t := *p.template
+			t.ClassRoomID = p.dest.ClassRoomID
+			t.SlotNumber = p.dest.SlotNumber
+			t.SessionID = p.dest.SessionID
+			t.EligibilityOverride = p.eligibility
+			t.UpdatedAt = time.Now()
+
+			if err := s.AssignRepository.UpdateAssginTemplate(ctx, t.ID, &t); err != nil {
+				return err
+			}
+
+			if err := event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(&t, userID)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return warnings, nil
+
+}
@@ -1,6 +1,7 @@
 package assign
 
 import (
+	"classroom-service/internal/rule"
 	"context"
 	"fmt"
 	"time"
@@ -12,10 +13,11 @@ import (
 )
 
 type AssignRepository interface {
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	CreateAssignment(ctx context.Context, assign *TeacherStudentAssignment) error
-	CheckDuplicateAssignmentForDate(ctx context.Context, classroomID primitive.ObjectID, date time.Time, studentID, teacherID string) (bool, error)
-	CheckDuplicateAssignmentStudent(ctx context.Context, date time.Time, studentID string) (bool, error)
-	GetAssignmentBySlotAndDate(ctx context.Context, classroomID primitive.ObjectID, slotNumber int, date *time.Time) (*TeacherStudentAssignment, error)
+	CheckDuplicateAssignmentForDate(ctx context.Context, classroomID primitive.ObjectID, date time.Time, sessionID *primitive.ObjectID, studentID, teacherID string) (bool, error)
+	GetAssignmentsByStudentAndDate(ctx context.Context, date time.Time, studentID string) ([]*TeacherStudentAssignment, error)
+	GetAssignmentBySlotAndDate(ctx context.Context, classroomID primitive.ObjectID, slotNumber int, date *time.Time, sessionID *primitive.ObjectID) (*TeacherStudentAssignment, error)
 	UpdateAssgin(ctx context.Context, id primitive.ObjectID, assign *TeacherStudentAssignment) error
 	GetAssignmentsByClassroomAndDate(ctx context.Context, classroomID primitive.ObjectID, date *time.Time) ([]*TeacherStudentAssignment, error)
 	CountAssignedSlotsTotal(ctx context.Context, classroomID primitive.ObjectID) (int, error)
@@ -25,36 +27,97 @@ type AssignRepository interface {
 	GetTeacherAssignmentsByClassroomID(ctx context.Context, classroomID primitive.ObjectID, teacherID string, start, end *time.Time) ([]*TeacherStudentAssignment, error)
 	CountAssignmentsByClassroomID(ctx context.Context, classroomID primitive.ObjectID, start, end *time.Time) (int, error)
 	// Assignments Template
-	GetAssignmentTemplateBySlot(ctx context.Context, classroomID, termID primitive.ObjectID, slotNumber int) (*ClassRoomTemplateAssignment, error)
+	GetAssignmentTemplateBySlot(ctx context.Context, classroomID, termID primitive.ObjectID, slotNumber int, sessionID *primitive.ObjectID) (*ClassRoomTemplateAssignment, error)
+	GetAssignmentTemplatesBySlot(ctx context.Context, classroomID, termID primitive.ObjectID, slotNumber int, sessionID *primitive.ObjectID) ([]*ClassRoomTemplateAssignment, error)
 	GetAssignmentTemplateByClassroomID(ctx context.Context, classroomID , termID primitive.ObjectID) ([]*ClassRoomTemplateAssignment, error)
 	GetAssignmentTemplateByTermID(ctx context.Context, termID primitive.ObjectID) ([]*ClassRoomTemplateAssignment, error)
 	GetAssignmentTemplateByTermIDAndStudentID(ctx context.Context, studentID string, termID primitive.ObjectID) ([]*ClassRoomTemplateAssignment, error)
 	CreateAssignmentTemplate(ctx context.Context, assign *ClassRoomTemplateAssignment) error
-	CheckDuplicateAssignmentTemplate(ctx context.Context, classroomID, termID primitive.ObjectID, studentID, teacherID string) (bool, error)
+	CheckDuplicateAssignmentTemplate(ctx context.Context, classroomID, termID primitive.ObjectID, sessionID *primitive.ObjectID, studentID, teacherID string) (bool, error)
 	UpdateAssginTemplate(ctx context.Context, id primitive.ObjectID, assign *ClassRoomTemplateAssignment) error
 	CheckStudentExistingInTerm(ctx context.Context, termID primitive.ObjectID, studentID string) (bool, error)
+	DeleteAssignmentTemplateByID(ctx context.Context, id primitive.ObjectID) error
+	// Transfers
+	GetAssignmentsByStudentFromDate(ctx context.Context, classroomID primitive.ObjectID, studentID string, from time.Time) ([]*TeacherStudentAssignment, error)
+	CreateStudentTransfer(ctx context.Context, transfer *StudentTransfer) error
+	GetStudentTransfers(ctx context.Context, studentID string, termID *primitive.ObjectID) ([]*StudentTransfer, error)
+	// Rules
+	GetPlacementsOnDate(ctx context.Context, date time.Time, studentIDs []string) ([]*rule.Placement, error)
+	GetPlacementsInTerm(ctx context.Context, termID primitive.ObjectID, studentIDs []string) ([]*rule.Placement, error)
+	// Attendance
+	GetAssignmentByID(ctx context.Context, id primitive.ObjectID) (*TeacherStudentAssignment, error)
+	GetAssignmentsByStudentAndRange(ctx context.Context, studentID string, start, end *time.Time) ([]*TeacherStudentAssignment, error)
+	// Closures
+	DeleteAssignmentByID(ctx context.Context, id primitive.ObjectID) error
+	// Publications
+	CreateTemplatePublication(ctx context.Context, publication *TemplatePublication) error
+	GetTemplatePublications(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*TemplatePublication, error)
+	GetTemplatePublicationByVersion(ctx context.Context, classroomID, termID primitive.ObjectID, version int) (*TemplatePublication, error)
+	GetCurrentTemplatePublication(ctx context.Context, classroomID, termID primitive.ObjectID) (*TemplatePublication, error)
+	GetCurrentTemplatePublicationsByTermID(ctx context.Context, termID primitive.ObjectID) ([]*TemplatePublication, error)
+	ClearCurrentTemplatePublication(ctx context.Context, classroomID, termID primitive.ObjectID) error
+	GetTemplateTermIDs(ctx context.Context) ([]primitive.ObjectID, error)
+	IsMigrationApplied(ctx context.Context, name string) (bool, error)
+	RecordMigration(ctx context.Context, name string) error
+	EnsureIndexes(ctx context.Context) error
+	// Eligibility
+	GetTeacherIDsByOrganization(ctx context.Context, organizationID string) ([]string, error)
+	// Notifications
+	GetUnnotifiedAssignmentsByDate(ctx context.Context, date *time.Time) ([]*TeacherStudentAssignment, error)
+	MarkAssignmentsNotified(ctx context.Context, ids []primitive.ObjectID) error
 }
 
 type assignRepository struct {
-	assginCollection         *mongo.Collection
-	assignTemplateCollection *mongo.Collection
-	classroomCollection      *mongo.Collection
+	assginCollection          *mongo.Collection
+	assignTemplateCollection  *mongo.Collection
+	classroomCollection       *mongo.Collection
+	studentTransferCollection *mongo.Collection
+	publicationCollection     *mongo.Collection
+	migrationCollection       *mongo.Collection
 }
 
-func NewAssignRepository(assginCollection, assignTemplateCollection, classroomCollection *mongo.Collection) AssignRepository {
+func NewAssignRepository(assginCollection, assignTemplateCollection, classroomCollection, studentTransferCollection, publicationCollection, migrationCollection *mongo.Collection) AssignRepository {
 	return &assignRepository{
-		assginCollection:         assginCollection,
-		assignTemplateCollection: assignTemplateCollection,
-		classroomCollection:      classroomCollection,
+		assginCollection:          assginCollection,
+		assignTemplateCollection:  assignTemplateCollection,
+		classroomCollection:       classroomCollection,
+		studentTransferCollection: studentTransferCollection,
+		publicationCollection:     publicationCollection,
+		migrationCollection:       migrationCollection,
 	}
 }
 
+// RunInTransaction runs fn in a MongoDB transaction, which needs the
+// database to run as a replica set. Calls made with the context passed to fn
+// take part in it, and so do nested calls to RunInTransaction. The driver
+// retries fn on transient errors, so fn must be safe to run more than once.
+func (r *assignRepository) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+
+	// A call made inside another transaction joins it.
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := r.assginCollection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+
+}
+
 func (r *assignRepository) CreateAssignment(ctx context.Context, assign *TeacherStudentAssignment) error {
 
 	filter := bson.M{
 		"class_room_id": assign.ClassRoomID,
 		"slot_number":   assign.SlotNumber,
 		"assign_date":   assign.AssignDate,
+		"session_id":    assign.SessionID,
 	}
 
 	_, err := r.assginCollection.DeleteMany(ctx, filter)
@@ -71,7 +134,7 @@ func (r *assignRepository) CreateAssignment(ctx context.Context, assign *Teacher
 
 }
 
-func (r *assignRepository) GetAssignmentBySlotAndDate(ctx context.Context, classroomID primitive.ObjectID, slotNumber int, date *time.Time) (*TeacherStudentAssignment, error) {
+func (r *assignRepository) GetAssignmentBySlotAndDate(ctx context.Context, classroomID primitive.ObjectID, slotNumber int, date *time.Time, sessionID *primitive.ObjectID) (*TeacherStudentAssignment, error) {
 
 	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	end := start.Add(24 * time.Hour)
@@ -79,6 +142,7 @@ func (r *assignRepository) GetAssignmentBySlotAndDate(ctx context.Context, class
 	filter := bson.M{
 		"class_room_id": classroomID,
 		"slot_number":   slotNumber,
+		"session_id":    sessionID,
 		"assign_date": bson.M{
 			"$gte": start,
 			"$lt":  end,
@@ -98,13 +162,14 @@ func (r *assignRepository) GetAssignmentBySlotAndDate(ctx context.Context, class
 
 }
 
-func (r *assignRepository) CheckDuplicateAssignmentForDate(ctx context.Context, classroomID primitive.ObjectID, date time.Time, studentID, teacherID string) (bool, error) {
+func (r *assignRepository) CheckDuplicateAssignmentForDate(ctx context.Context, classroomID primitive.ObjectID, date time.Time, sessionID *primitive.ObjectID, studentID, teacherID string) (bool, error) {
 
 	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	end := start.Add(24 * time.Hour)
 
 	filter := bson.M{
 		"class_room_id": classroomID,
+		"session_id":    sessionID,
 		"student_id":    studentID,
 		"teacher_id":    teacherID,
 		"assign_date": bson.M{
@@ -122,7 +187,7 @@ func (r *assignRepository) CheckDuplicateAssignmentForDate(ctx context.Context,
 
 }
 
-func (r *assignRepository) CheckDuplicateAssignmentStudent(ctx context.Context, date time.Time, studentID string) (bool, error) {
+func (r *assignRepository) GetAssignmentsByStudentAndDate(ctx context.Context, date time.Time, studentID string) ([]*TeacherStudentAssignment, error) {
 
 	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 	end := start.Add(24 * time.Hour)
@@ -135,13 +200,19 @@ func (r *assignRepository) CheckDuplicateAssignmentStudent(ctx context.Context,
 		},
 	}
 
-	count, err := r.assginCollection.CountDocuments(ctx, filter)
+	cursor, err := r.assginCollection.Find(ctx, filter)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	defer cursor.Close(ctx)
+
+	var results []*TeacherStudentAssignment
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
 
-	return count > 0, nil
-	
 }
 
 func (r *assignRepository) UpdateAssgin(ctx context.Context, id primitive.ObjectID, assign *TeacherStudentAssignment) error {
@@ -236,12 +307,13 @@ func (r *assignRepository) CountAssignmentsByClassroomID(ctx context.Context, cl
 
 }
 
-func (r *assignRepository) GetAssignmentTemplateBySlot(ctx context.Context, classroomID, termID primitive.ObjectID, slotNumber int) (*ClassRoomTemplateAssignment, error) {
+func (r *assignRepository) GetAssignmentTemplateBySlot(ctx context.Context, classroomID, termID primitive.ObjectID, slotNumber int, sessionID *primitive.ObjectID) (*ClassRoomTemplateAssignment, error) {
 
 	filter := bson.M{
 		"class_room_id": classroomID,
 		"term_id": termID,
 		"slot_number":   slotNumber,
+		"session_id":    sessionID,
 	}
 
 	var assign ClassRoomTemplateAssignment
@@ -257,6 +329,32 @@ func (r *assignRepository) GetAssignmentTemplateBySlot(ctx context.Context, clas
 
 }
 
+func (r *assignRepository) GetAssignmentTemplatesBySlot(ctx context.Context, classroomID, termID primitive.ObjectID, slotNumber int, sessionID *primitive.ObjectID) ([]*ClassRoomTemplateAssignment, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"slot_number":   slotNumber,
+		"session_id":    sessionID,
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.assignTemplateCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*ClassRoomTemplateAssignment
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
 func (r *assignRepository) GetAssignmentTemplateByClassroomID(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*ClassRoomTemplateAssignment, error) {
 
 	filter := bson.M{
@@ -345,13 +443,14 @@ func (r *assignRepository) CreateAssignmentTemplate(ctx context.Context, assign
 
 }
 
-func (r *assignRepository) CheckDuplicateAssignmentTemplate(ctx context.Context, classroomID, termID primitive.ObjectID, studentID, teacherID string) (bool, error) {
+func (r *assignRepository) CheckDuplicateAssignmentTemplate(ctx context.Context, classroomID, termID primitive.ObjectID, sessionID *primitive.ObjectID, studentID, teacherID string) (bool, error) {
 
 	filter := bson.M{
 		"class_room_id": classroomID,
 		"student_id":    studentID,
 		"teacher_id":    teacherID,
 		"term_id":       termID,
+		"session_id":    sessionID,
 	}
 
 	count, err := r.assignTemplateCollection.CountDocuments(ctx, filter)
@@ -417,6 +516,8 @@ func (r *assignRepository) GetAssignmentTemplateByTermID(ctx context.Context, te
 	
 }
 
+// CheckStudentExistingInTerm looks at templates only, so daily guest and
+// trial visits never count against a student's place in the term.
 func (r *assignRepository) CheckStudentExistingInTerm(ctx context.Context, termID primitive.ObjectID, studentID string) (bool, error) {
 	
 	filter := bson.M{
@@ -459,4 +560,434 @@ func (r *assignRepository) GetAssignmentTemplateByTermIDAndStudentID(ctx context
 
 	return result, nil
 
-}
\ No newline at end of file
+}
+func (r *assignRepository) GetUnnotifiedAssignmentsByDate(ctx context.Context, date *time.Time) ([]*TeacherStudentAssignment, error) {
+
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.Add(24 * time.Hour)
+
+	filter := bson.M{
+		"assign_date": bson.M{
+			"$gte": start,
+			"$lt":  end,
+		},
+		"teacher_id":      bson.M{"$ne": nil},
+		"is_notification": bson.M{"$ne": true},
+	}
+
+	cursor, err := r.assginCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*TeacherStudentAssignment
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+func (r *assignRepository) MarkAssignmentsNotified(ctx context.Context, ids []primitive.ObjectID) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	update := bson.M{
+		"$set": bson.M{
+			"is_notification": true,
+			"updated_at":      time.Now(),
+		},
+	}
+
+	_, err := r.assginCollection.UpdateMany(ctx, filter, update)
+	return err
+
+}
+
+func (r *assignRepository) DeleteAssignmentTemplateByID(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.assignTemplateCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *assignRepository) GetAssignmentsByStudentFromDate(ctx context.Context, classroomID primitive.ObjectID, studentID string, from time.Time) ([]*TeacherStudentAssignment, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"student_id":    studentID,
+		"assign_date":   bson.M{"$gte": from},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "assign_date", Value: 1}})
+
+	cursor, err := r.assginCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*TeacherStudentAssignment
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+func (r *assignRepository) CreateStudentTransfer(ctx context.Context, transfer *StudentTransfer) error {
+	_, err := r.studentTransferCollection.InsertOne(ctx, transfer)
+	return err
+}
+
+func (r *assignRepository) GetStudentTransfers(ctx context.Context, studentID string, termID *primitive.ObjectID) ([]*StudentTransfer, error) {
+
+	filter := bson.M{"student_id": studentID}
+	if termID != nil {
+		filter["term_id"] = *termID
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "effective_date", Value: -1}, {Key: "created_at", Value: -1}})
+
+	cursor, err := r.studentTransferCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*StudentTransfer
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+// GetPlacementsOnDate implements rule.PlacementProvider.
+func (r *assignRepository) GetPlacementsOnDate(ctx context.Context, date time.Time, studentIDs []string) ([]*rule.Placement, error) {
+
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.Add(24 * time.Hour)
+
+	filter := bson.M{
+		"student_id": bson.M{"$in": studentIDs},
+		"assign_date": bson.M{
+			"$gte": start,
+			"$lt":  end,
+		},
+	}
+
+	cursor, err := r.assginCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*TeacherStudentAssignment
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	placements := make([]*rule.Placement, 0, len(results))
+	for _, a := range results {
+		placements = append(placements, &rule.Placement{
+			StudentID:   *a.StudentID,
+			ClassRoomID: a.ClassRoomID,
+			TeacherID:   a.TeacherID,
+		})
+	}
+
+	return placements, nil
+
+}
+
+// GetPlacementsInTerm implements rule.PlacementProvider.
+func (r *assignRepository) GetPlacementsInTerm(ctx context.Context, termID primitive.ObjectID, studentIDs []string) ([]*rule.Placement, error) {
+
+	filter := bson.M{
+		"term_id":    termID,
+		"student_id": bson.M{"$in": studentIDs},
+	}
+
+	cursor, err := r.assignTemplateCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*ClassRoomTemplateAssignment
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	placements := make([]*rule.Placement, 0, len(results))
+	for _, t := range results {
+		placements = append(placements, &rule.Placement{
+			StudentID:   *t.StudentID,
+			ClassRoomID: t.ClassRoomID,
+			TeacherID:   t.TeacherID,
+		})
+	}
+
+	return placements, nil
+
+}
+
+func (r *assignRepository) GetAssignmentByID(ctx context.Context, id primitive.ObjectID) (*TeacherStudentAssignment, error) {
+
+	var assign TeacherStudentAssignment
+	err := r.assginCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&assign)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &assign, nil
+
+}
+
+func (r *assignRepository) GetAssignmentsByStudentAndRange(ctx context.Context, studentID string, start, end *time.Time) ([]*TeacherStudentAssignment, error) {
+
+	filter := bson.M{
+		"student_id": studentID,
+		"assign_date": bson.M{
+			"$gte": start,
+			"$lt":  end,
+		},
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "assign_date", Value: 1}})
+
+	cursor, err := r.assginCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*TeacherStudentAssignment
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+func (r *assignRepository) DeleteAssignmentByID(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.assginCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *assignRepository) CreateTemplatePublication(ctx context.Context, publication *TemplatePublication) error {
+	_, err := r.publicationCollection.InsertOne(ctx, publication)
+	return err
+}
+
+func (r *assignRepository) GetTemplatePublications(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*TemplatePublication, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := r.publicationCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*TemplatePublication
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+func (r *assignRepository) GetTemplatePublicationByVersion(ctx context.Context, classroomID, termID primitive.ObjectID, version int) (*TemplatePublication, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"version":       version,
+	}
+
+	var publication TemplatePublication
+	if err := r.publicationCollection.FindOne(ctx, filter).Decode(&publication); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &publication, nil
+
+}
+
+func (r *assignRepository) GetCurrentTemplatePublication(ctx context.Context, classroomID, termID primitive.ObjectID) (*TemplatePublication, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"current":       true,
+	}
+
+	var publication TemplatePublication
+	if err := r.publicationCollection.FindOne(ctx, filter).Decode(&publication); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &publication, nil
+
+}
+
+func (r *assignRepository) GetCurrentTemplatePublicationsByTermID(ctx context.Context, termID primitive.ObjectID) ([]*TemplatePublication, error) {
+
+	filter := bson.M{
+		"term_id": termID,
+		"current": true,
+	}
+
+	cursor, err := r.publicationCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*TemplatePublication
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+func (r *assignRepository) ClearCurrentTemplatePublication(ctx context.Context, classroomID, termID primitive.ObjectID) error {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"current":       true,
+	}
+
+	_, err := r.publicationCollection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"current": false}})
+	return err
+
+}
+
+// GetTemplateTermIDs returns every term that has templates.
+func (r *assignRepository) GetTemplateTermIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+
+	values, err := r.assignTemplateCollection.Distinct(ctx, "term_id", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	var termIDs []primitive.ObjectID
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			termIDs = append(termIDs, id)
+		}
+	}
+
+	return termIDs, nil
+
+}
+
+func (r *assignRepository) IsMigrationApplied(ctx context.Context, name string) (bool, error) {
+
+	count, err := r.migrationCollection.CountDocuments(ctx, bson.M{"_id": name})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+
+}
+
+func (r *assignRepository) RecordMigration(ctx context.Context, name string) error {
+
+	_, err := r.migrationCollection.InsertOne(ctx, bson.M{
+		"_id":        name,
+		"applied_at": time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+
+}
+
+// EnsureIndexes makes publication versions unique per classroom and term and
+// allows only one current publication, so concurrent publishes cannot both
+// become the same version or both become current.
+func (r *assignRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.publicationCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "class_room_id", Value: 1}, {Key: "term_id", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "class_room_id", Value: 1}, {Key: "term_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"current": true}).
+				SetName("current_publication"),
+		},
+		{Keys: bson.D{{Key: "term_id", Value: 1}, {Key: "current", Value: 1}}},
+	})
+	return err
+
+}
+
+// GetTeacherIDsByOrganization returns every teacher placed in a classroom of
+// the organization, by a daily assignment or a template.
+func (r *assignRepository) GetTeacherIDsByOrganization(ctx context.Context, organizationID string) ([]string, error) {
+
+	classroomIDs, err := r.classroomCollection.Distinct(ctx, "_id", bson.M{"organization_id": organizationID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(classroomIDs) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{
+		"class_room_id": bson.M{"$in": classroomIDs},
+		"teacher_id":    bson.M{"$ne": nil},
+	}
+
+	seen := make(map[string]bool)
+	var teacherIDs []string
+	for _, collection := range []*mongo.Collection{r.assginCollection, r.assignTemplateCollection} {
+		values, err := collection.Distinct(ctx, "teacher_id", filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			if id, ok := v.(string); ok && id != "" && !seen[id] {
+				seen[id] = true
+				teacherIDs = append(teacherIDs, id)
+			}
+		}
+	}
+
+	return teacherIDs, nil
+
+}
@@ -7,4 +7,99 @@ type UpdateAssginRequest struct {
 	TermID      string  `json:"term_id"`
 	SlotNumber  int     `json:"slot_number"`
 	Date        string  `json:"date"`
+	// SessionID picks a session of the classroom day, empty for the whole day.
+	SessionID string `json:"session_id"`
+	// Type marks a daily assignment as a guest or trial visit. Empty keeps
+	// the current type, or makes a new student regular.
+	Type string `json:"type"`
+	// Override lets an organization admin place a teacher who does not meet
+	// the classroom's requirements or is unavailable; it is recorded on the
+	// assignment. Guards and placement rules still apply.
+	Override bool `json:"override"`
+	// Attendance pattern for template assignments. Leave weekdays out to
+	// keep the current pattern, send an empty list to attend every day.
+	Weekdays          []int  `json:"weekdays"`
+	AlternateWeekdays []int  `json:"alternate_weekdays"`
+	AnchorDate        string `json:"anchor_date"`
+}
+
+// ConvertGuestRequest turns a guest or trial visit into a template
+// assignment of the term. SlotNumber and SessionID default to those of the
+// visit, TeacherID to its teacher.
+type ConvertGuestRequest struct {
+	AssignmentID string  `json:"assignment_id"`
+	TermID       string  `json:"term_id"`
+	SlotNumber   int     `json:"slot_number"`
+	SessionID    *string `json:"session_id"`
+	TeacherID    *string `json:"teacher_id"`
+	// Attendance pattern of the new template, see UpdateAssginRequest.
+	Weekdays          []int  `json:"weekdays"`
+	AlternateWeekdays []int  `json:"alternate_weekdays"`
+	AnchorDate        string `json:"anchor_date"`
+	Override          bool   `json:"override"`
+}
+
+type TransferStudentRequest struct {
+	StudentID       string `json:"student_id"`
+	TermID          string `json:"term_id"`
+	FromClassroomID string `json:"from_classroom_id"`
+	ToClassroomID   string `json:"to_classroom_id"`
+	SlotNumber      int    `json:"slot_number"`
+	// SessionID picks a session of the target classroom, empty for the whole day.
+	SessionID     string  `json:"session_id"`
+	TeacherID     *string `json:"teacher_id"`
+	EffectiveDate string  `json:"effective_date"`
+	Note          *string `json:"note"`
+	// Override lets an organization admin place a teacher who does not meet
+	// the classroom's requirements; it is recorded on the assignment.
+	Override bool `json:"override"`
+}
+
+// SlotRef points at one slot of a classroom. SessionID is empty for the
+// whole day.
+type SlotRef struct {
+	ClassroomID string `json:"classroom_id"`
+	SlotNumber  int    `json:"slot_number"`
+	SessionID   string `json:"session_id"`
+}
+
+// SwapSlotsRequest exchanges the occupants of two slots. Date selects daily
+// assignments, TermID the templates of the term.
+type SwapSlotsRequest struct {
+	Date   string  `json:"date"`
+	TermID string  `json:"term_id"`
+	First  SlotRef `json:"first"`
+	Second SlotRef `json:"second"`
+	// Override lets an organization admin move a teacher who does not meet
+	// the destination classroom's requirements; it is recorded on the
+	// assignment.
+	Override bool `json:"override"`
+}
+
+// MoveSlotRequest moves the occupants of a slot to an empty one. Date
+// selects daily assignments, TermID the templates of the term.
+type MoveSlotRequest struct {
+	Date   string  `json:"date"`
+	TermID string  `json:"term_id"`
+	From   SlotRef `json:"from"`
+	To     SlotRef `json:"to"`
+	// Override lets an organization admin move a teacher who does not meet
+	// the destination classroom's requirements; it is recorded on the
+	// assignment.
+	Override bool `json:"override"`
+}
+
+type PublishTemplateRequest struct {
+	ClassroomID string  `json:"class_room_id"`
+	TermID      string  `json:"term_id"`
+	Note        *string `json:"note"`
+}
+
+// RollbackTemplateRequest publishes again the templates of an earlier
+// version. The draft is left as it is.
+type RollbackTemplateRequest struct {
+	ClassroomID string  `json:"class_room_id"`
+	TermID      string  `json:"term_id"`
+	Version     int     `json:"version"`
+	Note        *string `json:"note"`
 }
@@ -0,0 +1,31 @@
+package assign
+
+import (
+	"classroom-service/internal/rule"
+	"classroom-service/internal/teacher"
+)
+
+// AssignResultResponse lists the placement rules a successful change breaks
+// without being rejected.
+type AssignResultResponse struct {
+	Warnings []*rule.Violation `json:"warnings"`
+}
+
+type EligibleTeachersResponse struct {
+	ClassroomID string               `json:"classroom_id"`
+	Date        string               `json:"date"`
+	SessionID   string               `json:"session_id,omitempty"`
+	SlotNumber  int                  `json:"slot_number"`
+	Eligible    []*EligibleTeacher   `json:"eligible"`
+	Ineligible  []*IneligibleTeacher `json:"ineligible"`
+}
+
+type EligibleTeacher struct {
+	TeacherID string                  `json:"teacher_id"`
+	Profile   *teacher.TeacherProfile `json:"profile"`
+}
+
+type IneligibleTeacher struct {
+	TeacherID string   `json:"teacher_id"`
+	Reasons   []string `json:"reasons"`
+}
@@ -11,10 +11,23 @@ func RegisterRoutes(r *gin.Engine, handler *AssignHandler) {
 	{
 		assginGroup.POST("/assigns", handler.AssignSlot)
 		assginGroup.POST("/remove/assigns", handler.UnAssignSlot)
+		assginGroup.GET("/assigns/eligible-teachers", handler.GetEligibleTeachers)
+		assginGroup.POST("/assigns/swap", handler.SwapAssignments)
+		assginGroup.POST("/assigns/move", handler.MoveAssignment)
+		assginGroup.POST("/assigns/convert-guest", handler.ConvertGuestAssignment)
 
 		
 		// Assignment Template
 		assginGroup.POST("/assignment-templates", handler.CreateAssignmentTemplate)
 		assginGroup.POST("/remove/assignment-templates", handler.DeleteAssignmentTemplate)
+		assginGroup.POST("/assignment-templates/swap", handler.SwapAssignmentTemplates)
+		assginGroup.POST("/assignment-templates/move", handler.MoveAssignmentTemplate)
+		assginGroup.POST("/assignment-templates/publish", handler.PublishAssignmentTemplates)
+		assginGroup.POST("/assignment-templates/rollback", handler.RollbackAssignmentTemplates)
+		assginGroup.GET("/assignment-templates/publications", handler.GetTemplatePublications)
+
+		// Transfers
+		assginGroup.POST("/transfers", handler.TransferStudent)
+		assginGroup.GET("/transfers", handler.GetStudentTransfers)
 	}
 }
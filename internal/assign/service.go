@@ -1,59 +1,342 @@
 package assign
 
 import (
+	"classroom-service/internal/event"
+	"classroom-service/internal/rule"
+	"classroom-service/internal/session"
+	"classroom-service/internal/teacher"
+	"classroom-service/internal/user"
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var ErrOverrideNotAllowed = errors.New("only organization admins may override teacher eligibility")
+
 type AssignService interface {
-	AssignSlot(ctx context.Context, request *UpdateAssginRequest, userID string) error
+	AssignSlot(ctx context.Context, request *UpdateAssginRequest, userID string) ([]*rule.Violation, error)
 	UnAssignSlot(ctx context.Context, request *UpdateAssginRequest, userID string) error
-	CreateAssignmentTemplate(ctx context.Context, request *UpdateAssginRequest, userID string) error
+	CreateAssignmentTemplate(ctx context.Context, request *UpdateAssginRequest, userID string) ([]*rule.Violation, error)
+	CheckAssignmentTemplate(ctx context.Context, template *ClassRoomTemplateAssignment) ([]*rule.Violation, error)
 	DeleteAssignmentTemplate(ctx context.Context, request *UpdateAssginRequest, userID string) error
+	GetEligibleTeachers(ctx context.Context, classroomID, date, sessionID string, slotNumber int) (*EligibleTeachersResponse, error)
+	TransferStudent(ctx context.Context, request *TransferStudentRequest, userID string) (*StudentTransfer, error)
+	GetStudentTransfers(ctx context.Context, studentID, termID string) ([]*StudentTransfer, error)
+	ConvertGuestAssignment(ctx context.Context, request *ConvertGuestRequest, userID string) ([]*rule.Violation, error)
+	SwapAssignments(ctx context.Context, request *SwapSlotsRequest, userID string) ([]*rule.Violation, error)
+	MoveAssignment(ctx context.Context, request *MoveSlotRequest, userID string) ([]*rule.Violation, error)
+	SwapAssignmentTemplates(ctx context.Context, request *SwapSlotsRequest, userID string) ([]*rule.Violation, error)
+	MoveAssignmentTemplate(ctx context.Context, request *MoveSlotRequest, userID string) ([]*rule.Violation, error)
+	PublishAssignmentTemplates(ctx context.Context, request *PublishTemplateRequest, userID string) (*TemplatePublication, error)
+	RollbackAssignmentTemplates(ctx context.Context, request *RollbackTemplateRequest, userID string) (*TemplatePublication, error)
+	GetTemplatePublications(ctx context.Context, classroomID, termID string) ([]*TemplatePublication, error)
+	PublishExistingTemplates(ctx context.Context) error
+	AddSlotReleaseListener(listener SlotReleaseListener)
+	AddAssignmentGuard(guard AssignmentGuard)
+	// CheckAssignment runs every registered guard.
+	AssignmentGuard
 }
 
 type assignService struct {
-	AssignRepository AssignRepository
+	AssignRepository  AssignRepository
+	EventPublisher    event.Publisher
+	TeacherRepository teacher.TeacherRepository
+	SessionRepository session.SessionRepository
+	RuleService       rule.RuleService
+	UserService       user.UserService
+	// SlotReleaseListeners and AssignmentGuards are registered after
+	// construction because they usually depend on this service or its
+	// repository themselves.
+	SlotReleaseListeners []SlotReleaseListener
+	AssignmentGuards     []AssignmentGuard
 }
 
-func NewAssignService(repo AssignRepository) AssignService {
+func NewAssignService(repo AssignRepository, publisher event.Publisher, teacherRepository teacher.TeacherRepository, sessionRepository session.SessionRepository, ruleService rule.RuleService, userService user.UserService) AssignService {
 	return &assignService{
-		AssignRepository: repo,
+		AssignRepository:  repo,
+		EventPublisher:    publisher,
+		TeacherRepository: teacherRepository,
+		SessionRepository: sessionRepository,
+		RuleService:       ruleService,
+		UserService:       userService,
 	}
 }
 
-func (s *assignService) AssignSlot(ctx context.Context, request *UpdateAssginRequest, userID string) error {
+// resolveSession returns the id of the requested session after checking it
+// belongs to the classroom. Nil means the whole day.
+func (s *assignService) resolveSession(ctx context.Context, classroomID primitive.ObjectID, sessionID string) (*primitive.ObjectID, error) {
 
-	if request.SlotNumber < -1 || request.SlotNumber > 15 {
-		return errors.New("slot number must be between 1 and 15")
+	sess, err := session.ResolveForClassroom(ctx, s.SessionRepository, classroomID, sessionID)
+	if err != nil || sess == nil {
+		return nil, err
 	}
 
-	classroomObjID, err := primitive.ObjectIDFromHex(request.ClassroomID)
+	return &sess.ID, nil
+
+}
+
+// checkStudentFreeOnDate makes sure the student has no other assignment on
+// the day whose session overlaps the requested one.
+func (s *assignService) checkStudentFreeOnDate(ctx context.Context, date time.Time, studentID string, sessionID *primitive.ObjectID) error {
+
+	assignments, err := s.AssignRepository.GetAssignmentsByStudentAndDate(ctx, date, studentID)
 	if err != nil {
 		return err
 	}
 
-	dateParse, err := time.Parse("2006-01-02", request.Date)
+	sessionIDs := make([]*primitive.ObjectID, 0, len(assignments))
+	for _, a := range assignments {
+		sessionIDs = append(sessionIDs, a.SessionID)
+	}
+
+	i, err := session.FindConflict(ctx, s.SessionRepository, sessionID, sessionIDs)
+	if err != nil {
+		return err
+	}
+
+	if i >= 0 {
+		return errors.New("student already assigned to another class in this session")
+	}
+
+	return nil
+
+}
+
+// checkSlotFreeOnDate makes sure no other assignment holds the slot on the
+// day in a session overlapping the requested one; a whole-day request
+// collides with every session of the slot. Assignments in exclude are left
+// out.
+func (s *assignService) checkSlotFreeOnDate(ctx context.Context, classroomID primitive.ObjectID, slotNumber int, date time.Time, sessionID *primitive.ObjectID, exclude map[primitive.ObjectID]bool) error {
+
+	assignments, err := s.AssignRepository.GetAssignmentsByClassroomAndDate(ctx, classroomID, &date)
+	if err != nil {
+		return err
+	}
+
+	sessionIDs := make([]*primitive.ObjectID, 0, len(assignments))
+	for _, a := range assignments {
+		if a.SlotNumber != slotNumber || exclude[a.ID] || !assignmentOccupied(a) {
+			continue
+		}
+		sessionIDs = append(sessionIDs, a.SessionID)
+	}
+
+	i, err := session.FindConflict(ctx, s.SessionRepository, sessionID, sessionIDs)
 	if err != nil {
 		return err
 	}
 
-	existingAssignment, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, classroomObjID, request.SlotNumber, &dateParse)
+	if i >= 0 {
+		return fmt.Errorf("slot %d is already taken in an overlapping session", slotNumber)
+	}
+
+	return nil
+
+}
+
+// checkStudentFreeInTerm is the template counterpart of
+// checkStudentFreeOnDate; excludeID skips the template being edited.
+func (s *assignService) checkStudentFreeInTerm(ctx context.Context, termID primitive.ObjectID, studentID string, sessionID *primitive.ObjectID, excludeID primitive.ObjectID) error {
+
+	templates, err := s.AssignRepository.GetAssignmentTemplateByTermIDAndStudentID(ctx, studentID, termID)
 	if err != nil {
 		return err
 	}
 
+	sessionIDs := make([]*primitive.ObjectID, 0, len(templates))
+	for _, t := range templates {
+		if t.ID == excludeID {
+			continue
+		}
+		sessionIDs = append(sessionIDs, t.SessionID)
+	}
+
+	i, err := session.FindConflict(ctx, s.SessionRepository, sessionID, sessionIDs)
+	if err != nil {
+		return err
+	}
+
+	if i >= 0 {
+		return errors.New("student already assigned to another class in this region for the same term and session")
+	}
+
+	return nil
+
+}
+
+// checkRules returns the placement rules a student's new placement breaks.
+// Blocking violations fail the change, the others are returned as warnings.
+// Exactly one of date and termID is set.
+func (s *assignService) checkRules(ctx context.Context, placement *rule.Placement, date *time.Time, termID *primitive.ObjectID) ([]*rule.Violation, error) {
+
+	var violations []*rule.Violation
+	var err error
+	if date != nil {
+		violations, err = s.RuleService.CheckPlacementOnDate(ctx, placement, *date)
+	} else {
+		violations, err = s.RuleService.CheckPlacementInTerm(ctx, placement, *termID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if blocking := rule.Blocking(violations); len(blocking) > 0 {
+		return nil, rule.ViolationError(blocking)
+	}
+
+	return violations, nil
+
+}
+
+// checkTeacherEligible verifies the teacher's qualifications for the
+// classroom and, when date is set, their availability on that day. An
+// ineligible teacher is only placed when an admin overrides the check; the
+// returned record is then kept on the assignment. Override does not reach
+// past this check: guards and placement rules still apply.
+func (s *assignService) checkTeacherEligible(ctx context.Context, classroomID primitive.ObjectID, teacherID string, date *time.Time, override bool, userID string) (*EligibilityOverride, error) {
+
+	profile, err := s.TeacherRepository.GetTeacherProfileByTeacherID(ctx, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements, err := s.TeacherRepository.GetClassroomRequirements(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	reasons := teacher.CheckEligibility(profile, requirements, date)
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+
+	if !override {
+		return nil, teacher.NotEligibleError(teacherID, reasons)
+	}
+
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if currentUser == nil || (currentUser.OrganizationAdmin == nil && !currentUser.IsSuperAdmin) {
+		return nil, ErrOverrideNotAllowed
+	}
+
+	return &EligibilityOverride{
+		TeacherID:    teacherID,
+		Reasons:      reasons,
+		OverriddenBy: userID,
+		OverriddenAt: time.Now(),
+	}, nil
+
+}
+
+func assignmentEvent(eventType string, assignment *TeacherStudentAssignment, userID string) *event.Event {
+	return event.New(eventType, "assignment", assignment.ID.Hex(), map[string]interface{}{
+		"class_room_id": assignment.ClassRoomID.Hex(),
+		"slot_number":   assignment.SlotNumber,
+		"date":          assignment.AssignDate.Format("2006-01-02"),
+		"session_id":    assignment.SessionID,
+		"teacher_id":    assignment.TeacherID,
+		"student_id":    assignment.StudentID,
+		"type":          assignment.Type,
+	}).WithActor(userID)
+}
+
+// NewAssignmentEvent builds the event for a daily assignment written outside
+// this package, e.g. by a closure.
+func NewAssignmentEvent(eventType string, assignment *TeacherStudentAssignment, userID string) *event.Event {
+	return assignmentEvent(eventType, assignment, userID)
+}
+
+func assignmentTemplateEvent(assignment *ClassRoomTemplateAssignment, userID string) *event.Event {
+	return event.New(event.AssignmentTemplateChanged, "assignment_template", assignment.ID.Hex(), map[string]interface{}{
+		"class_room_id": assignment.ClassRoomID.Hex(),
+		"term_id":       assignment.TermID.Hex(),
+		"slot_number":   assignment.SlotNumber,
+		"session_id":    assignment.SessionID,
+		"teacher_id":    assignment.TeacherID,
+		"student_id":    assignment.StudentID,
+		"attendance":    assignment.Attendance,
+		"start_date":    assignment.StartDate,
+		"end_date":      assignment.EndDate,
+	}).WithActor(userID)
+}
+
+func (s *assignService) AssignSlot(ctx context.Context, request *UpdateAssginRequest, userID string) ([]*rule.Violation, error) {
+
+	if request.SlotNumber < -1 || request.SlotNumber > 15 {
+		return nil, errors.New("slot number must be between 1 and 15")
+	}
+
+	if err := validateAssignmentType(request.Type); err != nil {
+		return nil, err
+	}
+
+	classroomObjID, err := primitive.ObjectIDFromHex(request.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	dateParse, err := time.Parse("2006-01-02", request.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := s.resolveSession(ctx, classroomObjID, request.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var eligibility *EligibilityOverride
+	if request.TeacherID != nil {
+		eligibility, err = s.checkTeacherEligible(ctx, classroomObjID, *request.TeacherID, &dateParse, request.Override, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var warnings []*rule.Violation
+
+	existingAssignment, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, classroomObjID, request.SlotNumber, &dateParse, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var before *TeacherStudentAssignment
+	if existingAssignment != nil {
+		stored := *existingAssignment
+		before = &stored
+	}
+
 	if existingAssignment == nil {
+		assignmentType := request.Type
+		if assignmentType == "" {
+			assignmentType = AssignmentTypeRegular
+		}
+		if assignmentType != AssignmentTypeRegular && request.StudentID == nil {
+			return nil, ErrGuestWithoutStudent
+		}
+
+		if err := s.checkSlotFreeOnDate(ctx, classroomObjID, request.SlotNumber, dateParse, sessionID, nil); err != nil {
+			return nil, err
+		}
+
 		if request.StudentID != nil {
-			check, err := s.AssignRepository.CheckDuplicateAssignmentStudent(ctx, dateParse, *request.StudentID)
-			if err != nil {
-				return err
+			if err := s.checkStudentFreeOnDate(ctx, dateParse, *request.StudentID, sessionID); err != nil {
+				return nil, err
 			}
-			if check {
-				return errors.New("student already assigned to another class in")
+			warnings, err = s.checkRules(ctx, &rule.Placement{
+				StudentID:   *request.StudentID,
+				ClassRoomID: classroomObjID,
+				TeacherID:   request.TeacherID,
+			}, &dateParse, nil)
+			if err != nil {
+				return nil, err
 			}
 		}
 		newAssignment := &TeacherStudentAssignment{
@@ -61,55 +344,130 @@ func (s *assignService) AssignSlot(ctx context.Context, request *UpdateAssginReq
 			ClassRoomID:    classroomObjID,
 			SlotNumber:     request.SlotNumber,
 			AssignDate:     dateParse,
+			SessionID:      sessionID,
 			TeacherID:      request.TeacherID,
 			StudentID:      request.StudentID,
+			Type:           assignmentType,
 			CreatedBy:      userID,
 			IsNotification: false,
 			CreatedAt:      time.Now(),
 			UpdatedAt:      time.Now(),
 		}
+		if request.TeacherID != nil {
+			newAssignment.EligibilityOverride = eligibility
+		}
+
+		err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+			if err := s.checkGuards(ctx, nil, newAssignment); err != nil {
+				return err
+			}
+
+			if err := s.AssignRepository.CreateAssignment(ctx, newAssignment); err != nil {
+				return err
+			}
+
+			return event.Record(ctx, s.EventPublisher, assignmentEvent(event.AssignmentCreated, newAssignment, userID))
+
+		})
+		if err != nil {
+			return nil, err
+		}
 
-		return s.AssignRepository.CreateAssignment(ctx, newAssignment)
+		return warnings, nil
 	} else {
 		if request.TeacherID != nil {
 			if existingAssignment.StudentID != nil {
-				exists, err := s.AssignRepository.CheckDuplicateAssignmentForDate(ctx, classroomObjID, dateParse, *existingAssignment.StudentID, *request.TeacherID)
+				exists, err := s.AssignRepository.CheckDuplicateAssignmentForDate(ctx, classroomObjID, dateParse, sessionID, *existingAssignment.StudentID, *request.TeacherID)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				if exists {
-					return errors.New("student already assigned to teacher")
+					return nil, errors.New("student already assigned to teacher")
 				}
 			}
 			existingAssignment.TeacherID = request.TeacherID
+			existingAssignment.EligibilityOverride = eligibility
 		}
 		if request.StudentID != nil {
 			if existingAssignment.TeacherID != nil {
-				exists, err := s.AssignRepository.CheckDuplicateAssignmentForDate(ctx, classroomObjID, dateParse, *request.StudentID, *existingAssignment.TeacherID)
+				exists, err := s.AssignRepository.CheckDuplicateAssignmentForDate(ctx, classroomObjID, dateParse, sessionID, *request.StudentID, *existingAssignment.TeacherID)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				if exists {
-					return errors.New("teacher already assigned to student")
+					return nil, errors.New("teacher already assigned to student")
+				}
+			}
+			if existingAssignment.StudentID == nil || *existingAssignment.StudentID != *request.StudentID {
+				if err := s.checkStudentFreeOnDate(ctx, dateParse, *request.StudentID, sessionID); err != nil {
+					return nil, err
 				}
 			}
 			existingAssignment.StudentID = request.StudentID
 		}
 
+		// A new student starts over as a regular assignment unless the
+		// request says otherwise.
+		if request.Type != "" {
+			existingAssignment.Type = request.Type
+		} else if request.StudentID != nil && (before.StudentID == nil || *before.StudentID != *request.StudentID) {
+			existingAssignment.Type = AssignmentTypeRegular
+		}
+		if before.StudentID == nil || existingAssignment.StudentID == nil || *before.StudentID != *existingAssignment.StudentID {
+			existingAssignment.ConvertedTemplateID = nil
+		}
+		if existingAssignment.IsGuest() && existingAssignment.StudentID == nil {
+			return nil, ErrGuestWithoutStudent
+		}
+
+		if existingAssignment.StudentID != nil {
+			warnings, err = s.checkRules(ctx, &rule.Placement{
+				StudentID:   *existingAssignment.StudentID,
+				ClassRoomID: classroomObjID,
+				TeacherID:   existingAssignment.TeacherID,
+			}, &dateParse, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		assign := &TeacherStudentAssignment{
-			ID:             existingAssignment.ID,
-			ClassRoomID:    existingAssignment.ClassRoomID,
-			SlotNumber:     existingAssignment.SlotNumber,
-			AssignDate:     existingAssignment.AssignDate,
-			TeacherID:      existingAssignment.TeacherID,
-			StudentID:      existingAssignment.StudentID,
-			CreatedBy:      existingAssignment.CreatedBy,
-			IsNotification: existingAssignment.IsNotification,
-			CreatedAt:      existingAssignment.CreatedAt,
-			UpdatedAt:      time.Now(),
+			ID:                  existingAssignment.ID,
+			ClassRoomID:         existingAssignment.ClassRoomID,
+			SlotNumber:          existingAssignment.SlotNumber,
+			AssignDate:          existingAssignment.AssignDate,
+			SessionID:           existingAssignment.SessionID,
+			TeacherID:           existingAssignment.TeacherID,
+			StudentID:           existingAssignment.StudentID,
+			Type:                existingAssignment.Type,
+			ConvertedTemplateID: existingAssignment.ConvertedTemplateID,
+			EligibilityOverride: existingAssignment.EligibilityOverride,
+			ClosureID:           existingAssignment.ClosureID,
+			CreatedBy:           existingAssignment.CreatedBy,
+			IsNotification:      existingAssignment.IsNotification,
+			CreatedAt:           existingAssignment.CreatedAt,
+			UpdatedAt:           time.Now(),
 		}
 
-		return s.AssignRepository.UpdateAssgin(ctx, assign.ID, assign)
+		err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+			if err := s.checkGuards(ctx, before, assign); err != nil {
+				return err
+			}
+
+			if err := s.AssignRepository.UpdateAssgin(ctx, assign.ID, assign); err != nil {
+				return err
+			}
+
+			return event.Record(ctx, s.EventPublisher, assignmentEvent(event.AssignmentChanged, assign, userID))
+
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return warnings, nil
 	}
 }
 
@@ -133,7 +491,12 @@ func (s *assignService) UnAssignSlot(ctx context.Context, request *UpdateAssginR
 		return err
 	}
 
-	assign, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, classroomObjID, request.SlotNumber, &dateParse)
+	sessionID, err := s.resolveSession(ctx, classroomObjID, request.SessionID)
+	if err != nil {
+		return err
+	}
+
+	assign, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, classroomObjID, request.SlotNumber, &dateParse, sessionID)
 	if err != nil {
 		return err
 	}
@@ -141,60 +504,182 @@ func (s *assignService) UnAssignSlot(ctx context.Context, request *UpdateAssginR
 		return errors.New("assign not found")
 	}
 
+	released := request.StudentID != nil && assign.StudentID != nil
+	before := *assign
+
 	if request.TeacherID != nil {
 		assign.TeacherID = nil
 	}
 	if request.StudentID != nil {
 		assign.StudentID = nil
+		if assign.IsGuest() {
+			assign.Type = AssignmentTypeRegular
+		}
+		assign.ConvertedTemplateID = nil
+	}
+
+	if err := s.checkGuards(ctx, &before, assign); err != nil {
+		return err
+	}
+
+	if err := s.AssignRepository.UpdateAssgin(ctx, assign.ID, assign); err != nil {
+		return err
 	}
 
-	return s.AssignRepository.UpdateAssgin(ctx, assign.ID, assign)
+	event.Emit(ctx, s.EventPublisher, assignmentEvent(event.AssignmentChanged, assign, userID))
+
+	if released {
+		release := &SlotRelease{
+			ClassRoomID: classroomObjID,
+			SlotNumber:  assign.SlotNumber,
+			SessionID:   sessionID,
+			Date:        &dateParse,
+			ReleasedBy:  userID,
+		}
+		if termObjID, err := primitive.ObjectIDFromHex(request.TermID); err == nil {
+			release.TermID = &termObjID
+		}
+		s.notifySlotReleased(ctx, release)
+	}
+
+	return nil
 }
 
-func (s *assignService) CreateAssignmentTemplate(ctx context.Context, request *UpdateAssginRequest, userID string) error {
+func (s *assignService) CreateAssignmentTemplate(ctx context.Context, request *UpdateAssginRequest, userID string) ([]*rule.Violation, error) {
 
 	if request.SlotNumber < -1 || request.SlotNumber > 15 {
-		return errors.New("slot number must be between 1 and 15")
+		return nil, errors.New("slot number must be between 1 and 15")
 	}
 
 	classroomObjID, err := primitive.ObjectIDFromHex(request.ClassroomID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	termObjID, err := primitive.ObjectIDFromHex(request.TermID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	existingAssignment, err := s.AssignRepository.GetAssignmentTemplateBySlot(ctx, classroomObjID, termObjID, request.SlotNumber)
+	pattern, patternSet, err := attendanceFromRequest(request)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	sessionID, err := s.resolveSession(ctx, classroomObjID, request.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A template spans the whole term, so only qualifications are checked
+	// here; availability is applied day by day when the template is expanded.
+	var eligibility *EligibilityOverride
+	if request.TeacherID != nil {
+		eligibility, err = s.checkTeacherEligible(ctx, classroomObjID, *request.TeacherID, nil, request.Override, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slotTemplates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, classroomObjID, termObjID, request.SlotNumber, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Part-time students share a slot through one template each. Without a
+	// student the request only changes the teacher, which applies to every
+	// template of the slot.
+	if request.StudentID == nil && len(slotTemplates) > 1 {
+		if patternSet {
+			return nil, errors.New("student_id is required to change the attendance of a shared slot")
+		}
+		return s.updateSlotTeacher(ctx, slotTemplates, request, eligibility, userID)
+	}
+
+	var existingAssignment *ClassRoomTemplateAssignment
+	if request.StudentID != nil {
+		for _, t := range slotTemplates {
+			if t.StudentID != nil && *t.StudentID == *request.StudentID {
+				existingAssignment = t
+				break
+			}
+		}
+		if existingAssignment == nil {
+			for _, t := range slotTemplates {
+				if t.StudentID == nil {
+					existingAssignment = t
+					break
+				}
+			}
+		}
+		// Without an attendance pattern a new student replaces the one in
+		// a single-template slot, as before part-time students existed. Only
+		// a request with weekdays adds a second template to the slot.
+		if existingAssignment == nil && pattern == nil && len(slotTemplates) == 1 {
+			existingAssignment = slotTemplates[0]
+		}
+	} else if len(slotTemplates) == 1 {
+		existingAssignment = slotTemplates[0]
 	}
 
 	if existingAssignment == nil {
 		if request.StudentID != nil {
-			exists, err := s.AssignRepository.CheckStudentExistingInTerm(ctx, termObjID, *request.StudentID)
-			if err != nil {
-				return err
-			}
-			if exists {
-				return errors.New("student already assigned to another class in this region for the same term")
+			if err := s.checkStudentFreeInTerm(ctx, termObjID, *request.StudentID, sessionID, primitive.NilObjectID); err != nil {
+				return nil, err
 			}
 		}
+
+		teacherID := request.TeacherID
+		if teacherID == nil && len(slotTemplates) > 0 {
+			teacherID = slotTemplates[0].TeacherID
+			eligibility = slotTemplates[0].EligibilityOverride
+		}
+
 		newAssignment := &ClassRoomTemplateAssignment{
 			ID:          primitive.NewObjectID(),
 			ClassRoomID: classroomObjID,
 			TermID:      termObjID,
 			SlotNumber:  request.SlotNumber,
-			TeacherID:   request.TeacherID,
+			SessionID:   sessionID,
+			TeacherID:   teacherID,
 			StudentID:   request.StudentID,
+			Attendance:  pattern,
 			CreatedBy:   userID,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
+		newAssignment.EligibilityOverride = eligibility
+
+		if err := checkAttendanceOverlap(newAssignment, slotTemplates); err != nil {
+			return nil, err
+		}
+
+		var warnings []*rule.Violation
+		if newAssignment.StudentID != nil {
+			warnings, err = s.checkRules(ctx, &rule.Placement{
+				StudentID:   *newAssignment.StudentID,
+				ClassRoomID: classroomObjID,
+				TeacherID:   newAssignment.TeacherID,
+			}, nil, &termObjID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+			if err := s.AssignRepository.CreateAssignmentTemplate(ctx, newAssignment); err != nil {
+				return err
+			}
 
-		return s.AssignRepository.CreateAssignmentTemplate(ctx, newAssignment)
+			return event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(newAssignment, userID))
+
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return warnings, nil
 	} else {
 		if request.TeacherID != nil {
 			if existingAssignment.StudentID != nil {
@@ -202,26 +687,26 @@ func (s *assignService) CreateAssignmentTemplate(ctx context.Context, request *U
 					ctx,
 					classroomObjID,
 					termObjID,
+					sessionID,
 					*existingAssignment.StudentID,
 					*request.TeacherID,
 				)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				if exists {
-					return errors.New("teacher already assigned to this student")
+					return nil, errors.New("teacher already assigned to this student")
 				}
 			}
 			existingAssignment.TeacherID = request.TeacherID
+			existingAssignment.EligibilityOverride = eligibility
 		}
 
-		if request.StudentID != nil {
-			exists, err := s.AssignRepository.CheckStudentExistingInTerm(ctx, termObjID, *request.StudentID)
-			if err != nil {
-				return err
-			}
-			if exists {
-				return errors.New("student already assigned to another class in this region for the same term")
+		sameStudent := request.StudentID != nil && existingAssignment.StudentID != nil && *existingAssignment.StudentID == *request.StudentID
+
+		if request.StudentID != nil && !sameStudent {
+			if err := s.checkStudentFreeInTerm(ctx, termObjID, *request.StudentID, sessionID, existingAssignment.ID); err != nil {
+				return nil, err
 			}
 
 			if existingAssignment.TeacherID != nil {
@@ -229,22 +714,241 @@ func (s *assignService) CreateAssignmentTemplate(ctx context.Context, request *U
 					ctx,
 					classroomObjID,
 					termObjID,
+					sessionID,
 					*request.StudentID,
 					*existingAssignment.TeacherID,
 				)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				if exists {
-					return errors.New("student already assigned to this teacher")
+					return nil, errors.New("student already assigned to this teacher")
 				}
 			}
 			existingAssignment.StudentID = request.StudentID
 		}
 
-		return s.AssignRepository.UpdateAssginTemplate(ctx, existingAssignment.ID, existingAssignment)
+		if patternSet {
+			existingAssignment.Attendance = pattern
+		}
+
+		if err := checkAttendanceOverlap(existingAssignment, slotTemplates); err != nil {
+			return nil, err
+		}
+
+		var warnings []*rule.Violation
+		if existingAssignment.StudentID != nil {
+			warnings, err = s.checkRules(ctx, &rule.Placement{
+				StudentID:   *existingAssignment.StudentID,
+				ClassRoomID: classroomObjID,
+				TeacherID:   existingAssignment.TeacherID,
+			}, nil, &termObjID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+			if err := s.AssignRepository.UpdateAssginTemplate(ctx, existingAssignment.ID, existingAssignment); err != nil {
+				return err
+			}
+
+			return event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(existingAssignment, userID))
+
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return warnings, nil
+	}
+
+}
+
+// CheckAssignmentTemplate runs the checks CreateAssignmentTemplate makes
+// before it adds a template, for writers outside this package such as the
+// term rollover. The session must be one of the classroom's, the teacher
+// must be eligible unless the template carries an override, and the
+// student must be free in the term and fit the placement rules. Daily
+// guards such as ratios apply when the template is expanded.
+func (s *assignService) CheckAssignmentTemplate(ctx context.Context, template *ClassRoomTemplateAssignment) ([]*rule.Violation, error) {
+
+	sessionID := ""
+	if template.SessionID != nil {
+		sessionID = template.SessionID.Hex()
+	}
+	if _, err := s.resolveSession(ctx, template.ClassRoomID, sessionID); err != nil {
+		return nil, err
+	}
+
+	if template.TeacherID != nil && template.EligibilityOverride == nil {
+		if _, err := s.checkTeacherEligible(ctx, template.ClassRoomID, *template.TeacherID, nil, false, template.CreatedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	slotTemplates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, template.ClassRoomID, template.TermID, template.SlotNumber, template.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAttendanceOverlap(template, slotTemplates); err != nil {
+		return nil, err
+	}
+
+	if template.StudentID == nil {
+		return nil, nil
+	}
+
+	if err := s.checkStudentFreeInTerm(ctx, template.TermID, *template.StudentID, template.SessionID, template.ID); err != nil {
+		return nil, err
+	}
+
+	return s.checkRules(ctx, &rule.Placement{
+		StudentID:   *template.StudentID,
+		ClassRoomID: template.ClassRoomID,
+		TeacherID:   template.TeacherID,
+	}, nil, &template.TermID)
+
+}
+
+func (s *assignService) updateSlotTeacher(ctx context.Context, slotTemplates []*ClassRoomTemplateAssignment, request *UpdateAssginRequest, eligibility *EligibilityOverride, userID string) ([]*rule.Violation, error) {
+
+	if request.TeacherID == nil {
+		return nil, errors.New("teacher_id or student_id is required")
+	}
+
+	var warnings []*rule.Violation
+
+	for _, t := range slotTemplates {
+		if t.StudentID != nil {
+			exists, err := s.AssignRepository.CheckDuplicateAssignmentTemplate(ctx, t.ClassRoomID, t.TermID, t.SessionID, *t.StudentID, *request.TeacherID)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				return nil, errors.New("teacher already assigned to this student")
+			}
+
+			violations, err := s.checkRules(ctx, &rule.Placement{
+				StudentID:   *t.StudentID,
+				ClassRoomID: t.ClassRoomID,
+				TeacherID:   request.TeacherID,
+			}, nil, &t.TermID)
+			if err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, violations...)
+		}
+	}
+
+	err := s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		for _, stored := range slotTemplates {
+			t := *stored
+			t.TeacherID = request.TeacherID
+			t.EligibilityOverride = eligibility
+			t.UpdatedAt = time.Now()
+			if err := s.AssignRepository.UpdateAssginTemplate(ctx, t.ID, &t); err != nil {
+				return err
+			}
+			if err := event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(&t, userID)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return warnings, nil
+
+}
+
+// attendanceFromRequest builds the attendance pattern of a template request.
+// The second result is false when the request leaves the pattern unchanged.
+func attendanceFromRequest(request *UpdateAssginRequest) (*AttendancePattern, bool, error) {
+
+	if request.Weekdays == nil && request.AlternateWeekdays == nil {
+		return nil, false, nil
+	}
+
+	if len(request.Weekdays) == 0 && len(request.AlternateWeekdays) == 0 {
+		return nil, true, nil
+	}
+
+	weekdays, err := parseWeekdays(request.Weekdays)
+	if err != nil {
+		return nil, false, err
+	}
+
+	alternate, err := parseWeekdays(request.AlternateWeekdays)
+	if err != nil {
+		return nil, false, err
+	}
+
+	pattern := &AttendancePattern{
+		Weekdays:          weekdays,
+		AlternateWeekdays: alternate,
+	}
+
+	if len(alternate) > 0 {
+		if request.AnchorDate == "" {
+			return nil, false, errors.New("anchor_date is required for alternate-week attendance")
+		}
+		anchor, err := time.Parse("2006-01-02", request.AnchorDate)
+		if err != nil {
+			return nil, false, err
+		}
+		pattern.AnchorDate = &anchor
+	}
+
+	return pattern, true, nil
+
+}
+
+func parseWeekdays(values []int) ([]time.Weekday, error) {
+
+	weekdays := make([]time.Weekday, 0, len(values))
+	seen := make(map[int]bool)
+	for _, w := range values {
+		if w < int(time.Sunday) || w > int(time.Saturday) {
+			return nil, fmt.Errorf("invalid weekday %d, must be between 0 (sunday) and 6 (saturday)", w)
+		}
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		weekdays = append(weekdays, time.Weekday(w))
 	}
 
+	return weekdays, nil
+
+}
+
+// checkAttendanceOverlap makes sure no two students of one slot attend on
+// the same day.
+func checkAttendanceOverlap(target *ClassRoomTemplateAssignment, slotTemplates []*ClassRoomTemplateAssignment) error {
+
+	if target.StudentID == nil {
+		return nil
+	}
+
+	for _, t := range slotTemplates {
+		if t.ID == target.ID || t.StudentID == nil || !target.periodOverlaps(t) {
+			continue
+		}
+		if target.Attendance.Overlaps(t.Attendance) {
+			return fmt.Errorf("slot %d is already taken by student %s on some of these days", target.SlotNumber, *t.StudentID)
+		}
+	}
+
+	return nil
+
 }
 
 func (s *assignService) DeleteAssignmentTemplate(ctx context.Context, request *UpdateAssginRequest, userID string) error {
@@ -263,22 +967,180 @@ func (s *assignService) DeleteAssignmentTemplate(ctx context.Context, request *U
 		return err
 	}
 
-	assign, err := s.AssignRepository.GetAssignmentTemplateBySlot(ctx, classroomObjID, termObjID, request.SlotNumber)
+	sessionID, err := s.resolveSession(ctx, classroomObjID, request.SessionID)
 	if err != nil {
 		return err
 	}
 
-	if assign == nil {
+	slotTemplates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, classroomObjID, termObjID, request.SlotNumber, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if len(slotTemplates) == 0 {
 		return errors.New("assign not found")
 	}
 
-	if request.TeacherID != nil {
-		assign.TeacherID = nil
+	// In a shared slot a student is removed from their own template only,
+	// while removing the teacher clears the whole slot.
+	targets := slotTemplates
+	if request.StudentID != nil && len(slotTemplates) > 1 {
+		targets = nil
+		for _, t := range slotTemplates {
+			if t.StudentID != nil && *t.StudentID == *request.StudentID {
+				targets = append(targets, t)
+			}
+		}
+		if len(targets) == 0 {
+			return errors.New("assign not found")
+		}
 	}
-	if request.StudentID != nil {
-		assign.StudentID = nil
+
+	err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		for _, stored := range targets {
+			assign := *stored
+			if request.TeacherID != nil {
+				assign.TeacherID = nil
+			}
+			if request.StudentID != nil {
+				assign.StudentID = nil
+				assign.Attendance = nil
+			}
+
+			if err := s.AssignRepository.UpdateAssginTemplate(ctx, assign.ID, &assign); err != nil {
+				return err
+			}
+
+			if err := event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(&assign, userID)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, assign := range targets {
+		released := request.StudentID != nil && assign.StudentID != nil
+
+		if released {
+			s.notifySlotReleased(ctx, &SlotRelease{
+				ClassRoomID: classroomObjID,
+				TermID:      &termObjID,
+				SlotNumber:  assign.SlotNumber,
+				SessionID:   sessionID,
+				ReleasedBy:  userID,
+			})
+		}
+	}
+
+	return nil
+
+}
+
+func (s *assignService) GetEligibleTeachers(ctx context.Context, classroomID, date, sessionID string, slotNumber int) (*EligibleTeachersResponse, error) {
+
+	if slotNumber < 1 || slotNumber > 15 {
+		return nil, errors.New("slot number must be between 1 and 15")
+	}
+
+	classroomObjID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	dateParse, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements, err := s.TeacherRepository.GetClassroomRequirements(ctx, classroomObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if requirements == nil {
+		return nil, errors.New("classroom not found")
+	}
+
+	profiles, err := s.TeacherRepository.GetTeacherProfilesByOrganization(ctx, requirements.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	objSessionID, err := s.resolveSession(ctx, classroomObjID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, classroomObjID, slotNumber, &dateParse, objSessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &EligibleTeachersResponse{
+		ClassroomID: classroomID,
+		Date:        date,
+		SessionID:   sessionID,
+		SlotNumber:  slotNumber,
+		Eligible:    make([]*EligibleTeacher, 0),
+		Ineligible:  make([]*IneligibleTeacher, 0),
+	}
+
+	// Teachers placed in the organization without a profile are listed too;
+	// like everywhere else they are not constrained.
+	teacherIDs, err := s.AssignRepository.GetTeacherIDsByOrganization(ctx, requirements.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	profileByTeacher := make(map[string]*teacher.TeacherProfile, len(profiles))
+	order := make([]string, 0, len(profiles)+len(teacherIDs))
+	for _, profile := range profiles {
+		profileByTeacher[profile.TeacherID] = profile
+		order = append(order, profile.TeacherID)
+	}
+	for _, id := range teacherIDs {
+		if _, ok := profileByTeacher[id]; !ok {
+			profileByTeacher[id] = nil
+			order = append(order, id)
+		}
+	}
+
+	for _, teacherID := range order {
+		profile := profileByTeacher[teacherID]
+		reasons := teacher.CheckEligibility(profile, requirements, &dateParse)
+
+		// Same rule as AssignSlot: a student is paired with a teacher at
+		// most once per classroom, day and session.
+		if current != nil && current.StudentID != nil {
+			duplicate, err := s.AssignRepository.CheckDuplicateAssignmentForDate(ctx, classroomObjID, dateParse, objSessionID, *current.StudentID, teacherID)
+			if err != nil {
+				return nil, err
+			}
+			if duplicate {
+				reasons = append(reasons, fmt.Sprintf("already assigned to student %s on this day", *current.StudentID))
+			}
+		}
+
+		if len(reasons) > 0 {
+			response.Ineligible = append(response.Ineligible, &IneligibleTeacher{
+				TeacherID: teacherID,
+				Reasons:   reasons,
+			})
+			continue
+		}
+
+		response.Eligible = append(response.Eligible, &EligibleTeacher{
+			TeacherID: teacherID,
+			Profile:   profile,
+		})
 	}
 
-	return s.AssignRepository.UpdateAssginTemplate(ctx, assign.ID, assign)
+	return response, nil
 
 }
@@ -0,0 +1,102 @@
+package assign
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func template(studentID string, pattern *AttendancePattern) *ClassRoomTemplateAssignment {
+
+	t := &ClassRoomTemplateAssignment{
+		ID:         primitive.NewObjectID(),
+		SlotNumber: 1,
+		Attendance: pattern,
+	}
+	if studentID != "" {
+		t.StudentID = &studentID
+	}
+
+	return t
+
+}
+
+func weekdays(days ...time.Weekday) *AttendancePattern {
+	return &AttendancePattern{Weekdays: days}
+}
+
+func TestCheckAttendanceOverlap(t *testing.T) {
+
+	anchor := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	alternate := func(days, alternateDays []time.Weekday) *AttendancePattern {
+		return &AttendancePattern{Weekdays: days, AlternateWeekdays: alternateDays, AnchorDate: &anchor}
+	}
+
+	taken := template("a", weekdays(time.Monday, time.Wednesday))
+
+	tests := []struct {
+		name    string
+		target  *ClassRoomTemplateAssignment
+		slot    []*ClassRoomTemplateAssignment
+		wantErr bool
+	}{
+		{
+			name:   "empty slot",
+			target: template("b", nil),
+		},
+		{
+			name:    "every day collides with any pattern",
+			target:  template("b", nil),
+			slot:    []*ClassRoomTemplateAssignment{taken},
+			wantErr: true,
+		},
+		{
+			name:    "shared weekday",
+			target:  template("b", weekdays(time.Wednesday, time.Friday)),
+			slot:    []*ClassRoomTemplateAssignment{taken},
+			wantErr: true,
+		},
+		{
+			name:   "different weekdays",
+			target: template("b", weekdays(time.Tuesday, time.Thursday)),
+			slot:   []*ClassRoomTemplateAssignment{taken},
+		},
+		{
+			name:   "alternate weeks",
+			target: template("b", alternate([]time.Weekday{time.Friday}, []time.Weekday{time.Monday})),
+			slot:   []*ClassRoomTemplateAssignment{template("a", alternate([]time.Weekday{time.Tuesday}, []time.Weekday{time.Friday}))},
+		},
+		{
+			name:    "alternate week shared",
+			target:  template("b", alternate([]time.Weekday{time.Friday}, []time.Weekday{time.Monday})),
+			slot:    []*ClassRoomTemplateAssignment{template("a", weekdays(time.Monday))},
+			wantErr: true,
+		},
+		{
+			name:   "template without a student",
+			target: template("b", nil),
+			slot:   []*ClassRoomTemplateAssignment{template("", nil)},
+		},
+		{
+			name:   "target without a student",
+			target: template("", nil),
+			slot:   []*ClassRoomTemplateAssignment{taken},
+		},
+		{
+			name:   "target itself",
+			target: taken,
+			slot:   []*ClassRoomTemplateAssignment{taken},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAttendanceOverlap(tt.target, tt.slot)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkAttendanceOverlap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+}
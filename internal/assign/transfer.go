@@ -0,0 +1,521 @@
+package assign
+
+import (
+	"classroom-service/internal/event"
+	"classroom-service/internal/rule"
+	"classroom-service/internal/session"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// dailyMove pairs a daily assignment the student leaves with the one they
+// take over in the target slot, which is nil when it has to be created.
+// sourceAfter and targetAfter are the documents as they will be written;
+// they are built before the transaction so a retried transaction writes the
+// same documents again.
+type dailyMove struct {
+	source      *TeacherStudentAssignment
+	target      *TeacherStudentAssignment
+	sourceAfter *TeacherStudentAssignment
+	targetAfter *TeacherStudentAssignment
+}
+
+func sameSession(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// mergeViolations appends the violations of more not yet in violations.
+func mergeViolations(violations, more []*rule.Violation) []*rule.Violation {
+	for _, v := range more {
+		seen := false
+		for _, w := range violations {
+			if w.RuleID == v.RuleID && w.Message == v.Message {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// TransferStudent moves a student to a slot of another classroom from the
+// effective date on. Placement rules are checked before anything is written
+// and the writes run in one transaction with the assignment guards, so a
+// rejected transfer leaves both classrooms untouched.
+func (s *assignService) TransferStudent(ctx context.Context, request *TransferStudentRequest, userID string) (*StudentTransfer, error) {
+
+	if request.StudentID == "" {
+		return nil, errors.New("student_id is required")
+	}
+
+	if request.SlotNumber < 1 || request.SlotNumber > 15 {
+		return nil, errors.New("slot number must be between 1 and 15")
+	}
+
+	fromObjID, err := primitive.ObjectIDFromHex(request.FromClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	toObjID, err := primitive.ObjectIDFromHex(request.ToClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	termObjID, err := primitive.ObjectIDFromHex(request.TermID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.EffectiveDate == "" {
+		return nil, errors.New("effective_date is required")
+	}
+
+	effectiveDate, err := time.Parse("2006-01-02", request.EffectiveDate)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements, err := s.TeacherRepository.GetClassroomRequirements(ctx, toObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if requirements == nil {
+		return nil, fmt.Errorf("target classroom %s not found", request.ToClassroomID)
+	}
+
+	sessionID, err := s.resolveSession(ctx, toObjID, request.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var eligibility *EligibilityOverride
+	if request.TeacherID != nil {
+		eligibility, err = s.checkTeacherEligible(ctx, toObjID, *request.TeacherID, nil, request.Override, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	studentTemplates, err := s.AssignRepository.GetAssignmentTemplateByTermIDAndStudentID(ctx, request.StudentID, termObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Source templates are the ones in the old classroom still running on
+	// the effective date or later.
+	var sources, others []*ClassRoomTemplateAssignment
+	for _, t := range studentTemplates {
+		if t.ClassRoomID == fromObjID && (t.EndDate == nil || !t.EndDate.Before(effectiveDate)) {
+			sources = append(sources, t)
+		} else {
+			others = append(others, t)
+		}
+	}
+
+	for _, t := range sources {
+		if t.ClassRoomID == toObjID && t.SlotNumber == request.SlotNumber && sameSession(t.SessionID, sessionID) {
+			return nil, errors.New("student is already in this slot")
+		}
+	}
+
+	dailies, err := s.AssignRepository.GetAssignmentsByStudentFromDate(ctx, fromObjID, request.StudentID, effectiveDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sources) == 0 && len(dailies) == 0 {
+		return nil, errors.New("student is not assigned to the classroom on or after the effective date")
+	}
+
+	target := &ClassRoomTemplateAssignment{
+		ID:          primitive.NewObjectID(),
+		ClassRoomID: toObjID,
+		TermID:      termObjID,
+		SlotNumber:  request.SlotNumber,
+		SessionID:   sessionID,
+		TeacherID:   request.TeacherID,
+		StudentID:   &request.StudentID,
+		StartDate:   &effectiveDate,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	target.EligibilityOverride = eligibility
+	if len(sources) > 0 {
+		target.Attendance = sources[0].Attendance
+	}
+
+	slotTemplates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, toObjID, termObjID, request.SlotNumber, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Templates of the target slot without a student are replaced by the
+	// new one from the effective date, which takes over their teacher.
+	var emptyTemplates []*ClassRoomTemplateAssignment
+	for _, t := range slotTemplates {
+		if !t.periodOverlaps(target) {
+			continue
+		}
+		if t.StudentID == nil {
+			emptyTemplates = append(emptyTemplates, t)
+		}
+		if target.TeacherID == nil && t.TeacherID != nil {
+			target.TeacherID = t.TeacherID
+			target.EligibilityOverride = t.EligibilityOverride
+		}
+	}
+
+	if err := checkAttendanceOverlap(target, slotTemplates); err != nil {
+		return nil, err
+	}
+
+	otherSessions := make([]*primitive.ObjectID, 0, len(others))
+	for _, t := range others {
+		if t.periodOverlaps(target) {
+			otherSessions = append(otherSessions, t.SessionID)
+		}
+	}
+
+	i, err := session.FindConflict(ctx, s.SessionRepository, sessionID, otherSessions)
+	if err != nil {
+		return nil, err
+	}
+	if i >= 0 {
+		return nil, errors.New("student already assigned to another class for the same term and session")
+	}
+
+	sourceIDs := make(map[primitive.ObjectID]bool, len(dailies))
+	for _, a := range dailies {
+		sourceIDs[a.ID] = true
+	}
+
+	moves := make([]*dailyMove, 0, len(dailies))
+	movedDates := make(map[string]bool, len(dailies))
+	for _, a := range dailies {
+		date := a.AssignDate.Format("2006-01-02")
+
+		existing, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, toObjID, request.SlotNumber, &a.AssignDate, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.StudentID != nil && *existing.StudentID != request.StudentID {
+			return nil, fmt.Errorf("slot %d is already taken on %s", request.SlotNumber, date)
+		}
+
+		// The student may have several sessions in the old classroom on one
+		// day; they all move to the single target slot.
+		if movedDates[date] {
+			moves = append(moves, &dailyMove{source: a})
+			continue
+		}
+		movedDates[date] = true
+
+		dayAssignments, err := s.AssignRepository.GetAssignmentsByStudentAndDate(ctx, a.AssignDate, request.StudentID)
+		if err != nil {
+			return nil, err
+		}
+
+		daySessions := make([]*primitive.ObjectID, 0, len(dayAssignments))
+		for _, d := range dayAssignments {
+			if sourceIDs[d.ID] || (existing != nil && d.ID == existing.ID) {
+				continue
+			}
+			daySessions = append(daySessions, d.SessionID)
+		}
+
+		i, err := session.FindConflict(ctx, s.SessionRepository, sessionID, daySessions)
+		if err != nil {
+			return nil, err
+		}
+		if i >= 0 {
+			return nil, fmt.Errorf("student already assigned to another class in this session on %s", date)
+		}
+
+		if existing == nil {
+			existing = &TeacherStudentAssignment{
+				ClassRoomID: toObjID,
+				SlotNumber:  request.SlotNumber,
+				AssignDate:  a.AssignDate,
+				SessionID:   sessionID,
+			}
+		}
+		moves = append(moves, &dailyMove{source: a, target: existing})
+	}
+
+	warnings, err := s.checkRules(ctx, &rule.Placement{
+		StudentID:   request.StudentID,
+		ClassRoomID: toObjID,
+		TeacherID:   target.TeacherID,
+	}, nil, &termObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range moves {
+		if m.target == nil {
+			continue
+		}
+
+		teacherID := m.target.TeacherID
+		if teacherID == nil {
+			teacherID = target.TeacherID
+		}
+
+		violations, err := s.checkRules(ctx, &rule.Placement{
+			StudentID:   request.StudentID,
+			ClassRoomID: toObjID,
+			TeacherID:   teacherID,
+		}, &m.target.AssignDate, nil)
+		if err != nil {
+			return nil, err
+		}
+		warnings = mergeViolations(warnings, violations)
+	}
+
+	transfer := &StudentTransfer{
+		ID:              primitive.NewObjectID(),
+		StudentID:       request.StudentID,
+		TermID:          termObjID,
+		FromClassRoomID: fromObjID,
+		ToClassRoomID:   toObjID,
+		ToSlotNumber:    request.SlotNumber,
+		SessionID:       sessionID,
+		EffectiveDate:   effectiveDate,
+		Note:            request.Note,
+		CreatedBy:       userID,
+		CreatedAt:       time.Now(),
+	}
+	if len(sources) > 0 {
+		transfer.FromSlotNumber = sources[0].SlotNumber
+	} else {
+		transfer.FromSlotNumber = dailies[0].SlotNumber
+	}
+
+	for _, m := range moves {
+		sourceAfter := *m.source
+		sourceAfter.StudentID = nil
+		sourceAfter.UpdatedAt = time.Now()
+		m.sourceAfter = &sourceAfter
+
+		if m.target == nil {
+			continue
+		}
+
+		targetAfter := *m.target
+		targetAfter.StudentID = &request.StudentID
+		if targetAfter.TeacherID == nil {
+			targetAfter.TeacherID = target.TeacherID
+			targetAfter.EligibilityOverride = target.EligibilityOverride
+		}
+		targetAfter.UpdatedAt = time.Now()
+		if targetAfter.ID.IsZero() {
+			// The placeholder built above only marks the slot as free.
+			m.target = nil
+			targetAfter.ID = primitive.NewObjectID()
+			targetAfter.CreatedBy = userID
+			targetAfter.CreatedAt = time.Now()
+		}
+		m.targetAfter = &targetAfter
+		transfer.MovedAssignments++
+	}
+
+	err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		for _, t := range sources {
+			if err := s.endTemplateForStudent(ctx, t, effectiveDate, userID); err != nil {
+				return err
+			}
+		}
+
+		for _, t := range emptyTemplates {
+			if t.StartDate == nil || t.StartDate.Before(effectiveDate) {
+				ended := *t
+				end := effectiveDate.AddDate(0, 0, -1)
+				ended.EndDate = &end
+				ended.UpdatedAt = time.Now()
+				if err := s.AssignRepository.UpdateAssginTemplate(ctx, ended.ID, &ended); err != nil {
+					return err
+				}
+			} else if err := s.AssignRepository.DeleteAssignmentTemplateByID(ctx, t.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := s.AssignRepository.CreateAssignmentTemplate(ctx, target); err != nil {
+			return err
+		}
+
+		if err := event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(target, userID)); err != nil {
+			return err
+		}
+
+		for _, m := range moves {
+			if err := s.checkGuards(ctx, m.source, m.sourceAfter); err != nil {
+				return err
+			}
+			if err := s.AssignRepository.UpdateAssgin(ctx, m.sourceAfter.ID, m.sourceAfter); err != nil {
+				return err
+			}
+			if err := event.Record(ctx, s.EventPublisher, assignmentEvent(event.AssignmentChanged, m.sourceAfter, userID)); err != nil {
+				return err
+			}
+
+			if m.targetAfter == nil {
+				continue
+			}
+
+			if err := s.checkGuards(ctx, m.target, m.targetAfter); err != nil {
+				return err
+			}
+
+			if m.target == nil {
+				if err := s.AssignRepository.CreateAssignment(ctx, m.targetAfter); err != nil {
+					return err
+				}
+				if err := event.Record(ctx, s.EventPublisher, assignmentEvent(event.AssignmentCreated, m.targetAfter, userID)); err != nil {
+					return err
+				}
+			} else {
+				if err := s.AssignRepository.UpdateAssgin(ctx, m.targetAfter.ID, m.targetAfter); err != nil {
+					return err
+				}
+				if err := event.Record(ctx, s.EventPublisher, assignmentEvent(event.AssignmentChanged, m.targetAfter, userID)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.AssignRepository.CreateStudentTransfer(ctx, transfer); err != nil {
+			return err
+		}
+
+		return event.Record(ctx, s.EventPublisher, event.New(event.StudentTransferred, "student", request.StudentID, map[string]interface{}{
+			"term_id":            termObjID.Hex(),
+			"from_class_room_id": fromObjID.Hex(),
+			"from_slot_number":   transfer.FromSlotNumber,
+			"to_class_room_id":   toObjID.Hex(),
+			"to_slot_number":     request.SlotNumber,
+			"session_id":         sessionID,
+			"effective_date":     request.EffectiveDate,
+		}).WithActor(userID))
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.Warnings = warnings
+
+	for _, t := range sources {
+		s.notifySlotReleased(ctx, &SlotRelease{
+			ClassRoomID: t.ClassRoomID,
+			TermID:      &t.TermID,
+			SlotNumber:  t.SlotNumber,
+			SessionID:   t.SessionID,
+			ReleasedBy:  userID,
+		})
+	}
+
+	return transfer, nil
+
+}
+
+// endTemplateForStudent takes the student off a template from the given day
+// on. Days before it keep the student; afterwards the slot keeps its teacher
+// unless other part-time students share it and already carry the teacher.
+// The stored template is left as it is; the change is written from a copy,
+// so the function can run again when a transaction is retried.
+func (s *assignService) endTemplateForStudent(ctx context.Context, stored *ClassRoomTemplateAssignment, from time.Time, userID string) error {
+
+	ended := *stored
+	t := &ended
+
+	if t.StartDate != nil && !t.StartDate.Before(from) {
+		t.StudentID = nil
+		t.Attendance = nil
+		t.UpdatedAt = time.Now()
+		if err := s.AssignRepository.UpdateAssginTemplate(ctx, t.ID, t); err != nil {
+			return err
+		}
+		if err := event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(t, userID)); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	slotTemplates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, t.ClassRoomID, t.TermID, t.SlotNumber, t.SessionID)
+	if err != nil {
+		return err
+	}
+
+	tailEnd := t.EndDate
+	end := from.AddDate(0, 0, -1)
+	t.EndDate = &end
+	t.UpdatedAt = time.Now()
+	if err := s.AssignRepository.UpdateAssginTemplate(ctx, t.ID, t); err != nil {
+		return err
+	}
+	if err := event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(t, userID)); err != nil {
+		return err
+	}
+
+	if t.TeacherID == nil || len(slotTemplates) > 1 {
+		return nil
+	}
+
+	tail := &ClassRoomTemplateAssignment{
+		ID:          primitive.NewObjectID(),
+		ClassRoomID: t.ClassRoomID,
+		TermID:      t.TermID,
+		SlotNumber:  t.SlotNumber,
+		SessionID:   t.SessionID,
+		TeacherID:   t.TeacherID,
+		StartDate:   &from,
+		EndDate:     tailEnd,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.AssignRepository.CreateAssignmentTemplate(ctx, tail); err != nil {
+		return err
+	}
+
+	if err := event.Record(ctx, s.EventPublisher, assignmentTemplateEvent(tail, userID)); err != nil {
+		return err
+	}
+	return nil
+
+}
+
+func (s *assignService) GetStudentTransfers(ctx context.Context, studentID, termID string) ([]*StudentTransfer, error) {
+
+	if studentID == "" {
+		return nil, errors.New("student_id is required")
+	}
+
+	var termObjID *primitive.ObjectID
+	if termID != "" {
+		id, err := primitive.ObjectIDFromHex(termID)
+		if err != nil {
+			return nil, err
+		}
+		termObjID = &id
+	}
+
+	return s.AssignRepository.GetStudentTransfers(ctx, studentID, termObjID)
+
+}
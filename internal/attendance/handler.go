@@ -0,0 +1,269 @@
+package attendance
+
+import (
+	"classroom-service/helper"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttendanceHandler struct {
+	AttendanceService AttendanceService
+}
+
+func NewAttendanceHandler(attendanceService AttendanceService) *AttendanceHandler {
+	return &AttendanceHandler{
+		AttendanceService: attendanceService,
+	}
+}
+
+func attendanceErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrAssignmentNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, ErrAlreadyCheckedIn), errors.Is(err, ErrAlreadyCheckedOut), errors.Is(err, ErrNotCheckedIn):
+		return http.StatusConflict, helper.ErrConflict
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *AttendanceHandler) CheckIn(c *gin.Context) {
+
+	var req CheckRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	record, err := h.AttendanceService.CheckIn(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := attendanceErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Check In Successfully", record)
+
+}
+
+func (h *AttendanceHandler) CheckOut(c *gin.Context) {
+
+	var req CheckRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	record, err := h.AttendanceService.CheckOut(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := attendanceErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Check Out Successfully", record)
+
+}
+
+func (h *AttendanceHandler) MarkAbsent(c *gin.Context) {
+
+	var req AbsenceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	record, err := h.AttendanceService.MarkAbsent(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := attendanceErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Mark Absent Successfully", record)
+
+}
+
+func (h *AttendanceHandler) GetAttendanceSheet(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	date := c.Query("date")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	sheet, err := h.AttendanceService.GetAttendanceSheet(ctx, classroomID, date)
+
+	if err != nil {
+		statusCode, errorCode := attendanceErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Attendance Sheet Successfully", sheet)
+
+}
+
+func (h *AttendanceHandler) GetStudentSummary(c *gin.Context) {
+
+	studentID := c.Query("student_id")
+	termID := c.Query("term_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	summary, err := h.AttendanceService.GetStudentSummary(ctx, studentID, termID)
+
+	if err != nil {
+		statusCode, errorCode := attendanceErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Attendance Summary Successfully", summary)
+
+}
+
+func (h *AttendanceHandler) IssueCheckInToken(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	date := c.Query("date")
+
+	token, err := h.AttendanceService.IssueCheckInToken(c, classroomID, date)
+
+	if err != nil {
+		statusCode, errorCode := attendanceErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Issue Check In Token Successfully", token)
+
+}
+
+func (h *AttendanceHandler) GetCheckInQRCode(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	date := c.Query("date")
+
+	size := 0
+	if value := c.Query("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			helper.SendError(c, http.StatusBadRequest, fmt.Errorf("size must be a number"), "INVALID_REQUEST")
+			return
+		}
+		size = parsed
+	}
+
+	png, err := h.AttendanceService.GetCheckInQRCode(c, classroomID, date, size)
+
+	if err != nil {
+		statusCode, errorCode := attendanceErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", png)
+
+}
+
+func (h *AttendanceHandler) ScanCheckIn(c *gin.Context) {
+
+	var req ScanCheckInRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	records, err := h.AttendanceService.ScanCheckIn(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := attendanceErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Scan Check In Successfully", records)
+
+}
@@ -0,0 +1,57 @@
+package attendance
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+)
+
+const (
+	StatusPresent = "present"
+	StatusAbsent  = "absent"
+)
+
+// AbsenceReasons are the reasons an absence can be recorded with.
+var AbsenceReasons = map[string]bool{
+	"sick":        true,
+	"holiday":     true,
+	"family":      true,
+	"appointment": true,
+	"unexcused":   true,
+	"other":       true,
+}
+
+// AttendanceRecord is what actually happened for the student or the teacher
+// of a daily assignment. There is at most one record per assignment and
+// role; LateMinutes is counted from the expected start and stays 0 for
+// arrivals within the grace period.
+type AttendanceRecord struct {
+	ID            primitive.ObjectID  `json:"id" bson:"_id"`
+	AssignmentID  primitive.ObjectID  `json:"assignment_id" bson:"assignment_id"`
+	ClassRoomID   primitive.ObjectID  `json:"class_room_id" bson:"class_room_id"`
+	SlotNumber    int                 `json:"slot_number" bson:"slot_number"`
+	SessionID     *primitive.ObjectID `json:"session_id" bson:"session_id"`
+	Date          time.Time           `json:"date" bson:"date"`
+	PersonID      string              `json:"person_id" bson:"person_id"`
+	PersonRole    string              `json:"person_role" bson:"person_role"`
+	Status        string              `json:"status" bson:"status"`
+	CheckInAt     *time.Time          `json:"check_in_at" bson:"check_in_at"`
+	CheckInBy     *string             `json:"check_in_by" bson:"check_in_by"`
+	CheckOutAt    *time.Time          `json:"check_out_at" bson:"check_out_at"`
+	CheckOutBy    *string             `json:"check_out_by" bson:"check_out_by"`
+	LateMinutes   int                 `json:"late_minutes" bson:"late_minutes"`
+	AbsenceReason *string             `json:"absence_reason" bson:"absence_reason"`
+	Note          *string             `json:"note" bson:"note"`
+	CreatedAt     time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// IsLate reports whether the person arrived after the grace period.
+func (r *AttendanceRecord) IsLate() bool {
+	return r.LateMinutes > 0
+}
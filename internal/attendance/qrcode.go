@@ -0,0 +1,175 @@
+package attendance
+
+import (
+	"classroom-service/internal/assign"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultQRCodeSize = 256
+	maxQRCodeSize     = 1024
+)
+
+func (s *attendanceService) IssueCheckInToken(ctx context.Context, classroomID, date string) (*CheckInTokenResponse, error) {
+
+	if _, err := primitive.ObjectIDFromHex(classroomID); err != nil {
+		return nil, err
+	}
+
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, err
+		}
+	}
+
+	expiresAt := time.Now().Add(s.TokenTTL)
+
+	value, err := s.TokenSigner.Issue(&CheckInToken{
+		ClassroomID: classroomID,
+		Date:        date,
+		ExpiresAt:   expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckInTokenResponse{
+		Token:       value,
+		ClassroomID: classroomID,
+		Date:        date,
+		ExpiresAt:   time.Unix(expiresAt.Unix(), 0),
+	}, nil
+
+}
+
+// GetCheckInQRCode renders a freshly issued token as a PNG. Screens showing
+// it are expected to reload it before it expires, which rotates the token.
+func (s *attendanceService) GetCheckInQRCode(ctx context.Context, classroomID, date string, size int) ([]byte, error) {
+
+	if size <= 0 {
+		size = DefaultQRCodeSize
+	}
+	if size > maxQRCodeSize {
+		return nil, fmt.Errorf("size must be at most %d", maxQRCodeSize)
+	}
+
+	token, err := s.IssueCheckInToken(ctx, classroomID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return qrcode.Encode(token.Token, qrcode.Medium, size)
+
+}
+
+// ScanCheckIn checks a person in with a scanned classroom token. Of the
+// person's assignments in the classroom that day, those of the earliest
+// session not checked in yet are checked in, so a teacher with several
+// students in a session is checked in for all of them at once.
+func (s *attendanceService) ScanCheckIn(ctx context.Context, req *ScanCheckInRequest, userID string) ([]*AttendanceRecord, error) {
+
+	if err := validateRole(req.Role); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	token, err := s.TokenSigner.Verify(req.Token, now)
+	if err != nil {
+		return nil, err
+	}
+
+	today := now.Format("2006-01-02")
+	if token.Date != "" && token.Date != today {
+		return nil, fmt.Errorf("%w: token is for %s", ErrInvalidToken, token.Date)
+	}
+
+	personID := req.PersonID
+	if personID == "" {
+		personID = userID
+	}
+
+	classroomObjID, err := primitive.ObjectIDFromHex(token.ClassroomID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	date, _ := time.Parse("2006-01-02", today)
+
+	assignments, err := s.AssignRepository.GetAssignmentsByClassroomAndDate(ctx, classroomObjID, &date)
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		assignment *assign.TeacherStudentAssignment
+		record     *AttendanceRecord
+		start      time.Time
+	}
+
+	var candidates []candidate
+	for _, a := range assignments {
+		var assigned *string
+		if req.Role == RoleStudent {
+			assigned = a.StudentID
+		} else {
+			assigned = a.TeacherID
+		}
+		if assigned == nil || *assigned != personID {
+			continue
+		}
+
+		record, err := s.recordFor(ctx, a, req.Role)
+		if err != nil {
+			return nil, err
+		}
+		if record.CheckInAt != nil {
+			continue
+		}
+
+		start, err := s.expectedStart(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = append(candidates, candidate{a, record, start})
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no assignment to check in to in this classroom today", ErrAssignmentNotFound)
+	}
+
+	earliest := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.start.Before(earliest.start) {
+			earliest = c
+		}
+	}
+
+	var records []*AttendanceRecord
+	for _, c := range candidates {
+		if !c.start.Equal(earliest.start) || !sameSession(c.assignment.SessionID, earliest.assignment.SessionID) {
+			continue
+		}
+
+		if err := s.checkIn(ctx, c.record, c.start, now, nil, userID); err != nil {
+			return records, err
+		}
+		records = append(records, c.record)
+	}
+
+	return records, nil
+
+}
+
+func sameSession(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,108 @@
+package attendance
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AttendanceRepository interface {
+	SaveRecord(ctx context.Context, record *AttendanceRecord) error
+	GetRecord(ctx context.Context, assignmentID primitive.ObjectID, role string) (*AttendanceRecord, error)
+	GetRecordsByAssignmentIDs(ctx context.Context, assignmentIDs []primitive.ObjectID) ([]*AttendanceRecord, error)
+	GetRecordsByPersonAndRange(ctx context.Context, personID, role string, start, end *time.Time) ([]*AttendanceRecord, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type attendanceRepository struct {
+	attendanceCollection *mongo.Collection
+}
+
+func NewAttendanceRepository(attendanceCollection *mongo.Collection) AttendanceRepository {
+	return &attendanceRepository{
+		attendanceCollection: attendanceCollection,
+	}
+}
+
+func (r *attendanceRepository) SaveRecord(ctx context.Context, record *AttendanceRecord) error {
+
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := r.attendanceCollection.ReplaceOne(ctx, bson.M{"_id": record.ID}, record, opts)
+	return err
+
+}
+
+func (r *attendanceRepository) GetRecord(ctx context.Context, assignmentID primitive.ObjectID, role string) (*AttendanceRecord, error) {
+
+	var record AttendanceRecord
+	err := r.attendanceCollection.FindOne(ctx, bson.M{"assignment_id": assignmentID, "person_role": role}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &record, nil
+
+}
+
+func (r *attendanceRepository) GetRecordsByAssignmentIDs(ctx context.Context, assignmentIDs []primitive.ObjectID) ([]*AttendanceRecord, error) {
+
+	if len(assignmentIDs) == 0 {
+		return nil, nil
+	}
+
+	return r.findRecords(ctx, bson.M{"assignment_id": bson.M{"$in": assignmentIDs}})
+
+}
+
+func (r *attendanceRepository) GetRecordsByPersonAndRange(ctx context.Context, personID, role string, start, end *time.Time) ([]*AttendanceRecord, error) {
+
+	filter := bson.M{
+		"person_id":   personID,
+		"person_role": role,
+		"date": bson.M{
+			"$gte": start,
+			"$lt":  end,
+		},
+	}
+
+	return r.findRecords(ctx, filter)
+
+}
+
+func (r *attendanceRepository) findRecords(ctx context.Context, filter bson.M) ([]*AttendanceRecord, error) {
+
+	cursor, err := r.attendanceCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []*AttendanceRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+
+}
+
+func (r *attendanceRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.attendanceCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "assignment_id", Value: 1}, {Key: "person_role", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "person_id", Value: 1}, {Key: "person_role", Value: 1}, {Key: "date", Value: 1}}},
+	})
+	return err
+
+}
@@ -0,0 +1,26 @@
+package attendance
+
+type CheckRequest struct {
+	AssignmentID string `json:"assignment_id"`
+	Role         string `json:"role"`
+	// Time is RFC 3339 and defaults to now, so staff can record a check-in
+	// after the fact.
+	Time string  `json:"time"`
+	Note *string `json:"note"`
+}
+
+type AbsenceRequest struct {
+	AssignmentID string  `json:"assignment_id"`
+	Role         string  `json:"role"`
+	Reason       string  `json:"reason"`
+	Note         *string `json:"note"`
+}
+
+// ScanCheckInRequest is sent when a classroom QR code is scanned. PersonID
+// defaults to the scanning user, for students and teachers scanning with
+// their own account.
+type ScanCheckInRequest struct {
+	Token    string `json:"token"`
+	Role     string `json:"role"`
+	PersonID string `json:"person_id"`
+}
@@ -0,0 +1,57 @@
+package attendance
+
+import (
+	"classroom-service/internal/user"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AttendanceSheetResponse struct {
+	ClassroomID string                `json:"classroom_id"`
+	Date        string                `json:"date"`
+	Expected    int                   `json:"expected"`
+	Present     int                   `json:"present"`
+	Late        int                   `json:"late"`
+	Absent      int                   `json:"absent"`
+	Unrecorded  int                   `json:"unrecorded"`
+	Rows        []*AttendanceSheetRow `json:"rows"`
+}
+
+// AttendanceSheetRow is one daily assignment with what was recorded for
+// its student and teacher; a nil record means nothing was recorded yet.
+type AttendanceSheetRow struct {
+	AssignmentID      string              `json:"assignment_id"`
+	SlotNumber        int                 `json:"slot_number"`
+	SessionID         *primitive.ObjectID `json:"session_id"`
+	Student           *user.UserInfor     `json:"student"`
+	Teacher           *user.UserInfor     `json:"teacher"`
+	StudentAttendance *AttendanceRecord   `json:"student_attendance"`
+	TeacherAttendance *AttendanceRecord   `json:"teacher_attendance"`
+}
+
+// StudentSummaryResponse counts a student's attendance over a term.
+// Assignments after today are only scheduled unless something was already
+// recorded for them.
+type StudentSummaryResponse struct {
+	StudentID        string         `json:"student_id"`
+	TermID           string         `json:"term_id"`
+	StartDate        string         `json:"start_date"`
+	EndDate          string         `json:"end_date"`
+	Scheduled        int            `json:"scheduled"`
+	Expected         int            `json:"expected"`
+	Present          int            `json:"present"`
+	Late             int            `json:"late"`
+	TotalLateMinutes int            `json:"total_late_minutes"`
+	Absent           int            `json:"absent"`
+	AbsenceReasons   map[string]int `json:"absence_reasons"`
+	Unrecorded       int            `json:"unrecorded"`
+	AttendanceRate   float64        `json:"attendance_rate"`
+}
+
+type CheckInTokenResponse struct {
+	Token       string    `json:"token"`
+	ClassroomID string    `json:"classroom_id"`
+	Date        string    `json:"date"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -0,0 +1,22 @@
+package attendance
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *AttendanceHandler) {
+	attendanceGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Attendance
+		attendanceGroup.POST("/attendance/check-in", handler.CheckIn)
+		attendanceGroup.POST("/attendance/check-out", handler.CheckOut)
+		attendanceGroup.POST("/attendance/absence", handler.MarkAbsent)
+		attendanceGroup.GET("/attendance/sheet", handler.GetAttendanceSheet)
+		attendanceGroup.GET("/attendance/summary", handler.GetStudentSummary)
+		attendanceGroup.GET("/attendance/qr-token", handler.IssueCheckInToken)
+		attendanceGroup.GET("/attendance/qr", handler.GetCheckInQRCode)
+		attendanceGroup.POST("/attendance/scan", handler.ScanCheckIn)
+	}
+}
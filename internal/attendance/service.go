@@ -0,0 +1,561 @@
+package attendance
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/event"
+	"classroom-service/internal/session"
+	"classroom-service/internal/term"
+	"classroom-service/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultDayStart     = "08:00"
+	DefaultTokenMinutes = 10
+)
+
+var (
+	ErrAssignmentNotFound = errors.New("assignment not found")
+	ErrAlreadyCheckedIn   = errors.New("already checked in")
+	ErrAlreadyCheckedOut  = errors.New("already checked out")
+	ErrNotCheckedIn       = errors.New("not checked in")
+)
+
+type AttendanceService interface {
+	CheckIn(ctx context.Context, req *CheckRequest, userID string) (*AttendanceRecord, error)
+	CheckOut(ctx context.Context, req *CheckRequest, userID string) (*AttendanceRecord, error)
+	MarkAbsent(ctx context.Context, req *AbsenceRequest, userID string) (*AttendanceRecord, error)
+	GetAttendanceSheet(ctx context.Context, classroomID, date string) (*AttendanceSheetResponse, error)
+	GetStudentSummary(ctx context.Context, studentID, termID string) (*StudentSummaryResponse, error)
+	IssueCheckInToken(ctx context.Context, classroomID, date string) (*CheckInTokenResponse, error)
+	GetCheckInQRCode(ctx context.Context, classroomID, date string, size int) ([]byte, error)
+	ScanCheckIn(ctx context.Context, req *ScanCheckInRequest, userID string) ([]*AttendanceRecord, error)
+}
+
+type attendanceService struct {
+	AttendanceRepository AttendanceRepository
+	AssignRepository     assign.AssignRepository
+	SessionRepository    session.SessionRepository
+	UserService          user.UserService
+	TermService          term.TermService
+	EventPublisher       event.Publisher
+	DayStart             string
+	LateGrace            time.Duration
+	TokenSigner          *tokenSigner
+	TokenTTL             time.Duration
+}
+
+func NewAttendanceService(attendanceRepository AttendanceRepository,
+	assignRepository assign.AssignRepository,
+	sessionRepository session.SessionRepository,
+	userService user.UserService,
+	termService term.TermService,
+	publisher event.Publisher,
+	dayStart string,
+	lateGraceMinutes int,
+	tokenSecret string,
+	tokenMinutes int) AttendanceService {
+	if _, err := time.Parse("15:04", dayStart); err != nil {
+		dayStart = DefaultDayStart
+	}
+	if lateGraceMinutes < 0 {
+		lateGraceMinutes = 0
+	}
+	if tokenMinutes <= 0 {
+		tokenMinutes = DefaultTokenMinutes
+	}
+	return &attendanceService{
+		AttendanceRepository: attendanceRepository,
+		AssignRepository:     assignRepository,
+		SessionRepository:    sessionRepository,
+		UserService:          userService,
+		TermService:          termService,
+		EventPublisher:       publisher,
+		DayStart:             dayStart,
+		LateGrace:            time.Duration(lateGraceMinutes) * time.Minute,
+		TokenSigner:          newTokenSigner(tokenSecret),
+		TokenTTL:             time.Duration(tokenMinutes) * time.Minute,
+	}
+}
+
+func attendanceEvent(record *AttendanceRecord, action, userID string) *event.Event {
+
+	data := map[string]interface{}{
+		"action":        action,
+		"assignment_id": record.AssignmentID.Hex(),
+		"class_room_id": record.ClassRoomID.Hex(),
+		"slot_number":   record.SlotNumber,
+		"date":          record.Date.Format("2006-01-02"),
+		"person_id":     record.PersonID,
+		"person_role":   record.PersonRole,
+		"status":        record.Status,
+		"late_minutes":  record.LateMinutes,
+	}
+
+	if record.SessionID != nil {
+		data["session_id"] = record.SessionID.Hex()
+	}
+
+	if record.AbsenceReason != nil {
+		data["absence_reason"] = *record.AbsenceReason
+	}
+
+	return event.New(event.AttendanceRecorded, "attendance", record.ID.Hex(), data).WithActor(userID)
+
+}
+
+func validateRole(role string) error {
+	if role != RoleStudent && role != RoleTeacher {
+		return fmt.Errorf("role must be %s or %s", RoleStudent, RoleTeacher)
+	}
+	return nil
+}
+
+func parseCheckTime(value string) (time.Time, error) {
+
+	if value == "" {
+		return time.Now(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("time must be RFC 3339")
+	}
+
+	return t, nil
+
+}
+
+// loadRecord returns the record of the person with the given role on a daily
+// assignment, or a new unsaved one when nothing was recorded yet.
+func (s *attendanceService) loadRecord(ctx context.Context, assignmentID, role string) (*assign.TeacherStudentAssignment, *AttendanceRecord, error) {
+
+	if err := validateRole(role); err != nil {
+		return nil, nil, err
+	}
+
+	objID, err := primitive.ObjectIDFromHex(assignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	assignment, err := s.AssignRepository.GetAssignmentByID(ctx, objID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assignment == nil {
+		return nil, nil, ErrAssignmentNotFound
+	}
+
+	record, err := s.recordFor(ctx, assignment, role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return assignment, record, nil
+
+}
+
+func (s *attendanceService) recordFor(ctx context.Context, assignment *assign.TeacherStudentAssignment, role string) (*AttendanceRecord, error) {
+
+	var personID *string
+	if role == RoleStudent {
+		personID = assignment.StudentID
+	} else {
+		personID = assignment.TeacherID
+	}
+	if personID == nil {
+		return nil, fmt.Errorf("assignment has no %s", role)
+	}
+
+	record, err := s.AttendanceRepository.GetRecord(ctx, assignment.ID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil {
+		now := time.Now()
+		record = &AttendanceRecord{
+			ID:           primitive.NewObjectID(),
+			AssignmentID: assignment.ID,
+			PersonRole:   role,
+			CreatedAt:    now,
+		}
+	}
+
+	// The slot may have been rearranged since the record was created.
+	record.ClassRoomID = assignment.ClassRoomID
+	record.SlotNumber = assignment.SlotNumber
+	record.SessionID = assignment.SessionID
+	record.Date = assignment.AssignDate
+	record.PersonID = *personID
+
+	return record, nil
+
+}
+
+// expectedStart is when the assignment begins in local time: the start of
+// its session, or the configured day start for slots without one.
+func (s *attendanceService) expectedStart(ctx context.Context, assignment *assign.TeacherStudentAssignment) (time.Time, error) {
+
+	startTime := s.DayStart
+
+	if assignment.SessionID != nil {
+		sess, err := s.SessionRepository.GetSessionByID(ctx, *assignment.SessionID)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if sess != nil {
+			startTime = sess.StartTime
+		}
+	}
+
+	clock, err := time.Parse("15:04", startTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	y, m, d := assignment.AssignDate.Date()
+	return time.Date(y, m, d, clock.Hour(), clock.Minute(), 0, 0, time.Local), nil
+
+}
+
+func (s *attendanceService) CheckIn(ctx context.Context, req *CheckRequest, userID string) (*AttendanceRecord, error) {
+
+	checkInAt, err := parseCheckTime(req.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment, record, err := s.loadRecord(ctx, req.AssignmentID, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.CheckInAt != nil {
+		return nil, ErrAlreadyCheckedIn
+	}
+
+	start, err := s.expectedStart(ctx, assignment)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkIn(ctx, record, start, checkInAt, req.Note, userID); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+
+}
+
+func (s *attendanceService) checkIn(ctx context.Context, record *AttendanceRecord, start, checkInAt time.Time, note *string, userID string) error {
+
+	late := checkInAt.Sub(start)
+	record.LateMinutes = 0
+	if late > s.LateGrace {
+		record.LateMinutes = int(late / time.Minute)
+	}
+
+	// A check-in overrides an absence recorded in advance.
+	record.Status = StatusPresent
+	record.AbsenceReason = nil
+	record.CheckInAt = &checkInAt
+	record.CheckInBy = &userID
+	if note != nil {
+		record.Note = note
+	}
+	record.UpdatedAt = time.Now()
+
+	if err := s.AttendanceRepository.SaveRecord(ctx, record); err != nil {
+		return err
+	}
+
+	event.Emit(ctx, s.EventPublisher, attendanceEvent(record, "check_in", userID))
+
+	return nil
+
+}
+
+func (s *attendanceService) CheckOut(ctx context.Context, req *CheckRequest, userID string) (*AttendanceRecord, error) {
+
+	checkOutAt, err := parseCheckTime(req.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	_, record, err := s.loadRecord(ctx, req.AssignmentID, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.CheckInAt == nil {
+		return nil, ErrNotCheckedIn
+	}
+
+	if record.CheckOutAt != nil {
+		return nil, ErrAlreadyCheckedOut
+	}
+
+	if checkOutAt.Before(*record.CheckInAt) {
+		return nil, errors.New("check-out time must be after check-in time")
+	}
+
+	record.CheckOutAt = &checkOutAt
+	record.CheckOutBy = &userID
+	if req.Note != nil {
+		record.Note = req.Note
+	}
+	record.UpdatedAt = time.Now()
+
+	if err := s.AttendanceRepository.SaveRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	event.Emit(ctx, s.EventPublisher, attendanceEvent(record, "check_out", userID))
+
+	return record, nil
+
+}
+
+func (s *attendanceService) MarkAbsent(ctx context.Context, req *AbsenceRequest, userID string) (*AttendanceRecord, error) {
+
+	if !AbsenceReasons[req.Reason] {
+		return nil, fmt.Errorf("invalid absence reason: %s", req.Reason)
+	}
+
+	_, record, err := s.loadRecord(ctx, req.AssignmentID, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.CheckInAt != nil {
+		return nil, fmt.Errorf("%w: cannot mark as absent", ErrAlreadyCheckedIn)
+	}
+
+	reason := req.Reason
+	record.Status = StatusAbsent
+	record.AbsenceReason = &reason
+	record.LateMinutes = 0
+	if req.Note != nil {
+		record.Note = req.Note
+	}
+	record.UpdatedAt = time.Now()
+
+	if err := s.AttendanceRepository.SaveRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	event.Emit(ctx, s.EventPublisher, attendanceEvent(record, "absence", userID))
+
+	return record, nil
+
+}
+
+func (s *attendanceService) userInfor(ctx context.Context, cache map[string]*user.UserInfor, userID *string) *user.UserInfor {
+
+	if userID == nil {
+		return nil
+	}
+
+	if info, ok := cache[*userID]; ok {
+		return info
+	}
+
+	info, err := s.UserService.GetUserInfor(ctx, *userID)
+	if err != nil {
+		log.Printf("[ERROR] attendance: cannot load user %s: %v", *userID, err)
+	}
+	cache[*userID] = info
+
+	return info
+
+}
+
+func (s *attendanceService) GetAttendanceSheet(ctx context.Context, classroomID, date string) (*AttendanceSheetResponse, error) {
+
+	classroomObjID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if date == "" {
+		return nil, errors.New("date is required")
+	}
+
+	dateParse, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.AssignRepository.GetAssignmentsByClassroomAndDate(ctx, classroomObjID, &dateParse)
+	if err != nil {
+		return nil, err
+	}
+
+	assignmentIDs := make([]primitive.ObjectID, 0, len(assignments))
+	for _, a := range assignments {
+		if a.TeacherID != nil || a.StudentID != nil {
+			assignmentIDs = append(assignmentIDs, a.ID)
+		}
+	}
+
+	records, err := s.AttendanceRepository.GetRecordsByAssignmentIDs(ctx, assignmentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	type recordKey struct {
+		assignmentID primitive.ObjectID
+		role         string
+	}
+
+	recordMap := make(map[recordKey]*AttendanceRecord, len(records))
+	for _, r := range records {
+		recordMap[recordKey{r.AssignmentID, r.PersonRole}] = r
+	}
+
+	sheet := &AttendanceSheetResponse{
+		ClassroomID: classroomID,
+		Date:        date,
+		Rows:        make([]*AttendanceSheetRow, 0, len(assignmentIDs)),
+	}
+
+	users := make(map[string]*user.UserInfor)
+	for _, a := range assignments {
+		if a.TeacherID == nil && a.StudentID == nil {
+			continue
+		}
+
+		row := &AttendanceSheetRow{
+			AssignmentID: a.ID.Hex(),
+			SlotNumber:   a.SlotNumber,
+			SessionID:    a.SessionID,
+			Student:      s.userInfor(ctx, users, a.StudentID),
+			Teacher:      s.userInfor(ctx, users, a.TeacherID),
+		}
+
+		if a.TeacherID != nil {
+			row.TeacherAttendance = recordMap[recordKey{a.ID, RoleTeacher}]
+		}
+
+		if a.StudentID != nil {
+			record := recordMap[recordKey{a.ID, RoleStudent}]
+			row.StudentAttendance = record
+
+			sheet.Expected++
+			switch {
+			case record == nil:
+				sheet.Unrecorded++
+			case record.Status == StatusAbsent:
+				sheet.Absent++
+			default:
+				sheet.Present++
+				if record.IsLate() {
+					sheet.Late++
+				}
+			}
+		}
+
+		sheet.Rows = append(sheet.Rows, row)
+	}
+
+	return sheet, nil
+
+}
+
+func (s *attendanceService) GetStudentSummary(ctx context.Context, studentID, termID string) (*StudentSummaryResponse, error) {
+
+	if studentID == "" {
+		return nil, errors.New("student_id is required")
+	}
+
+	if termID == "" {
+		return nil, errors.New("term_id is required")
+	}
+
+	termInfor, err := s.TermService.GetTermByID(ctx, termID)
+	if err != nil {
+		return nil, err
+	}
+	if termInfor == nil {
+		return nil, errors.New("term not found")
+	}
+
+	start, err := time.Parse("2006-01-02", termInfor.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := time.Parse("2006-01-02", termInfor.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	end = end.Add(24 * time.Hour)
+
+	assignments, err := s.AssignRepository.GetAssignmentsByStudentAndRange(ctx, studentID, &start, &end)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.AttendanceRepository.GetRecordsByPersonAndRange(ctx, studentID, RoleStudent, &start, &end)
+	if err != nil {
+		return nil, err
+	}
+
+	recordMap := make(map[primitive.ObjectID]*AttendanceRecord, len(records))
+	for _, r := range records {
+		recordMap[r.AssignmentID] = r
+	}
+
+	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+
+	summary := &StudentSummaryResponse{
+		StudentID:      studentID,
+		TermID:         termID,
+		StartDate:      termInfor.StartDate,
+		EndDate:        termInfor.EndDate,
+		Scheduled:      len(assignments),
+		AbsenceReasons: make(map[string]int),
+	}
+
+	for _, a := range assignments {
+		record := recordMap[a.ID]
+
+		// Future days only count once something was recorded for them,
+		// such as an absence announced in advance.
+		if a.AssignDate.After(today) && record == nil {
+			continue
+		}
+
+		summary.Expected++
+		switch {
+		case record == nil:
+			summary.Unrecorded++
+		case record.Status == StatusAbsent:
+			summary.Absent++
+			if record.AbsenceReason != nil {
+				summary.AbsenceReasons[*record.AbsenceReason]++
+			}
+		default:
+			summary.Present++
+			if record.IsLate() {
+				summary.Late++
+				summary.TotalLateMinutes += record.LateMinutes
+			}
+		}
+	}
+
+	if summary.Expected > 0 {
+		summary.AttendanceRate = float64(summary.Present) / float64(summary.Expected)
+	}
+
+	return summary, nil
+
+}
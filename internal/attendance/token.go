@@ -0,0 +1,99 @@
+package attendance
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid check-in token")
+	ErrTokenExpired = errors.New("check-in token has expired")
+)
+
+// CheckInToken is the payload behind a classroom QR code. A token without a
+// date checks in against the day it is scanned on.
+type CheckInToken struct {
+	ClassroomID string `json:"c"`
+	Date        string `json:"d,omitempty"`
+	ExpiresAt   int64  `json:"e"`
+	Nonce       string `json:"n"`
+}
+
+// tokenSigner signs check-in tokens with HMAC-SHA256, so they can be
+// verified without storing them. A token is "<payload>.<signature>", both
+// base64url encoded. Every replica must share the secret, which the config
+// requires at startup.
+type tokenSigner struct {
+	secret []byte
+}
+
+func newTokenSigner(secret string) *tokenSigner {
+	return &tokenSigner{secret: []byte(secret)}
+}
+
+func (s *tokenSigner) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (s *tokenSigner) Issue(token *CheckInToken) (string, error) {
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	token.Nonce = hex.EncodeToString(nonce)
+
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(s.sign(payload)), nil
+
+}
+
+func (s *tokenSigner) Verify(value string, now time.Time) (*CheckInToken, error) {
+
+	encodedPayload, encodedSignature, ok := strings.Cut(value, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	enc := base64.RawURLEncoding
+
+	payload, err := enc.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := enc.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal(signature, s.sign(payload)) {
+		return nil, ErrInvalidToken
+	}
+
+	var token CheckInToken
+	if err := json.Unmarshal(payload, &token); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if now.Unix() >= token.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return &token, nil
+
+}
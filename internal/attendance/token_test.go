@@ -0,0 +1,70 @@
+package attendance
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenSignerVerify(t *testing.T) {
+
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	signer := newTokenSigner("secret")
+
+	issue := func(t *testing.T, expiresAt time.Time) string {
+		t.Helper()
+		value, err := signer.Issue(&CheckInToken{
+			ClassroomID: "room-1",
+			Date:        "2026-03-02",
+			ExpiresAt:   expiresAt.Unix(),
+		})
+		if err != nil {
+			t.Fatalf("Issue: %v", err)
+		}
+		return value
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		token, err := signer.Verify(issue(t, now.Add(time.Minute)), now)
+		if err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+		if token.ClassroomID != "room-1" || token.Date != "2026-03-02" || token.Nonce == "" {
+			t.Fatalf("unexpected token %+v", token)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		value := issue(t, now)
+		if _, err := signer.Verify(value, now); !errors.Is(err, ErrTokenExpired) {
+			t.Fatalf("got %v, want ErrTokenExpired", err)
+		}
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		value := issue(t, now.Add(time.Minute))
+		if _, err := newTokenSigner("other").Verify(value, now); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("got %v, want ErrInvalidToken", err)
+		}
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		value := issue(t, now.Add(time.Minute))
+		_, signature, _ := strings.Cut(value, ".")
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"c":"room-2","e":9999999999,"n":"00"}`))
+		if _, err := signer.Verify(payload+"."+signature, now); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("got %v, want ErrInvalidToken", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, value := range []string{"", "no-dot", "!!!.!!!", "e30.!!!"} {
+			if _, err := signer.Verify(value, now); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify(%q) = %v, want ErrInvalidToken", value, err)
+			}
+		}
+	})
+
+}
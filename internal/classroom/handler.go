@@ -22,6 +22,17 @@ func NewClassroomHandler(classroomService ClassroomService) *ClassroomHandler {
 	}
 }
 
+func classroomErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrRoomNotFound), errors.Is(err, ErrLocationOverrideNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, ErrRoomDoubleBooked), errors.Is(err, ErrRoomOverCapacity), errors.Is(err, ErrLocationOverrideOverlap):
+		return http.StatusConflict, helper.ErrConflict
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
 func (h *ClassroomHandler) CreateClassroom(c *gin.Context) {
 
 	var req CreateClassroomRequest
@@ -48,7 +59,8 @@ func (h *ClassroomHandler) CreateClassroom(c *gin.Context) {
 	id, err := h.ClassroomService.CreateClassroom(ctx, &req, userID.(string))
 
 	if err != nil {
-		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		statusCode, errorCode := classroomErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
 		return
 	}
 
@@ -118,7 +130,8 @@ func (h *ClassroomHandler) UpdateClassroom(c *gin.Context) {
 	err := h.ClassroomService.UpdateClassroom(ctx, &req, id)
 
 	if err != nil {
-		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		statusCode, errorCode := classroomErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
 		return
 	}
 
@@ -498,4 +511,143 @@ func (h *ClassroomHandler) GetStudentAssignmentsByTermAndTeacherID(c *gin.Contex
 	}
 	
 	helper.SendSuccess(c, http.StatusOK, "Get student assignments successfully", assignments)
-}
\ No newline at end of file
+}
+
+func (h *ClassroomHandler) GetRoomUsage(c *gin.Context) {
+
+	locationID := c.Query("location_id")
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	usage, err := h.ClassroomService.GetRoomUsage(ctx, locationID, start, end)
+
+	if err != nil {
+		statusCode, errorCode := classroomErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Room Usage Successfully", usage)
+
+}
+
+func (h *ClassroomHandler) GetRoomConflicts(c *gin.Context) {
+
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	conflicts, err := h.ClassroomService.GetRoomConflicts(ctx, start, end)
+
+	if err != nil {
+		statusCode, errorCode := classroomErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Room Conflicts Successfully", conflicts)
+
+}
+
+func (h *ClassroomHandler) CreateLocationOverride(c *gin.Context) {
+
+	var req CreateLocationOverrideRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	override, err := h.ClassroomService.CreateLocationOverride(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := classroomErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Create Location Override Successfully", override)
+
+}
+
+func (h *ClassroomHandler) GetLocationOverrides(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+
+	overrides, err := h.ClassroomService.GetLocationOverrides(c, classroomID, start, end)
+
+	if err != nil {
+		statusCode, errorCode := classroomErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Location Overrides Successfully", overrides)
+
+}
+
+func (h *ClassroomHandler) DeleteLocationOverride(c *gin.Context) {
+
+	var req DeleteLocationOverrideRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.ClassroomService.DeleteLocationOverride(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := classroomErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete Location Override Successfully", nil)
+
+}
@@ -0,0 +1,233 @@
+package classroom
+
+import (
+	"classroom-service/internal/event"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrLocationOverrideNotFound = errors.New("location override not found")
+	ErrLocationOverrideOverlap  = errors.New("classroom already has a location override in this period")
+)
+
+// effectiveLocation is where the classroom is on date given its overrides,
+// which may include those of other classrooms.
+func effectiveLocation(classroom *ClassRoom, overrides []*LocationOverride, date time.Time) *primitive.ObjectID {
+
+	for _, o := range overrides {
+		if o.ClassRoomID == classroom.ID && o.Covers(date) {
+			return &o.LocationID
+		}
+	}
+
+	return classroom.LocationID
+
+}
+
+// LocationForDate returns where the classroom is on date, and the override
+// that put it there, if any.
+func LocationForDate(ctx context.Context, repo ClassroomRepository, classroom *ClassRoom, date time.Time) (*primitive.ObjectID, *LocationOverride, error) {
+
+	end := date.Add(24 * time.Hour)
+
+	overrides, err := repo.GetLocationOverridesByClassroom(ctx, classroom.ID, &date, &end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, o := range overrides {
+		if o.Covers(date) {
+			return &o.LocationID, o, nil
+		}
+	}
+
+	return classroom.LocationID, nil, nil
+
+}
+
+func locationOverrideEvent(eventType string, override *LocationOverride, userID string) *event.Event {
+
+	return event.New(eventType, "classroom", override.ClassRoomID.Hex(), map[string]interface{}{
+		"override_id": override.ID.Hex(),
+		"location_id": override.LocationID.Hex(),
+		"start_date":  override.StartDate.Format("2006-01-02"),
+		"end_date":    override.EndDate.Format("2006-01-02"),
+	}).WithActor(userID)
+
+}
+
+func (s *classroomService) CreateLocationOverride(ctx context.Context, req *CreateLocationOverrideRequest, userID string) (*LocationOverride, error) {
+
+	classroomObjID, err := primitive.ObjectIDFromHex(req.ClassroomID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid classroom id: %v", err)
+	}
+
+	locationObjID, err := primitive.ObjectIDFromHex(req.LocationID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid location id: %v", err)
+	}
+
+	if req.StartDate == "" || req.EndDate == "" {
+		return nil, errors.New("start_date and end_date are required")
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if endDate.Before(startDate) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+
+	endExclusive := endDate.AddDate(0, 0, 1)
+	if endExclusive.Sub(startDate) > roomCheckDays*24*time.Hour {
+		return nil, fmt.Errorf("a location override cannot exceed %d days", roomCheckDays)
+	}
+
+	classroom, err := s.ClassroomRepository.GetClassroomByID(ctx, classroomObjID)
+	if err != nil {
+		return nil, err
+	}
+	if classroom == nil {
+		return nil, errors.New("classroom not found")
+	}
+
+	if classroom.LocationID != nil && *classroom.LocationID == locationObjID {
+		return nil, errors.New("classroom is already in this room")
+	}
+
+	existing, err := s.ClassroomRepository.GetLocationOverridesByClassroom(ctx, classroomObjID, &startDate, &endExclusive)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("%w: %s to %s", ErrLocationOverrideOverlap, existing[0].StartDate.Format("2006-01-02"), existing[0].EndDate.Format("2006-01-02"))
+	}
+
+	roomInfo, err := s.getRoom(ctx, locationObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	override := &LocationOverride{
+		ID:          primitive.NewObjectID(),
+		ClassRoomID: classroomObjID,
+		LocationID:  locationObjID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Reason:      req.Reason,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now(),
+	}
+
+	if classroom.IsActive {
+		if err := s.checkRoomChange(ctx, classroomObjID, locationObjID, startDate, endExclusive, roomInfo.Capacity, &roomChange{override: override}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.ClassroomRepository.CreateLocationOverride(ctx, override); err != nil {
+		return nil, err
+	}
+
+	event.Emit(ctx, s.EventPublisher, locationOverrideEvent(event.ClassroomLocationOverridden, override, userID))
+
+	return override, nil
+
+}
+
+func (s *classroomService) GetLocationOverrides(ctx context.Context, classroomID, start, end string) ([]*LocationOverride, error) {
+
+	classroomObjID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid classroom id: %v", err)
+	}
+
+	var startParse, endExclusive *time.Time
+
+	if start != "" {
+		t, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			return nil, err
+		}
+		startParse = &t
+	}
+
+	if end != "" {
+		t, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			return nil, err
+		}
+		t = t.AddDate(0, 0, 1)
+		endExclusive = &t
+	}
+
+	overrides, err := s.ClassroomRepository.GetLocationOverridesByClassroom(ctx, classroomObjID, startParse, endExclusive)
+	if err != nil {
+		return nil, err
+	}
+
+	if overrides == nil {
+		overrides = make([]*LocationOverride, 0)
+	}
+
+	return overrides, nil
+
+}
+
+func (s *classroomService) DeleteLocationOverride(ctx context.Context, req *DeleteLocationOverrideRequest, userID string) error {
+
+	objID, err := primitive.ObjectIDFromHex(req.ID)
+	if err != nil {
+		return err
+	}
+
+	override, err := s.ClassroomRepository.GetLocationOverrideByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if override == nil {
+		return ErrLocationOverrideNotFound
+	}
+
+	// Going back to the base room can clash as much as leaving it.
+	classroom, err := s.ClassroomRepository.GetClassroomByID(ctx, override.ClassRoomID)
+	if err != nil {
+		return err
+	}
+
+	if classroom != nil && classroom.IsActive && classroom.LocationID != nil {
+		start, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+		if override.StartDate.After(start) {
+			start = override.StartDate
+		}
+		end := override.EndDate.AddDate(0, 0, 1)
+
+		if start.Before(end) {
+			if err := s.checkRoomChange(ctx, classroom.ID, *classroom.LocationID, start, end, s.roomCapacity(ctx, *classroom.LocationID), &roomChange{removed: override}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.ClassroomRepository.DeleteLocationOverride(ctx, objID); err != nil {
+		return err
+	}
+
+	event.Emit(ctx, s.EventPublisher, locationOverrideEvent(event.ClassroomLocationOverrideRemoved, override, userID))
+
+	return nil
+
+}
@@ -0,0 +1,54 @@
+package classroom
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEffectiveLocation(t *testing.T) {
+
+	day := func(d int) time.Time {
+		return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	home := primitive.NewObjectID()
+	away := primitive.NewObjectID()
+	elsewhere := primitive.NewObjectID()
+
+	room := &ClassRoom{ID: primitive.NewObjectID(), LocationID: &home}
+	other := &ClassRoom{ID: primitive.NewObjectID(), LocationID: &home}
+
+	overrides := []*LocationOverride{
+		{ClassRoomID: room.ID, LocationID: away, StartDate: day(10), EndDate: day(12)},
+		{ClassRoomID: other.ID, LocationID: elsewhere, StartDate: day(1), EndDate: day(31)},
+	}
+
+	tests := []struct {
+		name string
+		room *ClassRoom
+		date time.Time
+		want *primitive.ObjectID
+	}{
+		{"before the override", room, day(9), &home},
+		{"first day of the override", room, day(10), &away},
+		{"last day of the override", room, day(12), &away},
+		{"after the override", room, day(13), &home},
+		{"other classrooms' overrides ignored", room, day(20), &home},
+		{"own override among others", other, day(11), &elsewhere},
+		{"no location", &ClassRoom{ID: primitive.NewObjectID()}, day(11), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := effectiveLocation(tt.room, overrides, tt.date)
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil || *got != *tt.want:
+				t.Errorf("effectiveLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+}
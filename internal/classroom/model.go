@@ -15,8 +15,31 @@ type ClassRoom struct {
 	Icon           *string             `json:"icon" bson:"icon"`
 	Note           *string             `json:"note" bson:"note"`
 	LocationID     *primitive.ObjectID `json:"location_id" bson:"location_id"`
-	IsActive       bool                `json:"is_active" bson:"is_active"`
-	CreatedBy      string              `json:"created_by" bson:"created_by"`
-	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
-	UpdatedAt      time.Time           `json:"updated_at" bson:"updated_at"`
+	// Teacher requirements
+	AgeGroup               *string   `json:"age_group" bson:"age_group"`
+	Languages              []string  `json:"languages" bson:"languages"`
+	RequiredCertifications []string  `json:"required_certifications" bson:"required_certifications"`
+	IsActive               bool      `json:"is_active" bson:"is_active"`
+	CreatedBy              string    `json:"created_by" bson:"created_by"`
+	CreatedAt              time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// LocationOverride moves a classroom to another room from StartDate to
+// EndDate, both inclusive, e.g. during a renovation. Overrides of a
+// classroom never overlap.
+type LocationOverride struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	ClassRoomID primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
+	LocationID  primitive.ObjectID `json:"location_id" bson:"location_id"`
+	StartDate   time.Time          `json:"start_date" bson:"start_date"`
+	EndDate     time.Time          `json:"end_date" bson:"end_date"`
+	Reason      *string            `json:"reason" bson:"reason"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// Covers reports whether the override applies on date.
+func (o *LocationOverride) Covers(date time.Time) bool {
+	return !date.Before(o.StartDate) && !date.After(o.EndDate)
 }
@@ -2,10 +2,12 @@ package classroom
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ClassroomRepository interface {
@@ -14,15 +16,27 @@ type ClassroomRepository interface {
 	GetClassroomByRegion(ctx context.Context, regionID primitive.ObjectID) ([]*ClassRoom, error)
 	GetClassroomByID(ctx context.Context, classroomID primitive.ObjectID) (*ClassRoom, error)
 	GetClassroomsByOrgID(ctx context.Context, orgID string) ([]*ClassRoom, error)
+	GetOrganizationIDs(ctx context.Context) ([]string, error)
+	GetActiveClassroomsByLocationID(ctx context.Context, locationID primitive.ObjectID) ([]*ClassRoom, error)
+	GetClassroomsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*ClassRoom, error)
+	// Location Overrides
+	CreateLocationOverride(ctx context.Context, override *LocationOverride) error
+	GetLocationOverrideByID(ctx context.Context, id primitive.ObjectID) (*LocationOverride, error)
+	DeleteLocationOverride(ctx context.Context, id primitive.ObjectID) error
+	GetLocationOverridesByClassroom(ctx context.Context, classroomID primitive.ObjectID, start, end *time.Time) ([]*LocationOverride, error)
+	GetLocationOverridesByClassrooms(ctx context.Context, classroomIDs []primitive.ObjectID, start, end *time.Time) ([]*LocationOverride, error)
+	GetLocationOverridesByLocation(ctx context.Context, locationID primitive.ObjectID, start, end *time.Time) ([]*LocationOverride, error)
 }
 
 type classroomRepository struct {
-	classroomCollection *mongo.Collection
+	classroomCollection        *mongo.Collection
+	locationOverrideCollection *mongo.Collection
 }
 
-func NewClassroomRepository(collection *mongo.Collection) ClassroomRepository {
+func NewClassroomRepository(collection, locationOverrideCollection *mongo.Collection) ClassroomRepository {
 	return &classroomRepository{
-		classroomCollection: collection,
+		classroomCollection:        collection,
+		locationOverrideCollection: locationOverrideCollection,
 	}
 }
 
@@ -101,4 +115,147 @@ func (c *classroomRepository) GetClassroomsByOrgID(ctx context.Context, orgID st
 
 	return classrooms, nil
 
-}
\ No newline at end of file
+}
+func (c *classroomRepository) GetOrganizationIDs(ctx context.Context) ([]string, error) {
+
+	values, err := c.classroomCollection.Distinct(ctx, "organization_id", bson.M{"is_active": true})
+	if err != nil {
+		return nil, err
+	}
+
+	orgIDs := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok && id != "" {
+			orgIDs = append(orgIDs, id)
+		}
+	}
+
+	return orgIDs, nil
+
+}
+
+func (c *classroomRepository) GetActiveClassroomsByLocationID(ctx context.Context, locationID primitive.ObjectID) ([]*ClassRoom, error) {
+
+	var classrooms []*ClassRoom
+
+	cursor, err := c.classroomCollection.Find(ctx, bson.M{"location_id": locationID, "is_active": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &classrooms); err != nil {
+		return nil, err
+	}
+
+	return classrooms, nil
+
+}
+
+func (c *classroomRepository) GetClassroomsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*ClassRoom, error) {
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var classrooms []*ClassRoom
+
+	cursor, err := c.classroomCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &classrooms); err != nil {
+		return nil, err
+	}
+
+	return classrooms, nil
+
+}
+
+func (c *classroomRepository) CreateLocationOverride(ctx context.Context, override *LocationOverride) error {
+
+	_, err := c.locationOverrideCollection.InsertOne(ctx, override)
+	return err
+
+}
+
+func (c *classroomRepository) GetLocationOverrideByID(ctx context.Context, id primitive.ObjectID) (*LocationOverride, error) {
+
+	var override LocationOverride
+	err := c.locationOverrideCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&override)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &override, nil
+
+}
+
+func (c *classroomRepository) DeleteLocationOverride(ctx context.Context, id primitive.ObjectID) error {
+
+	_, err := c.locationOverrideCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+
+}
+
+// overlapFilter matches overrides that cover at least one day of
+// [start, end). A nil bound leaves that side open.
+func overlapFilter(filter bson.M, start, end *time.Time) bson.M {
+
+	if start != nil {
+		filter["end_date"] = bson.M{"$gte": start}
+	}
+
+	if end != nil {
+		filter["start_date"] = bson.M{"$lt": end}
+	}
+
+	return filter
+
+}
+
+func (c *classroomRepository) GetLocationOverridesByClassroom(ctx context.Context, classroomID primitive.ObjectID, start, end *time.Time) ([]*LocationOverride, error) {
+
+	return c.findLocationOverrides(ctx, overlapFilter(bson.M{"class_room_id": classroomID}, start, end))
+
+}
+
+func (c *classroomRepository) GetLocationOverridesByClassrooms(ctx context.Context, classroomIDs []primitive.ObjectID, start, end *time.Time) ([]*LocationOverride, error) {
+
+	if len(classroomIDs) == 0 {
+		return nil, nil
+	}
+
+	return c.findLocationOverrides(ctx, overlapFilter(bson.M{"class_room_id": bson.M{"$in": classroomIDs}}, start, end))
+
+}
+
+func (c *classroomRepository) GetLocationOverridesByLocation(ctx context.Context, locationID primitive.ObjectID, start, end *time.Time) ([]*LocationOverride, error) {
+
+	return c.findLocationOverrides(ctx, overlapFilter(bson.M{"location_id": locationID}, start, end))
+
+}
+
+func (c *classroomRepository) findLocationOverrides(ctx context.Context, filter bson.M) ([]*LocationOverride, error) {
+
+	opts := options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}})
+
+	cursor, err := c.locationOverrideCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var overrides []*LocationOverride
+	if err := cursor.All(ctx, &overrides); err != nil {
+		return nil, err
+	}
+
+	return overrides, nil
+
+}
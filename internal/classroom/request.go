@@ -8,6 +8,10 @@ type CreateClassroomRequest struct {
 	Description *string `json:"description"`
 	Note        *string `json:"note"`
 	Icon        *string `json:"icon"`
+	// Teacher requirements
+	AgeGroup               *string  `json:"age_group"`
+	Languages              []string `json:"languages"`
+	RequiredCertifications []string `json:"required_certifications"`
 }
 
 type UpdateClassroomRequest struct {
@@ -18,6 +22,10 @@ type UpdateClassroomRequest struct {
 	Description *string `json:"description"`
 	Note        *string `json:"note"`
 	Icon        *string `json:"icon"`
+	// Teacher requirements
+	AgeGroup               *string  `json:"age_group"`
+	Languages              []string `json:"languages"`
+	RequiredCertifications []string `json:"required_certifications"`
 }
 
 type CreateAssignmentByTemplateRequest struct {
@@ -26,3 +34,15 @@ type CreateAssignmentByTemplateRequest struct {
 	StartDate   string `json:"start_date"`
 	EndDate     string `json:"end_date"`
 }
+
+type CreateLocationOverrideRequest struct {
+	ClassroomID string  `json:"classroom_id"`
+	LocationID  string  `json:"location_id"`
+	StartDate   string  `json:"start_date"`
+	EndDate     string  `json:"end_date"`
+	Reason      *string `json:"reason"`
+}
+
+type DeleteLocationOverrideRequest struct {
+	ID string `json:"id"`
+}
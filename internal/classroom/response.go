@@ -1,6 +1,7 @@
 package classroom
 
 import (
+	"classroom-service/internal/assign"
 	"classroom-service/internal/room"
 	"classroom-service/internal/user"
 	"time"
@@ -32,10 +33,16 @@ type ClassroomTemplateResponse struct {
 type SlotAssignmentResponse struct {
 	AssignmentID *string         `json:"assignment_id,omitempty"`
 	SlotNumber   int             `json:"slot_number"`
+	SessionID    *string         `json:"session_id,omitempty"`
 	Teacher      *user.UserInfor `json:"teacher"`
 	Student      *user.UserInfor `json:"student"`
-	CreatedAt    *time.Time      `json:"created_at,omitempty"`
-	UpdatedAt    *time.Time      `json:"updated_at,omitempty"`
+	// Type marks guest and trial visits in daily schedules.
+	Type       string                    `json:"type,omitempty"`
+	Attendance *assign.AttendancePattern `json:"attendance,omitempty"`
+	StartDate  *time.Time                `json:"start_date,omitempty"`
+	EndDate    *time.Time                `json:"end_date,omitempty"`
+	CreatedAt  *time.Time                `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time                `json:"updated_at,omitempty"`
 }
 
 type TeacherAssignmentResponse struct {
@@ -68,8 +75,24 @@ type ClassroomScheduleResponse struct {
 	Pagination  Pagination       `json:"pagination"`
 }
 
+// DailySchedule holds the whole-day leader and assignments of a day, and
+// those booked into a session under Sessions.
 type DailySchedule struct {
-	Date        string                    `json:"date"`
+	Date string `json:"date"`
+	// Location is the room the classroom is in that day, which differs
+	// from its own when LocationOverride is set.
+	Location         *room.RoomInfor           `json:"location,omitempty"`
+	LocationOverride *LocationOverride         `json:"location_override,omitempty"`
+	Leader           *user.UserInfor           `json:"leader,omitempty"`
+	Assignments      []*SlotAssignmentResponse `json:"assignments"`
+	Sessions         []*SessionSchedule        `json:"sessions"`
+}
+
+type SessionSchedule struct {
+	SessionID   string                    `json:"session_id"`
+	Name        string                    `json:"name"`
+	StartTime   string                    `json:"start_time"`
+	EndTime     string                    `json:"end_time"`
 	Leader      *user.UserInfor           `json:"leader,omitempty"`
 	Assignments []*SlotAssignmentResponse `json:"assignments"`
 }
@@ -95,8 +118,9 @@ type ClassroomTemplateGatewayResponse struct {
 }
 
 type AssignTemplate struct {
-	StudentID *string `json:"student_id"`
-	TeacherID *string `json:"teacher_id"`
+	StudentID  *string                   `json:"student_id"`
+	TeacherID  *string                   `json:"teacher_id"`
+	Attendance *assign.AttendancePattern `json:"attendance,omitempty"`
 }
 
 type ClassroomTemplateByTermIDAndStudentIDResponse struct {
@@ -109,3 +133,42 @@ type ClassRoomTemplateResponse struct {
 	ClassID   string `json:"class_id"`
 	ClassName string `json:"class_name"`
 }
+
+type RoomUsageResponse struct {
+	LocationID string          `json:"location_id"`
+	RoomName   string          `json:"room_name"`
+	Capacity   *int            `json:"capacity"`
+	StartDate  string          `json:"start_date"`
+	EndDate    string          `json:"end_date"`
+	Days       []*RoomUsageDay `json:"days"`
+}
+
+type RoomUsageDay struct {
+	Date         string          `json:"date"`
+	Bookings     []*RoomBooking  `json:"bookings"`
+	Conflicts    []*RoomConflict `json:"conflicts"`
+	OverCapacity bool            `json:"over_capacity"`
+}
+
+// RoomBooking is a classroom using the room in a session of the day; no
+// session means the whole day. Occupancy also counts the students of
+// conflicting bookings, since they would be in the room at the same time.
+type RoomBooking struct {
+	ClassroomID   string              `json:"classroom_id"`
+	ClassroomName string              `json:"classroom_name"`
+	SessionID     *primitive.ObjectID `json:"session_id"`
+	SessionName   string              `json:"session_name"`
+	StartTime     string              `json:"start_time"`
+	EndTime       string              `json:"end_time"`
+	Students      int                 `json:"students"`
+	Teachers      int                 `json:"teachers"`
+	Occupancy     int                 `json:"occupancy"`
+	OverCapacity  bool                `json:"over_capacity"`
+}
+
+type RoomConflict struct {
+	FirstClassroomID  string              `json:"first_classroom_id"`
+	FirstSessionID    *primitive.ObjectID `json:"first_session_id"`
+	SecondClassroomID string              `json:"second_classroom_id"`
+	SecondSessionID   *primitive.ObjectID `json:"second_session_id"`
+}
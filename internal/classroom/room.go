@@ -0,0 +1,621 @@
+package classroom
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/room"
+	"classroom-service/internal/session"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxRoomReportDays = 31
+	// roomCheckDays is how far ahead bookings are checked when a classroom
+	// moves to another room.
+	roomCheckDays = 366
+)
+
+var (
+	ErrRoomNotFound     = errors.New("room not found")
+	ErrRoomDoubleBooked = errors.New("room is used by another classroom at the same time")
+	ErrRoomOverCapacity = errors.New("room capacity exceeded")
+)
+
+// getRoom loads a room from the inventory service. Any failure is reported
+// as ErrRoomNotFound, as the service does not tell them apart.
+func (s *classroomService) getRoom(ctx context.Context, locationID primitive.ObjectID) (*room.RoomInfor, error) {
+
+	info, err := s.RoomService.GetRoomByID(ctx, locationID.Hex())
+	if err != nil || info == nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrRoomNotFound, locationID.Hex(), err)
+	}
+
+	return info, nil
+
+}
+
+// roomCapacity is the capacity of a room, or nil when it is unknown or the
+// inventory service cannot be reached.
+func (s *classroomService) roomCapacity(ctx context.Context, locationID primitive.ObjectID) *int {
+
+	info, err := s.RoomService.GetRoomByID(ctx, locationID.Hex())
+	if err != nil || info == nil {
+		log.Printf("[ERROR] cannot load room %s: %v", locationID.Hex(), err)
+		return nil
+	}
+
+	return info.Capacity
+
+}
+
+// roomChange is a change of where a classroom is that is not stored yet:
+// a classroom carrying its new base location, a new override or an
+// override about to be removed.
+type roomChange struct {
+	classroom *ClassRoom
+	override  *LocationOverride
+	removed   *LocationOverride
+}
+
+// roomOccupants returns the active classrooms that are in the room on some
+// day between start and end (exclusive), with their daily assignments on
+// the days they are in it. Location overrides are taken into account, as
+// is the pending change when not nil.
+func (s *classroomService) roomOccupants(ctx context.Context, locationID primitive.ObjectID, start, end time.Time, change *roomChange) ([]*ClassRoom, []*assign.TeacherStudentAssignment, error) {
+
+	base, err := s.ClassroomRepository.GetActiveClassroomsByLocationID(ctx, locationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	visiting, err := s.ClassroomRepository.GetLocationOverridesByLocation(ctx, locationID, &start, &end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*ClassRoom, len(base))
+	for _, c := range base {
+		byID[c.ID] = c
+	}
+
+	var missing []primitive.ObjectID
+	for _, o := range visiting {
+		if _, ok := byID[o.ClassRoomID]; !ok {
+			missing = append(missing, o.ClassRoomID)
+		}
+	}
+	if change != nil && change.override != nil {
+		if _, ok := byID[change.override.ClassRoomID]; !ok {
+			missing = append(missing, change.override.ClassRoomID)
+		}
+	}
+
+	others, err := s.ClassroomRepository.GetClassroomsByIDs(ctx, missing)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range others {
+		if c.IsActive {
+			byID[c.ID] = c
+		}
+	}
+
+	if change != nil && change.classroom != nil {
+		byID[change.classroom.ID] = change.classroom
+	}
+
+	classrooms := make([]*ClassRoom, 0, len(byID))
+	ids := make([]primitive.ObjectID, 0, len(byID))
+	for id, c := range byID {
+		classrooms = append(classrooms, c)
+		ids = append(ids, id)
+	}
+
+	overrides, err := s.ClassroomRepository.GetLocationOverridesByClassrooms(ctx, ids, &start, &end)
+	if err != nil {
+		return nil, nil, err
+	}
+	if change != nil && change.override != nil {
+		overrides = append(overrides, change.override)
+	}
+	if change != nil && change.removed != nil {
+		kept := overrides[:0]
+		for _, o := range overrides {
+			if o.ID != change.removed.ID {
+				kept = append(kept, o)
+			}
+		}
+		overrides = kept
+	}
+
+	var assignments []*assign.TeacherStudentAssignment
+	for _, c := range classrooms {
+		list, err := s.AssignRepository.GetAssignmentsByClassroomID(ctx, c.ID, &start, &end)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, a := range list {
+			if at := effectiveLocation(c, overrides, a.AssignDate); at != nil && *at == locationID {
+				assignments = append(assignments, a)
+			}
+		}
+	}
+
+	return classrooms, assignments, nil
+
+}
+
+// buildRoomUsage groups assignments into bookings per day and finds where
+// classrooms overlap in the room. Days without bookings are left out.
+func (s *classroomService) buildRoomUsage(ctx context.Context, classrooms []*ClassRoom, assignments []*assign.TeacherStudentAssignment, capacity *int) ([]*RoomUsageDay, error) {
+
+	classroomByID := make(map[primitive.ObjectID]*ClassRoom, len(classrooms))
+	for _, c := range classrooms {
+		classroomByID[c.ID] = c
+	}
+
+	type bookingKey struct {
+		date        string
+		classroomID primitive.ObjectID
+		sessionID   primitive.ObjectID
+	}
+
+	type bookingPeople struct {
+		booking  *RoomBooking
+		students map[string]bool
+		teachers map[string]bool
+	}
+
+	bookings := make(map[bookingKey]*bookingPeople)
+	days := make(map[string]*RoomUsageDay)
+	sessionIDs := make(map[primitive.ObjectID]bool)
+
+	for _, a := range assignments {
+		c, ok := classroomByID[a.ClassRoomID]
+		if !ok || (a.StudentID == nil && a.TeacherID == nil) {
+			continue
+		}
+
+		date := a.AssignDate.Format("2006-01-02")
+		key := bookingKey{date: date, classroomID: a.ClassRoomID}
+		if a.SessionID != nil {
+			key.sessionID = *a.SessionID
+			sessionIDs[*a.SessionID] = true
+		}
+
+		b, ok := bookings[key]
+		if !ok {
+			b = &bookingPeople{
+				booking: &RoomBooking{
+					ClassroomID:   c.ID.Hex(),
+					ClassroomName: c.Name,
+					SessionID:     a.SessionID,
+				},
+				students: make(map[string]bool),
+				teachers: make(map[string]bool),
+			}
+			bookings[key] = b
+
+			day, ok := days[date]
+			if !ok {
+				day = &RoomUsageDay{
+					Date:      date,
+					Bookings:  make([]*RoomBooking, 0),
+					Conflicts: make([]*RoomConflict, 0),
+				}
+				days[date] = day
+			}
+			day.Bookings = append(day.Bookings, b.booking)
+		}
+
+		if a.StudentID != nil {
+			b.students[*a.StudentID] = true
+		}
+		if a.TeacherID != nil {
+			b.teachers[*a.TeacherID] = true
+		}
+	}
+
+	for _, b := range bookings {
+		b.booking.Students = len(b.students)
+		b.booking.Teachers = len(b.teachers)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(sessionIDs))
+	for id := range sessionIDs {
+		ids = append(ids, id)
+	}
+
+	sessionByID := make(map[primitive.ObjectID]*session.Session, len(ids))
+	if len(ids) > 0 {
+		sessions, err := s.SessionRepository.GetSessionsByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, sess := range sessions {
+			sessionByID[sess.ID] = sess
+		}
+	}
+
+	lookup := func(id *primitive.ObjectID) *session.Session {
+		if id == nil {
+			return nil
+		}
+		return sessionByID[*id]
+	}
+
+	result := make([]*RoomUsageDay, 0, len(days))
+	for _, day := range days {
+		sort.Slice(day.Bookings, func(i, j int) bool {
+			a, b := lookup(day.Bookings[i].SessionID), lookup(day.Bookings[j].SessionID)
+			if a != nil && b != nil && a.StartTime != b.StartTime {
+				return a.StartTime < b.StartTime
+			}
+			return day.Bookings[i].ClassroomName < day.Bookings[j].ClassroomName
+		})
+
+		for _, b := range day.Bookings {
+			if sess := lookup(b.SessionID); sess != nil {
+				b.SessionName = sess.Name
+				b.StartTime = sess.StartTime
+				b.EndTime = sess.EndTime
+			}
+			b.Occupancy = b.Students
+		}
+
+		for i, first := range day.Bookings {
+			for _, second := range day.Bookings[i+1:] {
+				if first.ClassroomID == second.ClassroomID || !session.Conflicts(lookup(first.SessionID), lookup(second.SessionID)) {
+					continue
+				}
+
+				day.Conflicts = append(day.Conflicts, &RoomConflict{
+					FirstClassroomID:  first.ClassroomID,
+					FirstSessionID:    first.SessionID,
+					SecondClassroomID: second.ClassroomID,
+					SecondSessionID:   second.SessionID,
+				})
+				first.Occupancy += second.Students
+				second.Occupancy += first.Students
+			}
+		}
+
+		if capacity != nil {
+			for _, b := range day.Bookings {
+				if b.Occupancy > *capacity {
+					b.OverCapacity = true
+					day.OverCapacity = true
+				}
+			}
+		}
+
+		result = append(result, day)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
+
+	return result, nil
+
+}
+
+// checkRoomChange refuses a change that would make the classroom share the
+// room with another active classroom in a session between start and end, or
+// exceed the room capacity.
+func (s *classroomService) checkRoomChange(ctx context.Context, classroomID, locationID primitive.ObjectID, start, end time.Time, capacity *int, change *roomChange) error {
+
+	classrooms, assignments, err := s.roomOccupants(ctx, locationID, start, end, change)
+	if err != nil {
+		return err
+	}
+
+	days, err := s.buildRoomUsage(ctx, classrooms, assignments, capacity)
+	if err != nil {
+		return err
+	}
+
+	id := classroomID.Hex()
+	for _, day := range days {
+		for _, conflict := range day.Conflicts {
+			if conflict.FirstClassroomID == id || conflict.SecondClassroomID == id {
+				return fmt.Errorf("%w on %s", ErrRoomDoubleBooked, day.Date)
+			}
+		}
+		for _, b := range day.Bookings {
+			if b.ClassroomID == id && b.OverCapacity {
+				return fmt.Errorf("%w on %s: %d students for %d places", ErrRoomOverCapacity, day.Date, b.Occupancy, *capacity)
+			}
+		}
+	}
+
+	return nil
+
+}
+
+// checkRoomMove checks a classroom moving to another room from today on.
+func (s *classroomService) checkRoomMove(ctx context.Context, classroom *ClassRoom, locationID primitive.ObjectID, capacity *int) error {
+
+	moved := *classroom
+	moved.LocationID = &locationID
+
+	start, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	end := start.AddDate(0, 0, roomCheckDays)
+
+	return s.checkRoomChange(ctx, classroom.ID, locationID, start, end, capacity, &roomChange{classroom: &moved})
+
+}
+
+// CheckAssignment implements assign.AssignmentGuard. It refuses a change
+// that makes the classroom share its room with another classroom in a
+// session where it did not before, or that adds students to a booking
+// above the room capacity.
+func (s *classroomService) CheckAssignment(ctx context.Context, before, after *assign.TeacherStudentAssignment) error {
+
+	if after == nil || (after.StudentID == nil && after.TeacherID == nil) {
+		return nil
+	}
+
+	classroom, err := s.ClassroomRepository.GetClassroomByID(ctx, after.ClassRoomID)
+	if err != nil {
+		return err
+	}
+	if classroom == nil || !classroom.IsActive {
+		return nil
+	}
+
+	locationID, _, err := LocationForDate(ctx, s.ClassroomRepository, classroom, after.AssignDate)
+	if err != nil {
+		return err
+	}
+	if locationID == nil {
+		return nil
+	}
+
+	start := after.AssignDate
+	end := start.Add(24 * time.Hour)
+
+	classrooms, current, err := s.roomOccupants(ctx, *locationID, start, end, nil)
+	if err != nil {
+		return err
+	}
+
+	proposed := make([]*assign.TeacherStudentAssignment, 0, len(current)+1)
+	for _, a := range current {
+		if a.ID == after.ID || (before != nil && a.ID == before.ID) {
+			continue
+		}
+		proposed = append(proposed, a)
+	}
+	proposed = append(proposed, after)
+
+	capacity := s.roomCapacity(ctx, *locationID)
+
+	currentDays, err := s.buildRoomUsage(ctx, classrooms, current, capacity)
+	if err != nil {
+		return err
+	}
+
+	proposedDays, err := s.buildRoomUsage(ctx, classrooms, proposed, capacity)
+	if err != nil {
+		return err
+	}
+
+	type slot struct {
+		classroomID string
+		sessionID   primitive.ObjectID
+	}
+
+	key := func(classroomID string, sessionID *primitive.ObjectID) slot {
+		k := slot{classroomID: classroomID}
+		if sessionID != nil {
+			k.sessionID = *sessionID
+		}
+		return k
+	}
+
+	conflicting := make(map[[2]slot]bool)
+	occupancy := make(map[slot]int)
+	for _, day := range currentDays {
+		for _, c := range day.Conflicts {
+			conflicting[[2]slot{key(c.FirstClassroomID, c.FirstSessionID), key(c.SecondClassroomID, c.SecondSessionID)}] = true
+			conflicting[[2]slot{key(c.SecondClassroomID, c.SecondSessionID), key(c.FirstClassroomID, c.FirstSessionID)}] = true
+		}
+		for _, b := range day.Bookings {
+			occupancy[key(b.ClassroomID, b.SessionID)] = b.Occupancy
+		}
+	}
+
+	target := key(after.ClassRoomID.Hex(), after.SessionID)
+	for _, day := range proposedDays {
+		for _, c := range day.Conflicts {
+			first, second := key(c.FirstClassroomID, c.FirstSessionID), key(c.SecondClassroomID, c.SecondSessionID)
+			if (first == target || second == target) && !conflicting[[2]slot{first, second}] {
+				return fmt.Errorf("%w on %s", ErrRoomDoubleBooked, day.Date)
+			}
+		}
+		for _, b := range day.Bookings {
+			k := key(b.ClassroomID, b.SessionID)
+			if k == target && b.OverCapacity && b.Occupancy > occupancy[k] {
+				return fmt.Errorf("%w on %s: %d students for %d places", ErrRoomOverCapacity, day.Date, b.Occupancy, *capacity)
+			}
+		}
+	}
+
+	return nil
+
+}
+
+func (s *classroomService) GetRoomUsage(ctx context.Context, locationID, start, end string) (*RoomUsageResponse, error) {
+
+	locationObjID, err := primitive.ObjectIDFromHex(locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	startParse, endExclusive, err := parseRoomRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.getRoom(ctx, locationObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	classrooms, assignments, err := s.roomOccupants(ctx, locationObjID, startParse, endExclusive, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := s.buildRoomUsage(ctx, classrooms, assignments, info.Capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RoomUsageResponse{
+		LocationID: locationID,
+		RoomName:   info.Name,
+		Capacity:   info.Capacity,
+		StartDate:  start,
+		EndDate:    end,
+		Days:       days,
+	}, nil
+
+}
+
+// GetRoomConflicts lists, for every room of the organization, the days on
+// which it is double-booked or over capacity.
+func (s *classroomService) GetRoomConflicts(ctx context.Context, start, end string) ([]*RoomUsageResponse, error) {
+
+	startParse, endExclusive, err := parseRoomRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if currentUser == nil || currentUser.OrganizationAdmin == nil {
+		return nil, errors.New("user not found")
+	}
+
+	classrooms, err := s.ClassroomRepository.GetClassroomsByOrgID(ctx, currentUser.OrganizationAdmin.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	locationSet := make(map[primitive.ObjectID]bool)
+	var locations []primitive.ObjectID
+	addLocation := func(id primitive.ObjectID) {
+		if !locationSet[id] {
+			locationSet[id] = true
+			locations = append(locations, id)
+		}
+	}
+
+	classroomIDs := make([]primitive.ObjectID, 0, len(classrooms))
+	for _, c := range classrooms {
+		if !c.IsActive {
+			continue
+		}
+		classroomIDs = append(classroomIDs, c.ID)
+		if c.LocationID != nil {
+			addLocation(*c.LocationID)
+		}
+	}
+
+	overrides, err := s.ClassroomRepository.GetLocationOverridesByClassrooms(ctx, classroomIDs, &startParse, &endExclusive)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		addLocation(o.LocationID)
+	}
+
+	result := make([]*RoomUsageResponse, 0)
+	for _, locationID := range locations {
+		roomClassrooms, assignments, err := s.roomOccupants(ctx, locationID, startParse, endExclusive, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var capacity *int
+		roomName := ""
+		if info, err := s.getRoom(ctx, locationID); err != nil {
+			log.Printf("[ERROR] %v", err)
+		} else {
+			capacity = info.Capacity
+			roomName = info.Name
+		}
+
+		// A room used by one classroom can only be over capacity.
+		if len(roomClassrooms) < 2 && capacity == nil {
+			continue
+		}
+
+		days, err := s.buildRoomUsage(ctx, roomClassrooms, assignments, capacity)
+		if err != nil {
+			return nil, err
+		}
+
+		problems := make([]*RoomUsageDay, 0)
+		for _, day := range days {
+			if len(day.Conflicts) > 0 || day.OverCapacity {
+				problems = append(problems, day)
+			}
+		}
+
+		if len(problems) == 0 {
+			continue
+		}
+
+		result = append(result, &RoomUsageResponse{
+			LocationID: locationID.Hex(),
+			RoomName:   roomName,
+			Capacity:   capacity,
+			StartDate:  start,
+			EndDate:    end,
+			Days:       problems,
+		})
+	}
+
+	return result, nil
+
+}
+
+func parseRoomRange(start, end string) (time.Time, time.Time, error) {
+
+	if start == "" || end == "" {
+		return time.Time{}, time.Time{}, errors.New("start_date and end_date are required")
+	}
+
+	startParse, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	endParse, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if endParse.Before(startParse) {
+		return time.Time{}, time.Time{}, errors.New("end_date must not be before start_date")
+	}
+
+	endExclusive := endParse.AddDate(0, 0, 1)
+	if endExclusive.Sub(startParse) > maxRoomReportDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("date range cannot exceed %d days", maxRoomReportDays)
+	}
+
+	return startParse, endExclusive, nil
+
+}
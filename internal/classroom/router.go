@@ -20,6 +20,13 @@ func RegisterRoutes(r *gin.Engine, handler *ClassroomHandler) {
 		classroomGroup.GET("/template/teacher/term-student", handler.GetTeacherTemplateByTermIDAndStudentID)
 		// Classroom Assignment
 		classroomGroup.GET("/teacher-assignments", handler.GetTeacherAssignments)
+		// Rooms
+		classroomGroup.GET("/rooms/usage", handler.GetRoomUsage)
+		classroomGroup.GET("/rooms/conflicts", handler.GetRoomConflicts)
+		// Location Overrides
+		classroomGroup.POST("/location-overrides", handler.CreateLocationOverride)
+		classroomGroup.GET("/location-overrides", handler.GetLocationOverrides)
+		classroomGroup.POST("/remove/location-overrides", handler.DeleteLocationOverride)
 	}
 	apiGatewayClassroomGroup := r.Group("/api/v1/gateway", middleware.Secured())
 	{
@@ -2,9 +2,13 @@ package classroom
 
 import (
 	"classroom-service/internal/assign"
+	"classroom-service/internal/event"
 	"classroom-service/internal/language"
 	"classroom-service/internal/leader"
+	"classroom-service/internal/notification"
 	"classroom-service/internal/room"
+	"classroom-service/internal/session"
+	"classroom-service/internal/teacher"
 	"classroom-service/internal/term"
 	"classroom-service/internal/user"
 	"context"
@@ -13,6 +17,7 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -38,6 +43,16 @@ type ClassroomService interface {
 	GetClassroomTemplateByTermID(ctx context.Context, termID string) ([]*ClassroomTemplateGatewayResponse, error)
 	GetClassroomTemplateByTermIDAndClassroomID(ctx context.Context, classroomID, termID string) (*ClassroomTemplateGatewayResponse, error)
 	GetStudentAssignmentsByTermAndTeacherID(ctx context.Context, termID, teacherID string) ([]*string, error)
+	// Notifications
+	CronNotifications(ctx context.Context) error
+	SendDailyNotifications(ctx context.Context, date time.Time) error
+	// Rooms
+	GetRoomUsage(ctx context.Context, locationID, start, end string) (*RoomUsageResponse, error)
+	GetRoomConflicts(ctx context.Context, start, end string) ([]*RoomUsageResponse, error)
+	CreateLocationOverride(ctx context.Context, req *CreateLocationOverrideRequest, userID string) (*LocationOverride, error)
+	GetLocationOverrides(ctx context.Context, classroomID, start, end string) ([]*LocationOverride, error)
+	DeleteLocationOverride(ctx context.Context, req *DeleteLocationOverrideRequest, userID string) error
+	assign.AssignmentGuard
 }
 
 type classroomService struct {
@@ -48,6 +63,13 @@ type classroomService struct {
 	LanguageService     language.MessageLanguageGateway
 	TermService         term.TermService
 	RoomService         room.RoomService
+	EventPublisher      event.Publisher
+	Notifier            notification.Notifier
+	TeacherRepository   teacher.TeacherRepository
+	SessionRepository   session.SessionRepository
+	// AssignmentGuard holds template expansion to the checks every other
+	// assignment write goes through.
+	AssignmentGuard assign.AssignmentGuard
 }
 
 func NewClassroomService(classroomRepository ClassroomRepository,
@@ -56,7 +78,12 @@ func NewClassroomService(classroomRepository ClassroomRepository,
 	leaderRepository leader.LeaderRepository,
 	languageService language.MessageLanguageGateway,
 	termService term.TermService,
-	roomService room.RoomService) ClassroomService {
+	roomService room.RoomService,
+	publisher event.Publisher,
+	notifier notification.Notifier,
+	teacherRepository teacher.TeacherRepository,
+	sessionRepository session.SessionRepository,
+	assignmentGuard assign.AssignmentGuard) ClassroomService {
 	return &classroomService{
 		ClassroomRepository: classroomRepository,
 		AssignRepository:    assignRepository,
@@ -65,9 +92,34 @@ func NewClassroomService(classroomRepository ClassroomRepository,
 		LanguageService:     languageService,
 		TermService:         termService,
 		RoomService:         roomService,
+		EventPublisher:      publisher,
+		Notifier:            notifier,
+		TeacherRepository:   teacherRepository,
+		SessionRepository:   sessionRepository,
+		AssignmentGuard:     assignmentGuard,
 	}
 }
 
+func classroomEvent(eventType string, classroom *ClassRoom, userID string) *event.Event {
+
+	data := map[string]interface{}{
+		"organization_id": classroom.OrganizationID,
+		"name":            classroom.Name,
+		"is_active":       classroom.IsActive,
+	}
+
+	if classroom.RegionID != nil {
+		data["region_id"] = classroom.RegionID.Hex()
+	}
+
+	if classroom.LocationID != nil {
+		data["location_id"] = classroom.LocationID.Hex()
+	}
+
+	return event.New(eventType, "classroom", classroom.ID.Hex(), data).WithActor(userID)
+
+}
+
 func (s *classroomService) CreateClassroom(ctx context.Context, req *CreateClassroomRequest, userID string) (string, error) {
 
 	var locationID *primitive.ObjectID
@@ -77,6 +129,9 @@ func (s *classroomService) CreateClassroom(ctx context.Context, req *CreateClass
 		if err != nil {
 			return "", err
 		}
+		if _, err := s.getRoom(ctx, obj); err != nil {
+			return "", err
+		}
 		locationID = &obj
 	} else {
 		locationID = nil
@@ -116,18 +171,21 @@ func (s *classroomService) CreateClassroom(ctx context.Context, req *CreateClass
 	ClassroomID := primitive.NewObjectID()
 
 	data := &ClassRoom{
-		ID:             ClassroomID,
-		Name:           req.Name,
-		OrganizationID: orgID,
-		Description:    req.Description,
-		Note:           req.Note,
-		Icon:           req.Icon,
-		LocationID:     locationID,
-		RegionID:       regionID,
-		CreatedBy:      userID,
-		IsActive:       true,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		ID:                     ClassroomID,
+		Name:                   req.Name,
+		OrganizationID:         orgID,
+		Description:            req.Description,
+		Note:                   req.Note,
+		Icon:                   req.Icon,
+		LocationID:             locationID,
+		RegionID:               regionID,
+		AgeGroup:               req.AgeGroup,
+		Languages:              req.Languages,
+		RequiredCertifications: req.RequiredCertifications,
+		CreatedBy:              userID,
+		IsActive:               true,
+		CreatedAt:              time.Now(),
+		UpdatedAt:              time.Now(),
 	}
 
 	err = s.ClassroomRepository.CreateClassroom(ctx, data)
@@ -135,6 +193,8 @@ func (s *classroomService) CreateClassroom(ctx context.Context, req *CreateClass
 		return "", err
 	}
 
+	event.Emit(ctx, s.EventPublisher, classroomEvent(event.ClassroomCreated, data, userID))
+
 	languageReq := BuildDepartmentMessagesUpdate(ClassroomID.Hex(), *req)
 
 	err = s.LanguageService.UploadMessages(ctx, languageReq)
@@ -193,14 +253,40 @@ func (s *classroomService) UpdateClassroom(ctx context.Context, req *UpdateClass
 		if err != nil {
 			return fmt.Errorf("invalid location id: %v", err)
 		}
+
+		roomInfo, err := s.getRoom(ctx, locationObjID)
+		if err != nil {
+			return err
+		}
+
+		if classroom.IsActive && (classroom.LocationID == nil || *classroom.LocationID != locationObjID) {
+			if err := s.checkRoomMove(ctx, classroom, locationObjID, roomInfo.Capacity); err != nil {
+				return err
+			}
+		}
+
 		classroom.LocationID = &locationObjID
 	}
 
+	if req.AgeGroup != nil {
+		classroom.AgeGroup = req.AgeGroup
+	}
+
+	if req.Languages != nil {
+		classroom.Languages = req.Languages
+	}
+
+	if req.RequiredCertifications != nil {
+		classroom.RequiredCertifications = req.RequiredCertifications
+	}
+
 	err = s.ClassroomRepository.UpdateClassroom(ctx, objectID, classroom)
 	if err != nil {
 		return err
 	}
 
+	event.Emit(ctx, s.EventPublisher, classroomEvent(event.ClassroomUpdated, classroom, ""))
+
 	reqLanguage := &CreateClassroomRequest{
 		Name:        req.Name,
 		LanguageID:  req.LanguageID,
@@ -299,7 +385,7 @@ func (s *classroomService) GetClassroomByIDTemplate(ctx context.Context, id, ter
 		return nil, err
 	}
 
-	leader, err := s.LeaderRopitory.GetLeaderTemplateByClassID(ctx, objectID, objectIDTerm)
+	leader, err := s.LeaderRopitory.GetLeaderTemplateBySession(ctx, objectID, objectIDTerm, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -359,6 +445,10 @@ func (s *classroomService) GetClassroomByIDTemplate(ctx context.Context, id, ter
 		assignmentResp := &SlotAssignmentResponse{
 			SlotNumber:   assignment.SlotNumber,
 			AssignmentID: &assignmentID,
+			SessionID:    sessionHex(assignment.SessionID),
+			Attendance:   assignment.Attendance,
+			StartDate:    assignment.StartDate,
+			EndDate:      assignment.EndDate,
 			CreatedAt:    &assignment.CreatedAt,
 			UpdatedAt:    &assignment.UpdatedAt,
 		}
@@ -427,12 +517,19 @@ func (s *classroomService) CreateAssignmentByTemplate(ctx context.Context, req *
 		return err
 	}
 
-	assignTemplate, err := s.AssignRepository.GetAssignmentTemplateByClassroomID(ctx, objectID, objectTermID)
+	// Daily assignments come from the published templates; a classroom that
+	// was never published has nothing to expand.
+	assignTemplate, err := s.publishedTemplatesByClassroom(ctx, objectID, objectTermID)
 	if err != nil {
 		return err
 	}
 
-	leaderTemplate, err := s.LeaderRopitory.GetLeaderTemplateByClassID(ctx, objectID, objectTermID)
+	leaderTemplates, err := s.LeaderRopitory.GetLeaderTemplatesByClassID(ctx, objectID, objectTermID)
+	if err != nil {
+		return err
+	}
+
+	leaderRota, err := s.LeaderRopitory.GetLeaderRotaByClassID(ctx, objectID, objectTermID)
 	if err != nil {
 		return err
 	}
@@ -447,43 +544,168 @@ func (s *classroomService) CreateAssignmentByTemplate(ctx context.Context, req *
 		return err
 	}
 
-	if assignTemplate != nil && leaderTemplate != nil {
+	// Teachers are left off the days they are not available; the empty
+	// slots then show up as coverage gaps instead of silently being staffed.
+	teacherIDs := make([]string, 0, len(assignTemplate))
+	for _, a := range assignTemplate {
+		if a.TeacherID != nil {
+			teacherIDs = append(teacherIDs, *a.TeacherID)
+		}
+	}
+
+	profiles, err := s.TeacherRepository.GetTeacherProfilesByTeacherIDs(ctx, teacherIDs)
+	if err != nil {
+		return err
+	}
+
+	teacherProfiles := make(map[string]*teacher.TeacherProfile, len(profiles))
+	for _, p := range profiles {
+		teacherProfiles[p.TeacherID] = p
+	}
+
+	// A rota takes precedence over the whole-day leader template, session
+	// leader templates always apply.
+	leadersOn := func(d time.Time) []*leader.Leader {
+		var dayLeaders []*leader.Leader
+		if leaderRota != nil {
+			if owner := leaderRota.OwnerForDate(d); owner != nil {
+				dayLeaders = append(dayLeaders, &leader.Leader{Owner: owner})
+			}
+		}
+		for _, lt := range leaderTemplates {
+			if lt.SessionID == nil && leaderRota != nil {
+				continue
+			}
+			dayLeaders = append(dayLeaders, &leader.Leader{Owner: lt.Owner, SessionID: lt.SessionID})
+		}
+		return dayLeaders
+	}
+
+	if assignTemplate != nil && (len(leaderTemplates) > 0 || leaderRota != nil) {
+		// Owners are checked for every day before anything is written, so
+		// one leading another classroom on one of the days fails the whole
+		// expansion.
+		type ownerSession struct {
+			ownerID   string
+			sessionID primitive.ObjectID
+			whole     bool
+		}
+		leaderDays := make(map[ownerSession][]time.Time)
 		for d := startParse; d.Before(endParse); d = d.AddDate(0, 0, 1) {
-			leaderData := leader.Leader{
-				ID:          primitive.NewObjectID(),
-				Owner:       leaderTemplate.Owner,
-				ClassRoomID: leaderTemplate.ClassRoomID,
-				Date:        d,
-				CreatedAt:   time.Now(),
-				UpdatedAt:   time.Now(),
+			for _, l := range leadersOn(d) {
+				key := ownerSession{ownerID: l.Owner.OwnerID, whole: l.SessionID == nil}
+				if l.SessionID != nil {
+					key.sessionID = *l.SessionID
+				}
+				leaderDays[key] = append(leaderDays[key], d)
 			}
-			err := s.LeaderRopitory.CreateLeader(ctx, &leaderData)
-			if err != nil {
+		}
+		for key, days := range leaderDays {
+			var sessionID *primitive.ObjectID
+			if !key.whole {
+				id := key.sessionID
+				sessionID = &id
+			}
+			if err := leader.CheckOwnerOnDates(ctx, s.LeaderRopitory, s.SessionRepository, objectID, key.ownerID, sessionID, days); err != nil {
 				return err
 			}
+		}
+
+		// Slots that already hold a guest or trial visit or a closure
+		// placement keep it. Every other slot is replaced, and the new
+		// assignments pass the assignment guards before anything is written.
+		existing, err := s.AssignRepository.GetAssignmentsByClassroomID(ctx, objectID, &startParse, &endParse)
+		if err != nil {
+			return err
+		}
+
+		slotKey := func(slotNumber int, date time.Time, sessionID *primitive.ObjectID) string {
+			session := ""
+			if sessionID != nil {
+				session = sessionID.Hex()
+			}
+			return fmt.Sprintf("%d|%s|%s", slotNumber, date.Format("2006-01-02"), session)
+		}
+
+		stored := make(map[string]*assign.TeacherStudentAssignment, len(existing))
+		for _, a := range existing {
+			stored[slotKey(a.SlotNumber, a.AssignDate, a.SessionID)] = a
+		}
+
+		var assignments []*assign.TeacherStudentAssignment
+		for d := startParse; d.Before(endParse); d = d.AddDate(0, 0, 1) {
 			for _, assignment := range assignTemplate {
-				assignmentData := assign.TeacherStudentAssignment{
+				if !assignment.ActiveOn(d) || !assignment.Attendance.AttendsOn(d) {
+					continue
+				}
+				before := stored[slotKey(assignment.SlotNumber, d, assignment.SessionID)]
+				if before != nil && (before.IsGuest() || before.ClosureID != nil) {
+					continue
+				}
+				teacherID := assignment.TeacherID
+				if teacherID != nil {
+					if p, ok := teacherProfiles[*teacherID]; ok && !p.IsAvailableOn(d) {
+						teacherID = nil
+					}
+				}
+				assignmentData := &assign.TeacherStudentAssignment{
 					ID:             primitive.NewObjectID(),
 					ClassRoomID:    objectID,
 					SlotNumber:     assignment.SlotNumber,
 					AssignDate:     d,
-					TeacherID:      assignment.TeacherID,
+					SessionID:      assignment.SessionID,
+					TeacherID:      teacherID,
 					StudentID:      assignment.StudentID,
 					CreatedBy:      assignment.CreatedBy,
 					IsNotification: false,
 					CreatedAt:      time.Now(),
 					UpdatedAt:      time.Now(),
 				}
-				err := s.AssignRepository.CreateAssignment(ctx, &assignmentData)
+				if teacherID != nil {
+					assignmentData.EligibilityOverride = assignment.EligibilityOverride
+				}
+				if err := s.AssignmentGuard.CheckAssignment(ctx, before, assignmentData); err != nil {
+					return err
+				}
+				assignments = append(assignments, assignmentData)
+			}
+		}
+
+		for d := startParse; d.Before(endParse); d = d.AddDate(0, 0, 1) {
+			for _, l := range leadersOn(d) {
+				leaderData := leader.Leader{
+					ID:          primitive.NewObjectID(),
+					Owner:       l.Owner,
+					ClassRoomID: objectID,
+					SessionID:   l.SessionID,
+					Date:        d,
+					CreatedAt:   time.Now(),
+					UpdatedAt:   time.Now(),
+				}
+				err := s.LeaderRopitory.CreateLeader(ctx, &leaderData)
 				if err != nil {
 					return err
 				}
 			}
 		}
+
+		for _, assignmentData := range assignments {
+			if err := s.AssignRepository.CreateAssignment(ctx, assignmentData); err != nil {
+				return err
+			}
+		}
 	} else {
 		return errors.New("template not found")
 	}
 
+	// Expanding a template writes one document per slot and day, so a
+	// single summary event is emitted instead of one per assignment.
+	event.Emit(ctx, s.EventPublisher, event.New(event.ClassroomTemplateApplied, "classroom", objectID.Hex(), map[string]interface{}{
+		"term_id":    objectTermID.Hex(),
+		"start_date": req.StartDate,
+		"end_date":   req.EndDate,
+	}))
+
 	return nil
 
 }
@@ -567,6 +789,97 @@ func (s *classroomService) GetTeacherAssignments(ctx context.Context, userID, or
 
 }
 
+func sessionHex(id *primitive.ObjectID) *string {
+	if id == nil {
+		return nil
+	}
+	hex := id.Hex()
+	return &hex
+}
+
+// newDailySchedule starts a day of the schedule with an empty group for each
+// of the classroom's sessions, in time order.
+func newDailySchedule(date string, sessions []*session.Session) *DailySchedule {
+
+	day := &DailySchedule{
+		Date:        date,
+		Assignments: []*SlotAssignmentResponse{},
+		Sessions:    make([]*SessionSchedule, 0, len(sessions)),
+	}
+
+	for _, sess := range sessions {
+		day.Sessions = append(day.Sessions, &SessionSchedule{
+			SessionID:   sess.ID.Hex(),
+			Name:        sess.Name,
+			StartTime:   sess.StartTime,
+			EndTime:     sess.EndTime,
+			Assignments: []*SlotAssignmentResponse{},
+		})
+	}
+
+	return day
+
+}
+
+// sessionSchedule returns the group of a session within the day, adding one
+// for sessions that are no longer configured.
+func sessionSchedule(day *DailySchedule, sessionID primitive.ObjectID) *SessionSchedule {
+
+	for _, group := range day.Sessions {
+		if group.SessionID == sessionID.Hex() {
+			return group
+		}
+	}
+
+	group := &SessionSchedule{
+		SessionID:   sessionID.Hex(),
+		Name:        "Deleted",
+		Assignments: []*SlotAssignmentResponse{},
+	}
+	day.Sessions = append(day.Sessions, group)
+
+	return group
+
+}
+
+// scheduleLeaderInfor resolves a leader for the schedule. It returns nil for
+// unknown owner roles and an empty user when the person cannot be found.
+func (s *classroomService) scheduleLeaderInfor(ctx context.Context, owner *leader.Owner) (*user.UserInfor, error) {
+
+	var (
+		leaderInforData *user.UserInfor
+		err             error
+	)
+
+	switch owner.OwnerRole {
+	case leader.OwnerRoleTeacher:
+		leaderInforData, err = s.UserService.GetTeacherInfor(ctx, owner.OwnerID)
+	case leader.OwnerRoleStaff:
+		leaderInforData, err = s.UserService.GetStaffInfor(ctx, owner.OwnerID)
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if leaderInforData == nil {
+		return &user.UserInfor{
+			UserID:   "",
+			UserName: "",
+			Avartar:  user.Avatar{},
+		}, nil
+	}
+
+	return &user.UserInfor{
+		UserID:   leaderInforData.UserID,
+		UserName: leaderInforData.UserName,
+		Avartar:  leaderInforData.Avartar,
+	}, nil
+
+}
+
 func (s *classroomService) GetClassroomByID(ctx context.Context, id, start, end string, page, limit int) (*ClassroomScheduleResponse, error) {
 
 	if id == "" {
@@ -620,67 +933,41 @@ func (s *classroomService) GetClassroomByID(ctx context.Context, id, start, end
 		return nil, err
 	}
 
-	scheduleMap := make(map[string]*DailySchedule)
-
-	for _, leader := range leaderByClasses {
-		if leader != nil && leader.Owner != nil {
-			date := leader.Date.Format("2006-01-02")
-			switch leader.Owner.OwnerRole {
-			case "teacher":
-				leaderInforData, err := s.UserService.GetTeacherInfor(ctx, leader.Owner.OwnerID)
-				if err != nil {
-					return nil, err
-				}
+	sessions, err := session.EffectiveSessions(ctx, s.SessionRepository, objectID)
+	if err != nil {
+		return nil, err
+	}
 
-				var leaderInfor *user.UserInfor
+	scheduleMap := make(map[string]*DailySchedule)
 
-				if leaderInforData != nil {
-					leaderInfor = &user.UserInfor{
-						UserID:   leaderInforData.UserID,
-						UserName: leaderInforData.UserName,
-						Avartar:  leaderInforData.Avartar,
-					}
-				} else {
-					leaderInfor = &user.UserInfor{
-						UserID:   "",
-						UserName: "",
-						Avartar:  user.Avatar{},
-					}
-				}
+	// Days are listed through their leaders, which is also what the
+	// pagination counts. Whole-day leaders and assignments stay on the day,
+	// the others are grouped under their session.
+	for _, l := range leaderByClasses {
+		if l == nil || l.Owner == nil {
+			continue
+		}
 
-				scheduleMap[date] = &DailySchedule{
-					Date:        date,
-					Leader:      leaderInfor,
-					Assignments: []*SlotAssignmentResponse{},
-				}
+		leaderInfor, err := s.scheduleLeaderInfor(ctx, l.Owner)
+		if err != nil {
+			return nil, err
+		}
 
-			case "staff":
-				leaderInforData, err := s.UserService.GetStaffInfor(ctx, leader.Owner.OwnerID)
-				if err != nil {
-					return nil, err
-				}
+		if leaderInfor == nil {
+			continue
+		}
 
-				var leaderInfor *user.UserInfor
+		date := l.Date.Format("2006-01-02")
+		day, ok := scheduleMap[date]
+		if !ok {
+			day = newDailySchedule(date, sessions)
+			scheduleMap[date] = day
+		}
 
-				if leaderInforData != nil {
-					leaderInfor = &user.UserInfor{
-						UserID:   leaderInforData.UserID,
-						UserName: leaderInforData.UserName,
-						Avartar:  leaderInforData.Avartar,
-					}
-				} else {
-					leaderInfor = &user.UserInfor{
-						UserID:   "",
-						UserName: "",
-						Avartar:  user.Avatar{},
-					}
-				}
-				scheduleMap[date] = &DailySchedule{
-					Date:        date,
-					Leader:      leaderInfor,
-					Assignments: []*SlotAssignmentResponse{},
-				}
-			}
+		if l.SessionID == nil {
+			day.Leader = leaderInfor
+		} else {
+			sessionSchedule(day, *l.SessionID).Leader = leaderInfor
 		}
 	}
 
@@ -688,7 +975,8 @@ func (s *classroomService) GetClassroomByID(ctx context.Context, id, start, end
 
 		date := a.AssignDate.Format("2006-01-02")
 
-		if _, ok := scheduleMap[date]; !ok {
+		day, ok := scheduleMap[date]
+		if !ok {
 			continue
 		}
 
@@ -722,12 +1010,21 @@ func (s *classroomService) GetClassroomByID(ctx context.Context, id, start, end
 
 		id := a.ID.Hex()
 
-		scheduleMap[date].Assignments = append(scheduleMap[date].Assignments, &SlotAssignmentResponse{
+		slot := &SlotAssignmentResponse{
 			AssignmentID: &id,
 			SlotNumber:   a.SlotNumber,
+			SessionID:    sessionHex(a.SessionID),
 			Teacher:      teacherInfo,
 			Student:      studentInfo,
-		})
+			Type:         a.Type,
+		}
+
+		if a.SessionID == nil {
+			day.Assignments = append(day.Assignments, slot)
+		} else {
+			group := sessionSchedule(day, *a.SessionID)
+			group.Assignments = append(group.Assignments, slot)
+		}
 	}
 
 	var schedule []*DailySchedule
@@ -739,6 +1036,44 @@ func (s *classroomService) GetClassroomByID(ctx context.Context, id, start, end
 		return schedule[i].Date < schedule[j].Date
 	})
 
+	overrideEnd := endParse.AddDate(0, 0, 1)
+	overrides, err := s.ClassroomRepository.GetLocationOverridesByClassroom(ctx, objectID, &startParse, &overrideEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make(map[primitive.ObjectID]*room.RoomInfor)
+	for _, day := range schedule {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			continue
+		}
+
+		for _, o := range overrides {
+			if o.Covers(date) {
+				day.LocationOverride = o
+			}
+		}
+
+		locationID := effectiveLocation(classroom, overrides, date)
+		if locationID == nil {
+			continue
+		}
+
+		info, ok := rooms[*locationID]
+		if !ok {
+			info, err = s.RoomService.GetRoomByID(ctx, locationID.Hex())
+			if err != nil || info == nil {
+				info = &room.RoomInfor{
+					ID:   locationID.Hex(),
+					Name: "Deleted",
+				}
+			}
+			rooms[*locationID] = info
+		}
+		day.Location = info
+	}
+
 	return &ClassroomScheduleResponse{
 		ClassroomID: classroom.ID.Hex(),
 		ClassName:   classroom.Name,
@@ -825,26 +1160,12 @@ func (s *classroomService) GetStudentsByTermAndClassroomID(ctx context.Context,
 		return nil, err
 	}
 
-	term, err := s.TermService.GetTermByID(ctx, termID)
-	if err != nil {
-		log.Printf("[ERROR] termService.GetTermByID failed (id=%s): %v", termID, err)
-	}
-
-	if term == nil {
-		return nil, fmt.Errorf("term not found")
-	}
-
-	start, err := time.Parse("2006-01-02", term.StartDate)
-	if err != nil {
-		return nil, err
-	}
-
-	end, err := time.Parse("2006-01-02", term.EndDate)
+	objectIDTerm, err := primitive.ObjectIDFromHex(termID)
 	if err != nil {
 		return nil, err
 	}
 
-	assignments, err := s.AssignRepository.GetAssignmentsByClassroomID(ctx, objectID, &start, &end)
+	assignTemplate, err := s.publishedTemplatesByClassroom(ctx, objectID, objectIDTerm)
 	if err != nil {
 		return nil, err
 	}
@@ -852,7 +1173,11 @@ func (s *classroomService) GetStudentsByTermAndClassroomID(ctx context.Context,
 	var infor []*user.UserInfor
 	seen := make(map[string]bool)
 
-	for _, a := range assignments {
+	for _, a := range assignTemplate {
+
+		if a.StudentID == nil || *a.StudentID == "" {
+			continue
+		}
 
 		studentID := *a.StudentID
 		if seen[studentID] {
@@ -861,18 +1186,12 @@ func (s *classroomService) GetStudentsByTermAndClassroomID(ctx context.Context,
 
 		seen[studentID] = true
 
-		var studentInfo *user.UserInfor
-
-		if a.StudentID != nil && *a.StudentID != "" {
-			info, err := s.UserService.GetStudentInfor(ctx, *a.StudentID)
-			if err == nil && info != nil {
-				studentInfo = info
-			} else {
-				studentInfo = &user.UserInfor{
-					UserID:   "",
-					UserName: "",
-					Avartar:  user.Avatar{},
-				}
+		studentInfo, err := s.UserService.GetStudentInfor(ctx, studentID)
+		if err != nil || studentInfo == nil {
+			studentInfo = &user.UserInfor{
+				UserID:   "",
+				UserName: "",
+				Avartar:  user.Avatar{},
 			}
 		}
 
@@ -882,6 +1201,40 @@ func (s *classroomService) GetStudentsByTermAndClassroomID(ctx context.Context,
 	return infor, nil
 }
 
+// publishedTemplatesByClassroom returns the classroom's templates as last
+// published. A classroom that was never published in the term has none.
+func (s *classroomService) publishedTemplatesByClassroom(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*assign.ClassRoomTemplateAssignment, error) {
+
+	publication, err := s.AssignRepository.GetCurrentTemplatePublication(ctx, classroomID, termID)
+	if err != nil {
+		return nil, err
+	}
+	if publication == nil {
+		return nil, nil
+	}
+
+	return publication.Assignments, nil
+
+}
+
+// publishedTemplatesByTerm is publishedTemplatesByClassroom for every
+// classroom of the term.
+func (s *classroomService) publishedTemplatesByTerm(ctx context.Context, termID primitive.ObjectID) ([]*assign.ClassRoomTemplateAssignment, error) {
+
+	publications, err := s.AssignRepository.GetCurrentTemplatePublicationsByTermID(ctx, termID)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []*assign.ClassRoomTemplateAssignment
+	for _, p := range publications {
+		templates = append(templates, p.Assignments...)
+	}
+
+	return templates, nil
+
+}
+
 func (s *classroomService) GetStudentsAndTeachersClassroomTemplateByClassroomID(ctx context.Context, classroomID, termID string) (*ClassroomTemplateByTeacherAndStudent, error) {
 
 	objectID, err := primitive.ObjectIDFromHex(classroomID)
@@ -894,7 +1247,7 @@ func (s *classroomService) GetStudentsAndTeachersClassroomTemplateByClassroomID(
 		return nil, err
 	}
 
-	assignTemplate, err := s.AssignRepository.GetAssignmentTemplateByClassroomID(ctx, objectID, objectIDTerm)
+	assignTemplate, err := s.publishedTemplatesByClassroom(ctx, objectID, objectIDTerm)
 	if err != nil {
 		return nil, err
 	}
@@ -950,7 +1303,7 @@ func (s *classroomService) GetClassroomTemplateByTermID(ctx context.Context, ter
 		return nil, err
 	}
 
-	assignTemplate, err := s.AssignRepository.GetAssignmentTemplateByTermID(ctx, objectIDTerm)
+	assignTemplate, err := s.publishedTemplatesByTerm(ctx, objectIDTerm)
 	if err != nil {
 		return nil, err
 	}
@@ -1034,8 +1387,9 @@ func (s *classroomService) GetClassroomTemplateByTermID(ctx context.Context, ter
 
 		if a.TeacherID != nil && *a.TeacherID != "" && a.StudentID != nil && *a.StudentID != "" {
 			classMap[classID].AssignTemplates = append(classMap[classID].AssignTemplates, &AssignTemplate{
-				TeacherID: a.TeacherID,
-				StudentID: a.StudentID,
+				TeacherID:  a.TeacherID,
+				StudentID:  a.StudentID,
+				Attendance: a.Attendance,
 			})
 		}
 
@@ -1062,7 +1416,7 @@ func (s *classroomService) GetClassroomTemplateByTermIDAndClassroomID(ctx contex
 		return nil, err
 	}
 
-	assignTemplate, err := s.AssignRepository.GetAssignmentTemplateByClassroomID(ctx, objectID, objectIDTerm)
+	assignTemplate, err := s.publishedTemplatesByClassroom(ctx, objectID, objectIDTerm)
 	if err != nil {
 		return nil, err
 	}
@@ -1087,8 +1441,9 @@ func (s *classroomService) GetClassroomTemplateByTermIDAndClassroomID(ctx contex
 	for _, a := range assignTemplate {
 		if a.StudentID != nil && *a.StudentID != "" && a.TeacherID != nil && *a.TeacherID != "" {
 			data.AssignTemplates = append(data.AssignTemplates, &AssignTemplate{
-				TeacherID: a.TeacherID,
-				StudentID: a.StudentID,
+				TeacherID:  a.TeacherID,
+				StudentID:  a.StudentID,
+				Attendance: a.Attendance,
 			})
 		}
 	}
@@ -1196,11 +1551,18 @@ func (s *classroomService) GetTeacherTemplateByTermIDAndStudentID(ctx context.Co
 		return nil, err
 	}
 
-	assignTemplate, err := s.AssignRepository.GetAssignmentTemplateByTermIDAndStudentID(ctx, studentID, objectIDTerm)
+	published, err := s.publishedTemplatesByTerm(ctx, objectIDTerm)
 	if err != nil {
 		return nil, err
 	}
 
+	var assignTemplate []*assign.ClassRoomTemplateAssignment
+	for _, a := range published {
+		if a.StudentID != nil && *a.StudentID == studentID {
+			assignTemplate = append(assignTemplate, a)
+		}
+	}
+
 	if assignTemplate == nil {
 		log.Printf("ClassroomTemplateByTeacherAndStudent not found for classroomID=%s", termID)
 		return nil, nil
@@ -1264,3 +1626,182 @@ func (s *classroomService) GetStudentAssignmentsByTermAndTeacherID(ctx context.C
 	return studentArr, nil
 
 }
+
+// CronNotifications sends tomorrow's digests. It is meant to run once every
+// evening from the scheduler.
+func (s *classroomService) CronNotifications(ctx context.Context) error {
+
+	now := time.Now()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+
+	return s.SendDailyNotifications(ctx, tomorrow)
+
+}
+
+// SendDailyNotifications sends one digest per teacher with their slots on
+// date and one per leader with the classrooms they lead. Assignments and
+// leader days are flagged once the digests went out so a rerun does not
+// notify anybody twice.
+func (s *classroomService) SendDailyNotifications(ctx context.Context, date time.Time) error {
+
+	if s.Notifier == nil {
+		return errors.New("notifier is not configured")
+	}
+
+	dateStr := date.Format("2006-01-02")
+
+	assignments, err := s.AssignRepository.GetUnnotifiedAssignmentsByDate(ctx, &date)
+	if err != nil {
+		return err
+	}
+
+	leaders, err := s.LeaderRopitory.GetUnnotifiedLeadersByDate(ctx, &date)
+	if err != nil {
+		return err
+	}
+
+	classrooms := make(map[primitive.ObjectID]*ClassRoom)
+	getClassroom := func(id primitive.ObjectID) *ClassRoom {
+		if c, ok := classrooms[id]; ok {
+			return c
+		}
+		c, err := s.ClassroomRepository.GetClassroomByID(ctx, id)
+		if err != nil {
+			log.Printf("Cannot fetch class info for class_room_id=%s: %v", id.Hex(), err)
+		}
+		classrooms[id] = c
+		return c
+	}
+
+	messages := make([]*notification.Message, 0)
+
+	// Teacher digests
+	teacherSlots := make(map[string][]*assign.TeacherStudentAssignment)
+	teacherOrder := make([]string, 0)
+	assignmentIDs := make([]primitive.ObjectID, 0, len(assignments))
+	for _, a := range assignments {
+		assignmentIDs = append(assignmentIDs, a.ID)
+		if a.TeacherID == nil || *a.TeacherID == "" {
+			continue
+		}
+		if _, ok := teacherSlots[*a.TeacherID]; !ok {
+			teacherOrder = append(teacherOrder, *a.TeacherID)
+		}
+		teacherSlots[*a.TeacherID] = append(teacherSlots[*a.TeacherID], a)
+	}
+
+	for _, teacherID := range teacherOrder {
+		slots := teacherSlots[teacherID]
+		sort.Slice(slots, func(i, j int) bool {
+			if slots[i].ClassRoomID != slots[j].ClassRoomID {
+				return slots[i].ClassRoomID.Hex() < slots[j].ClassRoomID.Hex()
+			}
+			return slots[i].SlotNumber < slots[j].SlotNumber
+		})
+
+		var orgID string
+		lines := make([]string, 0, len(slots))
+		items := make([]map[string]interface{}, 0, len(slots))
+		for _, a := range slots {
+			name := a.ClassRoomID.Hex()
+			if c := getClassroom(a.ClassRoomID); c != nil {
+				name = c.Name
+				orgID = c.OrganizationID
+			}
+			lines = append(lines, fmt.Sprintf("%s - slot %d", name, a.SlotNumber))
+			items = append(items, map[string]interface{}{
+				"class_room_id": a.ClassRoomID.Hex(),
+				"slot_number":   a.SlotNumber,
+				"student_id":    a.StudentID,
+			})
+		}
+
+		messages = append(messages, &notification.Message{
+			RecipientID:    teacherID,
+			RecipientRole:  notification.RecipientRoleTeacher,
+			OrganizationID: orgID,
+			Type:           notification.TypeTeacherDigest,
+			Title:          fmt.Sprintf("Your classroom schedule for %s", dateStr),
+			Body:           fmt.Sprintf("You have %d slot(s) on %s:\n%s", len(slots), dateStr, strings.Join(lines, "\n")),
+			Data: map[string]interface{}{
+				"date":        dateStr,
+				"assignments": items,
+			},
+			ScheduledFor: date,
+		})
+	}
+
+	// Leader digests
+	type leaderDays struct {
+		owner   *leader.Owner
+		leaders []*leader.Leader
+	}
+	ownerLeaders := make(map[string]*leaderDays)
+	ownerOrder := make([]string, 0)
+	leaderIDs := make([]primitive.ObjectID, 0, len(leaders))
+	for _, l := range leaders {
+		leaderIDs = append(leaderIDs, l.ID)
+		if l.Owner == nil || l.Owner.OwnerID == "" {
+			continue
+		}
+		if _, ok := ownerLeaders[l.Owner.OwnerID]; !ok {
+			ownerOrder = append(ownerOrder, l.Owner.OwnerID)
+			ownerLeaders[l.Owner.OwnerID] = &leaderDays{owner: l.Owner}
+		}
+		ownerLeaders[l.Owner.OwnerID].leaders = append(ownerLeaders[l.Owner.OwnerID].leaders, l)
+	}
+
+	for _, ownerID := range ownerOrder {
+		days := ownerLeaders[ownerID]
+
+		var orgID string
+		names := make([]string, 0, len(days.leaders))
+		classroomIDs := make([]string, 0, len(days.leaders))
+		for _, l := range days.leaders {
+			name := l.ClassRoomID.Hex()
+			if c := getClassroom(l.ClassRoomID); c != nil {
+				name = c.Name
+				orgID = c.OrganizationID
+			}
+			names = append(names, name)
+			classroomIDs = append(classroomIDs, l.ClassRoomID.Hex())
+		}
+
+		role := notification.RecipientRoleTeacher
+		if days.owner.OwnerRole == leader.OwnerRoleStaff {
+			role = notification.RecipientRoleStaff
+		}
+
+		messages = append(messages, &notification.Message{
+			RecipientID:    ownerID,
+			RecipientRole:  role,
+			OrganizationID: orgID,
+			Type:           notification.TypeLeaderDigest,
+			Title:          fmt.Sprintf("You are leading on %s", dateStr),
+			Body:           fmt.Sprintf("You are the leader of %s on %s.", strings.Join(names, ", "), dateStr),
+			Data: map[string]interface{}{
+				"date":           dateStr,
+				"class_room_ids": classroomIDs,
+			},
+			ScheduledFor: date,
+		})
+	}
+
+	if len(messages) > 0 {
+		if err := s.Notifier.Send(ctx, messages); err != nil {
+			return err
+		}
+	}
+
+	if err := s.AssignRepository.MarkAssignmentsNotified(ctx, assignmentIDs); err != nil {
+		return err
+	}
+
+	if err := s.LeaderRopitory.MarkLeadersNotified(ctx, leaderIDs); err != nil {
+		return err
+	}
+
+	log.Printf("Sent %d notification(s) for %s", len(messages), dateStr)
+	return nil
+
+}
@@ -0,0 +1,256 @@
+package closure
+
+import (
+	"classroom-service/helper"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClosureHandler struct {
+	ClosureService ClosureService
+}
+
+func NewClosureHandler(closureService ClosureService) *ClosureHandler {
+	return &ClosureHandler{
+		ClosureService: closureService,
+	}
+}
+
+func closureErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrClosureNotFound), errors.Is(err, ErrClassroomNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, ErrClosureOverlap), errors.Is(err, ErrClosureNotDraft), errors.Is(err, ErrClosureNotApplied),
+		errors.Is(err, ErrPlanOutdated), errors.Is(err, ErrClassroomClosed):
+		return http.StatusConflict, helper.ErrConflict
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *ClosureHandler) CreateClosure(c *gin.Context) {
+
+	var req CreateClosureRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	closure, err := h.ClosureService.CreateClosure(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := closureErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Create Closure Successfully", closure)
+
+}
+
+func (h *ClosureHandler) GetClosures(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	closures, err := h.ClosureService.GetClosures(ctx, classroomID)
+
+	if err != nil {
+		statusCode, errorCode := closureErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Closures Successfully", closures)
+
+}
+
+func (h *ClosureHandler) GetClosure(c *gin.Context) {
+
+	id := c.Param("id")
+	if id == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	closure, err := h.ClosureService.GetClosure(ctx, id)
+
+	if err != nil {
+		statusCode, errorCode := closureErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Closure Successfully", closure)
+
+}
+
+func (h *ClosureHandler) UpdatePlacement(c *gin.Context) {
+
+	var req UpdatePlacementRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	closure, err := h.ClosureService.UpdatePlacement(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := closureErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Update Placement Successfully", closure)
+
+}
+
+func (h *ClosureHandler) ApplyClosure(c *gin.Context) {
+
+	id := c.Param("id")
+	if id == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	closure, err := h.ClosureService.ApplyClosure(ctx, id, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := closureErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Apply Closure Successfully", closure)
+
+}
+
+func (h *ClosureHandler) RestoreClosure(c *gin.Context) {
+
+	id := c.Param("id")
+	if id == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	var req RestoreClosureRequest
+
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+			return
+		}
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	closure, err := h.ClosureService.RestoreClosure(ctx, id, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := closureErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Restore Closure Successfully", closure)
+
+}
+
+func (h *ClosureHandler) DeleteClosure(c *gin.Context) {
+
+	var req DeleteClosureRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.ClosureService.DeleteClosure(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := closureErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete Closure Successfully", nil)
+
+}
@@ -0,0 +1,73 @@
+package closure
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// StatusDraft closures hold a proposed plan that can still be edited.
+	StatusDraft = "draft"
+	// StatusApplied closures have moved their students.
+	StatusApplied = "applied"
+	// StatusRestored closures were ended early and the days left were put
+	// back as they were.
+	StatusRestored = "restored"
+	// StatusEnded closures ran until their end date.
+	StatusEnded = "ended"
+)
+
+// Closure closes a classroom from StartDate to EndDate, both inclusive, and
+// plans where the students of its daily assignments go meanwhile.
+type Closure struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id"`
+	ClassRoomID    primitive.ObjectID  `json:"class_room_id" bson:"class_room_id"`
+	RegionID       *primitive.ObjectID `json:"region_id" bson:"region_id"`
+	OrganizationID string              `json:"organization_id" bson:"organization_id"`
+	StartDate      time.Time           `json:"start_date" bson:"start_date"`
+	EndDate        time.Time           `json:"end_date" bson:"end_date"`
+	Reason         *string             `json:"reason" bson:"reason"`
+	Status         string              `json:"status" bson:"status"`
+	Placements     []*Placement        `json:"placements" bson:"placements"`
+	CreatedBy      string              `json:"created_by" bson:"created_by"`
+	AppliedBy      *string             `json:"applied_by" bson:"applied_by"`
+	AppliedAt      *time.Time          `json:"applied_at" bson:"applied_at"`
+	RestoredBy     *string             `json:"restored_by" bson:"restored_by"`
+	RestoredAt     *time.Time          `json:"restored_at" bson:"restored_at"`
+	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// Covers reports whether the closure applies on date.
+func (c *Closure) Covers(date time.Time) bool {
+	return !date.Before(c.StartDate) && !date.After(c.EndDate)
+}
+
+// Placement is one affected daily assignment of the closed classroom and,
+// when ToClassRoomID is set, where its student goes. Assignments with only
+// a teacher are listed but never placed.
+type Placement struct {
+	AssignmentID primitive.ObjectID  `json:"assignment_id" bson:"assignment_id"`
+	Date         time.Time           `json:"date" bson:"date"`
+	SlotNumber   int                 `json:"slot_number" bson:"slot_number"`
+	SessionID    *primitive.ObjectID `json:"session_id" bson:"session_id"`
+	StudentID    *string             `json:"student_id" bson:"student_id"`
+	TeacherID    *string             `json:"teacher_id" bson:"teacher_id"`
+	// Type is the assignment type of the student, e.g. a trial visit.
+	Type string `json:"type,omitempty" bson:"type,omitempty"`
+
+	ToClassRoomID *primitive.ObjectID `json:"to_class_room_id" bson:"to_class_room_id"`
+	ToSlotNumber  int                 `json:"to_slot_number" bson:"to_slot_number"`
+	ToSessionID   *primitive.ObjectID `json:"to_session_id" bson:"to_session_id"`
+	ToTeacherID   *string             `json:"to_teacher_id" bson:"to_teacher_id"`
+	// Note explains why a student has no placement.
+	Note string `json:"note,omitempty" bson:"note,omitempty"`
+
+	// Filled in when the plan is applied, so it can be undone.
+	TargetAssignmentID *primitive.ObjectID `json:"target_assignment_id,omitempty" bson:"target_assignment_id,omitempty"`
+	CreatedTarget      bool                `json:"created_target,omitempty" bson:"created_target,omitempty"`
+	TeacherMoved       bool                `json:"teacher_moved,omitempty" bson:"teacher_moved,omitempty"`
+	Applied            bool                `json:"applied" bson:"applied"`
+	Restored           bool                `json:"restored" bson:"restored"`
+}
@@ -0,0 +1,367 @@
+package closure
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/classroom"
+	"classroom-service/internal/session"
+	"classroom-service/internal/teacher"
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// candidate is another classroom of the region that can take students
+// while the closure lasts.
+type candidate struct {
+	room         *classroom.ClassRoom
+	sessions     []*session.Session
+	requirements *teacher.ClassroomRequirements
+	// assignments per day, keyed by "2006-01-02"
+	assignments map[string][]*assign.TeacherStudentAssignment
+	closures    []*Closure
+}
+
+func (c *candidate) closedOn(date time.Time) bool {
+	for _, cl := range c.closures {
+		if cl.Covers(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionFor returns the candidate's session matching the original one:
+// the same session when the candidate shares it, otherwise the first one
+// whose hours overlap. ok is false when there is no match.
+func (c *candidate) sessionFor(original *session.Session, originalID *primitive.ObjectID) (*primitive.ObjectID, bool) {
+
+	if originalID == nil {
+		return nil, true
+	}
+
+	for _, s := range c.sessions {
+		if s.ID == *originalID {
+			return &s.ID, true
+		}
+	}
+
+	if original == nil {
+		return nil, false
+	}
+
+	for _, s := range c.sessions {
+		if s.Overlaps(original) {
+			return &s.ID, true
+		}
+	}
+
+	return nil, false
+
+}
+
+func (c *candidate) studentCount(date time.Time) int {
+	n := 0
+	for _, a := range c.assignments[date.Format("2006-01-02")] {
+		if a.StudentID != nil {
+			n++
+		}
+	}
+	return n
+}
+
+type slotKey struct {
+	classroomID primitive.ObjectID
+	date        string
+	slot        int
+	session     primitive.ObjectID
+}
+
+type teacherKey struct {
+	teacherID string
+	date      string
+	session   primitive.ObjectID
+}
+
+func keyOf(id *primitive.ObjectID) primitive.ObjectID {
+	if id == nil {
+		return primitive.NilObjectID
+	}
+	return *id
+}
+
+// proposer fills in the placements of a closure greedily, one student at a
+// time in date and slot order.
+type proposer struct {
+	s          *closureService
+	candidates []*candidate
+	sessions   map[primitive.ObjectID]*session.Session
+	profiles   map[string]*teacher.TeacherProfile
+	// taken holds the slots already used by the plan.
+	taken map[slotKey]bool
+	// teacherAt records where the plan moved a teacher for a session.
+	teacherAt map[teacherKey]primitive.ObjectID
+}
+
+func (s *closureService) propose(ctx context.Context, closure *Closure) error {
+
+	p := &proposer{
+		s:         s,
+		sessions:  make(map[primitive.ObjectID]*session.Session),
+		profiles:  make(map[string]*teacher.TeacherProfile),
+		taken:     make(map[slotKey]bool),
+		teacherAt: make(map[teacherKey]primitive.ObjectID),
+	}
+
+	if closure.RegionID != nil {
+		if err := p.loadCandidates(ctx, closure); err != nil {
+			return err
+		}
+	}
+
+	for _, pl := range closure.Placements {
+		if pl.StudentID == nil {
+			pl.Note = "teacher only"
+			continue
+		}
+		if closure.RegionID == nil {
+			pl.Note = "classroom has no region"
+			continue
+		}
+		if err := p.place(ctx, pl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+func (p *proposer) loadCandidates(ctx context.Context, closure *Closure) error {
+
+	rooms, err := p.s.ClassroomRepository.GetClassroomByRegion(ctx, *closure.RegionID)
+	if err != nil {
+		return err
+	}
+
+	start := closure.StartDate
+	end := closure.EndDate.AddDate(0, 0, 1)
+
+	ids := make([]primitive.ObjectID, 0, len(rooms))
+	for _, room := range rooms {
+		if !room.IsActive || room.ID == closure.ClassRoomID {
+			continue
+		}
+
+		sessions, err := session.EffectiveSessions(ctx, p.s.SessionRepository, room.ID)
+		if err != nil {
+			return err
+		}
+
+		requirements, err := p.s.TeacherRepository.GetClassroomRequirements(ctx, room.ID)
+		if err != nil {
+			return err
+		}
+
+		assignments, err := p.s.AssignRepository.GetAssignmentsByClassroomID(ctx, room.ID, &start, &end)
+		if err != nil {
+			return err
+		}
+
+		c := &candidate{
+			room:         room,
+			sessions:     sessions,
+			requirements: requirements,
+			assignments:  make(map[string][]*assign.TeacherStudentAssignment),
+		}
+		for _, a := range assignments {
+			day := a.AssignDate.Format("2006-01-02")
+			c.assignments[day] = append(c.assignments[day], a)
+		}
+
+		p.candidates = append(p.candidates, c)
+		ids = append(ids, room.ID)
+	}
+
+	closures, err := p.s.ClosureRepository.GetClosuresInRange(ctx, ids, start, end, []string{StatusDraft, StatusApplied})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range p.candidates {
+		for _, cl := range closures {
+			if cl.ClassRoomID == c.room.ID {
+				c.closures = append(c.closures, cl)
+			}
+		}
+	}
+
+	var sessionIDs []primitive.ObjectID
+	for _, pl := range closure.Placements {
+		if pl.SessionID != nil {
+			sessionIDs = append(sessionIDs, *pl.SessionID)
+		}
+	}
+
+	if len(sessionIDs) > 0 {
+		sessions, err := p.s.SessionRepository.GetSessionsByIDs(ctx, sessionIDs)
+		if err != nil {
+			return err
+		}
+		for _, s := range sessions {
+			p.sessions[s.ID] = s
+		}
+	}
+
+	return nil
+
+}
+
+func (p *proposer) eligible(ctx context.Context, teacherID string, c *candidate, date time.Time) (bool, error) {
+
+	profile, ok := p.profiles[teacherID]
+	if !ok {
+		var err error
+		profile, err = p.s.TeacherRepository.GetTeacherProfileByTeacherID(ctx, teacherID)
+		if err != nil {
+			return false, err
+		}
+		p.profiles[teacherID] = profile
+	}
+
+	return len(teacher.CheckEligibility(profile, c.requirements, &date)) == 0, nil
+
+}
+
+// place looks for a slot for the student of pl. A slot that already has
+// an eligible teacher and no student comes first, preferring the student's
+// own teacher; otherwise the own teacher follows the student into an empty
+// slot, as long as the plan has not sent them elsewhere for that session.
+func (p *proposer) place(ctx context.Context, pl *Placement) error {
+
+	day := pl.Date.Format("2006-01-02")
+
+	var original *session.Session
+	if pl.SessionID != nil {
+		original = p.sessions[*pl.SessionID]
+	}
+
+	var movedTo *primitive.ObjectID
+	if pl.TeacherID != nil {
+		if id, ok := p.teacherAt[teacherKey{*pl.TeacherID, day, keyOf(pl.SessionID)}]; ok {
+			movedTo = &id
+		}
+	}
+
+	// The classroom the teacher already went to comes first, then the
+	// one with the fewest students that day.
+	order := make([]*candidate, 0, len(p.candidates))
+	for _, c := range p.candidates {
+		if !c.closedOn(pl.Date) {
+			order = append(order, c)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		if movedTo != nil && (order[i].room.ID == *movedTo) != (order[j].room.ID == *movedTo) {
+			return order[i].room.ID == *movedTo
+		}
+		return order[i].studentCount(pl.Date) < order[j].studentCount(pl.Date)
+	})
+
+	for _, c := range order {
+		sessionID, ok := c.sessionFor(original, pl.SessionID)
+		if !ok {
+			continue
+		}
+
+		used := make(map[int]*assign.TeacherStudentAssignment)
+		for _, a := range c.assignments[day] {
+			if sameSession(a.SessionID, sessionID) {
+				used[a.SlotNumber] = a
+			}
+		}
+
+		// A slot with a teacher and no student.
+		var best *assign.TeacherStudentAssignment
+		for slot := 1; slot <= 15; slot++ {
+			a := used[slot]
+			if a == nil || a.StudentID != nil || a.TeacherID == nil || p.taken[slotKey{c.room.ID, day, slot, keyOf(sessionID)}] {
+				continue
+			}
+			ok, err := p.eligible(ctx, *a.TeacherID, c, pl.Date)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if best == nil || (pl.TeacherID != nil && *a.TeacherID == *pl.TeacherID) {
+				best = a
+			}
+			if pl.TeacherID != nil && *a.TeacherID == *pl.TeacherID {
+				break
+			}
+		}
+
+		if best != nil {
+			p.assign(pl, c, best.SlotNumber, sessionID, best.TeacherID)
+			return nil
+		}
+
+		// An empty slot with the student's own teacher.
+		if pl.TeacherID == nil || (movedTo != nil && *movedTo != c.room.ID) {
+			continue
+		}
+
+		ok, err := p.eligible(ctx, *pl.TeacherID, c, pl.Date)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		for slot := 1; slot <= 15; slot++ {
+			if used[slot] == nil && !p.taken[slotKey{c.room.ID, day, slot, keyOf(sessionID)}] {
+				p.assign(pl, c, slot, sessionID, pl.TeacherID)
+				p.teacherAt[teacherKey{*pl.TeacherID, day, keyOf(pl.SessionID)}] = c.room.ID
+				return nil
+			}
+		}
+	}
+
+	pl.Note = "no free slot with an eligible teacher"
+
+	return nil
+
+}
+
+func (p *proposer) assign(pl *Placement, c *candidate, slot int, sessionID *primitive.ObjectID, teacherID *string) {
+	p.taken[slotKey{c.room.ID, pl.Date.Format("2006-01-02"), slot, keyOf(sessionID)}] = true
+
+	id := c.room.ID
+	pl.ToClassRoomID = &id
+	pl.ToSlotNumber = slot
+	pl.ToSessionID = sessionID
+	pl.ToTeacherID = teacherID
+	pl.Note = ""
+}
+
+// teacherReasons returns why a teacher picked by hand cannot work in the
+// classroom on date. Teachers without a profile are not restricted.
+func (s *closureService) teacherReasons(ctx context.Context, teacherID string, classroomID primitive.ObjectID, date time.Time) ([]string, error) {
+
+	profile, err := s.TeacherRepository.GetTeacherProfileByTeacherID(ctx, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements, err := s.TeacherRepository.GetClassroomRequirements(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	return teacher.CheckEligibility(profile, requirements, &date), nil
+
+}
@@ -0,0 +1,117 @@
+package closure
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ClosureRepository interface {
+	CreateClosure(ctx context.Context, closure *Closure) error
+	UpdateClosure(ctx context.Context, closure *Closure) error
+	DeleteClosure(ctx context.Context, id primitive.ObjectID) error
+	GetClosureByID(ctx context.Context, id primitive.ObjectID) (*Closure, error)
+	GetClosuresByClassroom(ctx context.Context, classroomID primitive.ObjectID) ([]*Closure, error)
+	GetClosuresInRange(ctx context.Context, classroomIDs []primitive.ObjectID, start, end time.Time, statuses []string) ([]*Closure, error)
+	GetAppliedClosuresEndedBefore(ctx context.Context, date time.Time) ([]*Closure, error)
+}
+
+type closureRepository struct {
+	closureCollection *mongo.Collection
+}
+
+func NewClosureRepository(closureCollection *mongo.Collection) ClosureRepository {
+	return &closureRepository{
+		closureCollection: closureCollection,
+	}
+}
+
+func (r *closureRepository) CreateClosure(ctx context.Context, closure *Closure) error {
+
+	_, err := r.closureCollection.InsertOne(ctx, closure)
+	return err
+
+}
+
+func (r *closureRepository) UpdateClosure(ctx context.Context, closure *Closure) error {
+
+	_, err := r.closureCollection.ReplaceOne(ctx, bson.M{"_id": closure.ID}, closure)
+	return err
+
+}
+
+func (r *closureRepository) DeleteClosure(ctx context.Context, id primitive.ObjectID) error {
+
+	_, err := r.closureCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+
+}
+
+func (r *closureRepository) GetClosureByID(ctx context.Context, id primitive.ObjectID) (*Closure, error) {
+
+	var closure Closure
+	err := r.closureCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&closure)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &closure, nil
+
+}
+
+func (r *closureRepository) GetClosuresByClassroom(ctx context.Context, classroomID primitive.ObjectID) ([]*Closure, error) {
+
+	return r.findClosures(ctx, bson.M{"class_room_id": classroomID})
+
+}
+
+// GetClosuresInRange returns the closures of the classrooms with one of the
+// statuses that cover at least one day of [start, end).
+func (r *closureRepository) GetClosuresInRange(ctx context.Context, classroomIDs []primitive.ObjectID, start, end time.Time, statuses []string) ([]*Closure, error) {
+
+	if len(classroomIDs) == 0 {
+		return nil, nil
+	}
+
+	filter := bson.M{
+		"class_room_id": bson.M{"$in": classroomIDs},
+		"status":        bson.M{"$in": statuses},
+		"start_date":    bson.M{"$lt": end},
+		"end_date":      bson.M{"$gte": start},
+	}
+
+	return r.findClosures(ctx, filter)
+
+}
+
+func (r *closureRepository) GetAppliedClosuresEndedBefore(ctx context.Context, date time.Time) ([]*Closure, error) {
+
+	return r.findClosures(ctx, bson.M{"status": StatusApplied, "end_date": bson.M{"$lt": date}})
+
+}
+
+func (r *closureRepository) findClosures(ctx context.Context, filter bson.M) ([]*Closure, error) {
+
+	opts := options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}})
+
+	cursor, err := r.closureCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var closures []*Closure
+	if err := cursor.All(ctx, &closures); err != nil {
+		return nil, err
+	}
+
+	return closures, nil
+
+}
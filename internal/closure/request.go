@@ -0,0 +1,30 @@
+package closure
+
+type CreateClosureRequest struct {
+	ClassroomID string  `json:"classroom_id"`
+	StartDate   string  `json:"start_date"`
+	EndDate     string  `json:"end_date"`
+	Reason      *string `json:"reason"`
+}
+
+// UpdatePlacementRequest changes where the student of an affected
+// assignment goes. An empty ClassroomID leaves the student unplaced.
+type UpdatePlacementRequest struct {
+	ClosureID    string  `json:"closure_id"`
+	AssignmentID string  `json:"assignment_id"`
+	ClassroomID  string  `json:"classroom_id"`
+	SlotNumber   int     `json:"slot_number"`
+	SessionID    string  `json:"session_id"`
+	TeacherID    *string `json:"teacher_id"`
+}
+
+// RestoreClosureRequest ends a closure early. Days from From on, today by
+// default, get their original assignments back; earlier days keep the
+// record of where the students actually were.
+type RestoreClosureRequest struct {
+	From string `json:"from"`
+}
+
+type DeleteClosureRequest struct {
+	ID string `json:"id"`
+}
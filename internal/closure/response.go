@@ -0,0 +1,28 @@
+package closure
+
+type ClosureResponse struct {
+	*Closure
+	// Placed counts the students with a target classroom, Unplaced those
+	// still without one. Teacher-only assignments count as neither.
+	Placed   int `json:"placed"`
+	Unplaced int `json:"unplaced"`
+}
+
+func newClosureResponse(closure *Closure) *ClosureResponse {
+
+	res := &ClosureResponse{Closure: closure}
+
+	for _, p := range closure.Placements {
+		if p.StudentID == nil {
+			continue
+		}
+		if p.ToClassRoomID != nil {
+			res.Placed++
+		} else {
+			res.Unplaced++
+		}
+	}
+
+	return res
+
+}
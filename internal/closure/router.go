@@ -0,0 +1,21 @@
+package closure
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *ClosureHandler) {
+	closureGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Closures
+		closureGroup.POST("/closures", handler.CreateClosure)
+		closureGroup.GET("/closures", handler.GetClosures)
+		closureGroup.GET("/closures/:id", handler.GetClosure)
+		closureGroup.POST("/closures/placements", handler.UpdatePlacement)
+		closureGroup.POST("/closures/:id/apply", handler.ApplyClosure)
+		closureGroup.POST("/closures/:id/restore", handler.RestoreClosure)
+		closureGroup.POST("/remove/closures", handler.DeleteClosure)
+	}
+}
@@ -0,0 +1,770 @@
+package closure
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/classroom"
+	"classroom-service/internal/event"
+	"classroom-service/internal/session"
+	"classroom-service/internal/teacher"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxClosureDays = 31
+
+var (
+	ErrClosureNotFound   = errors.New("closure not found")
+	ErrClassroomNotFound = errors.New("classroom not found")
+	ErrClosureOverlap    = errors.New("classroom already has a closure in this period")
+	ErrClosureNotDraft   = errors.New("closure is not a draft")
+	ErrClosureNotApplied = errors.New("closure is not applied")
+	ErrPlanOutdated      = errors.New("closure plan no longer matches the assignments")
+	ErrClassroomClosed   = errors.New("classroom is closed on this date")
+)
+
+type ClosureService interface {
+	CreateClosure(ctx context.Context, req *CreateClosureRequest, userID string) (*ClosureResponse, error)
+	GetClosures(ctx context.Context, classroomID string) ([]*ClosureResponse, error)
+	GetClosure(ctx context.Context, id string) (*ClosureResponse, error)
+	UpdatePlacement(ctx context.Context, req *UpdatePlacementRequest) (*ClosureResponse, error)
+	ApplyClosure(ctx context.Context, id string, userID string) (*ClosureResponse, error)
+	RestoreClosure(ctx context.Context, id string, req *RestoreClosureRequest, userID string) (*ClosureResponse, error)
+	DeleteClosure(ctx context.Context, req *DeleteClosureRequest) error
+	EndClosures(ctx context.Context) (int, error)
+	// CheckAssignment keeps students out of closed classrooms.
+	assign.AssignmentGuard
+}
+
+type closureService struct {
+	ClosureRepository   ClosureRepository
+	AssignRepository    assign.AssignRepository
+	ClassroomRepository classroom.ClassroomRepository
+	SessionRepository   session.SessionRepository
+	TeacherRepository   teacher.TeacherRepository
+	EventPublisher      event.Publisher
+	// AssignmentGuard holds closure placements to the checks every other
+	// assignment write goes through.
+	AssignmentGuard assign.AssignmentGuard
+}
+
+func NewClosureService(closureRepository ClosureRepository,
+	assignRepository assign.AssignRepository,
+	classroomRepository classroom.ClassroomRepository,
+	sessionRepository session.SessionRepository,
+	teacherRepository teacher.TeacherRepository,
+	publisher event.Publisher,
+	assignmentGuard assign.AssignmentGuard) ClosureService {
+	return &closureService{
+		ClosureRepository:   closureRepository,
+		AssignRepository:    assignRepository,
+		ClassroomRepository: classroomRepository,
+		SessionRepository:   sessionRepository,
+		TeacherRepository:   teacherRepository,
+		EventPublisher:      publisher,
+		AssignmentGuard:     assignmentGuard,
+	}
+}
+
+func sameSession(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func sameString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func today() time.Time {
+	t, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	return t
+}
+
+func closureEvent(eventType string, closure *Closure, userID string) *event.Event {
+
+	placed := 0
+	for _, p := range closure.Placements {
+		if p.Applied {
+			placed++
+		}
+	}
+
+	return event.New(eventType, "closure", closure.ID.Hex(), map[string]interface{}{
+		"class_room_id": closure.ClassRoomID.Hex(),
+		"start_date":    closure.StartDate.Format("2006-01-02"),
+		"end_date":      closure.EndDate.Format("2006-01-02"),
+		"status":        closure.Status,
+		"placed":        placed,
+	}).WithActor(userID)
+
+}
+
+func (s *closureService) getClosure(ctx context.Context, id string) (*Closure, error) {
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid closure id: %v", err)
+	}
+
+	closure, err := s.ClosureRepository.GetClosureByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if closure == nil {
+		return nil, ErrClosureNotFound
+	}
+
+	return closure, nil
+
+}
+
+func (s *closureService) CreateClosure(ctx context.Context, req *CreateClosureRequest, userID string) (*ClosureResponse, error) {
+
+	classroomObjID, err := primitive.ObjectIDFromHex(req.ClassroomID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid classroom id: %v", err)
+	}
+
+	if req.StartDate == "" || req.EndDate == "" {
+		return nil, errors.New("start_date and end_date are required")
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if endDate.Before(startDate) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+
+	endExclusive := endDate.AddDate(0, 0, 1)
+	if endExclusive.Sub(startDate) > maxClosureDays*24*time.Hour {
+		return nil, fmt.Errorf("a closure cannot exceed %d days", maxClosureDays)
+	}
+
+	room, err := s.ClassroomRepository.GetClassroomByID(ctx, classroomObjID)
+	if err != nil {
+		return nil, err
+	}
+	if room == nil {
+		return nil, ErrClassroomNotFound
+	}
+
+	existing, err := s.ClosureRepository.GetClosuresInRange(ctx, []primitive.ObjectID{classroomObjID}, startDate, endExclusive, []string{StatusDraft, StatusApplied})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("%w: %s to %s", ErrClosureOverlap, existing[0].StartDate.Format("2006-01-02"), existing[0].EndDate.Format("2006-01-02"))
+	}
+
+	assignments, err := s.AssignRepository.GetAssignmentsByClassroomID(ctx, classroomObjID, &startDate, &endExclusive)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	closure := &Closure{
+		ID:             primitive.NewObjectID(),
+		ClassRoomID:    classroomObjID,
+		RegionID:       room.RegionID,
+		OrganizationID: room.OrganizationID,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		Reason:         req.Reason,
+		Status:         StatusDraft,
+		Placements:     make([]*Placement, 0, len(assignments)),
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	for _, a := range assignments {
+		if a.StudentID == nil && a.TeacherID == nil {
+			continue
+		}
+		closure.Placements = append(closure.Placements, &Placement{
+			AssignmentID: a.ID,
+			Date:         a.AssignDate,
+			SlotNumber:   a.SlotNumber,
+			SessionID:    a.SessionID,
+			StudentID:    a.StudentID,
+			TeacherID:    a.TeacherID,
+			Type:         a.Type,
+		})
+	}
+
+	if err := s.propose(ctx, closure); err != nil {
+		return nil, err
+	}
+
+	if err := s.ClosureRepository.CreateClosure(ctx, closure); err != nil {
+		return nil, err
+	}
+
+	return newClosureResponse(closure), nil
+
+}
+
+func (s *closureService) GetClosures(ctx context.Context, classroomID string) ([]*ClosureResponse, error) {
+
+	classroomObjID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid classroom id: %v", err)
+	}
+
+	closures, err := s.ClosureRepository.GetClosuresByClassroom(ctx, classroomObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*ClosureResponse, 0, len(closures))
+	for _, c := range closures {
+		result = append(result, newClosureResponse(c))
+	}
+
+	return result, nil
+
+}
+
+func (s *closureService) GetClosure(ctx context.Context, id string) (*ClosureResponse, error) {
+
+	closure, err := s.getClosure(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClosureResponse(closure), nil
+
+}
+
+func (s *closureService) UpdatePlacement(ctx context.Context, req *UpdatePlacementRequest) (*ClosureResponse, error) {
+
+	closure, err := s.getClosure(ctx, req.ClosureID)
+	if err != nil {
+		return nil, err
+	}
+
+	if closure.Status != StatusDraft {
+		return nil, ErrClosureNotDraft
+	}
+
+	assignmentObjID, err := primitive.ObjectIDFromHex(req.AssignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid assignment id: %v", err)
+	}
+
+	var placement *Placement
+	for _, p := range closure.Placements {
+		if p.AssignmentID == assignmentObjID {
+			placement = p
+			break
+		}
+	}
+	if placement == nil {
+		return nil, fmt.Errorf("assignment %s is not affected by this closure", req.AssignmentID)
+	}
+
+	if placement.StudentID == nil {
+		return nil, errors.New("assignment has no student to place")
+	}
+
+	if req.ClassroomID == "" {
+		clearTarget(placement, "left unplaced")
+	} else if err := s.setTarget(ctx, closure, placement, req); err != nil {
+		return nil, err
+	}
+
+	closure.UpdatedAt = time.Now()
+	if err := s.ClosureRepository.UpdateClosure(ctx, closure); err != nil {
+		return nil, err
+	}
+
+	return newClosureResponse(closure), nil
+
+}
+
+func clearTarget(p *Placement, note string) {
+	p.ToClassRoomID = nil
+	p.ToSlotNumber = 0
+	p.ToSessionID = nil
+	p.ToTeacherID = nil
+	p.Note = note
+}
+
+// setTarget checks a placement chosen by hand with the same rules the
+// proposal follows.
+func (s *closureService) setTarget(ctx context.Context, closure *Closure, p *Placement, req *UpdatePlacementRequest) error {
+
+	targetObjID, err := primitive.ObjectIDFromHex(req.ClassroomID)
+	if err != nil {
+		return fmt.Errorf("invalid classroom id: %v", err)
+	}
+
+	if targetObjID == closure.ClassRoomID {
+		return errors.New("cannot place a student in the closed classroom")
+	}
+
+	if req.SlotNumber < 1 || req.SlotNumber > 15 {
+		return errors.New("slot number must be between 1 and 15")
+	}
+
+	target, err := s.ClassroomRepository.GetClassroomByID(ctx, targetObjID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrClassroomNotFound
+	}
+	if !target.IsActive {
+		return errors.New("target classroom is not active")
+	}
+	if closure.RegionID == nil || target.RegionID == nil || *target.RegionID != *closure.RegionID {
+		return errors.New("target classroom is not in the same region")
+	}
+
+	closed, err := s.closedOn(ctx, targetObjID, p.Date)
+	if err != nil {
+		return err
+	}
+	if closed {
+		return fmt.Errorf("%w: %s", ErrClassroomClosed, p.Date.Format("2006-01-02"))
+	}
+
+	sess, err := session.ResolveForClassroom(ctx, s.SessionRepository, targetObjID, req.SessionID)
+	if err != nil {
+		return err
+	}
+	var sessionID *primitive.ObjectID
+	if sess != nil {
+		sessionID = &sess.ID
+	}
+
+	for _, other := range closure.Placements {
+		if other != p && other.ToClassRoomID != nil && *other.ToClassRoomID == targetObjID &&
+			other.Date.Equal(p.Date) && other.ToSlotNumber == req.SlotNumber && sameSession(other.ToSessionID, sessionID) {
+			return fmt.Errorf("slot %d is already used by another placement", req.SlotNumber)
+		}
+	}
+
+	date := p.Date
+	existing, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, targetObjID, req.SlotNumber, &date, sessionID)
+	if err != nil {
+		return err
+	}
+
+	teacherID := req.TeacherID
+	if existing != nil {
+		if existing.StudentID != nil {
+			return fmt.Errorf("slot %d already has a student", req.SlotNumber)
+		}
+		if existing.TeacherID != nil {
+			if teacherID != nil && *teacherID != *existing.TeacherID {
+				return fmt.Errorf("slot %d already has another teacher", req.SlotNumber)
+			}
+			teacherID = existing.TeacherID
+		}
+	}
+	if teacherID == nil {
+		teacherID = p.TeacherID
+	}
+	if teacherID == nil {
+		return errors.New("teacher_id is required for an empty slot")
+	}
+
+	reasons, err := s.teacherReasons(ctx, *teacherID, targetObjID, p.Date)
+	if err != nil {
+		return err
+	}
+	if len(reasons) > 0 {
+		return teacher.NotEligibleError(*teacherID, reasons)
+	}
+
+	p.ToClassRoomID = &targetObjID
+	p.ToSlotNumber = req.SlotNumber
+	p.ToSessionID = sessionID
+	p.ToTeacherID = teacherID
+	p.Note = ""
+
+	return nil
+
+}
+
+// closedOn reports whether the classroom has a draft or applied closure
+// covering date.
+func (s *closureService) closedOn(ctx context.Context, classroomID primitive.ObjectID, date time.Time) (bool, error) {
+
+	closures, err := s.ClosureRepository.GetClosuresInRange(ctx, []primitive.ObjectID{classroomID}, date, date.AddDate(0, 0, 1), []string{StatusDraft, StatusApplied})
+	if err != nil {
+		return false, err
+	}
+
+	return len(closures) > 0, nil
+
+}
+
+// ApplyClosure moves the students as planned. Every placement is checked
+// again against the current assignments and the whole plan is written in
+// one transaction, so a stale plan changes nothing.
+func (s *closureService) ApplyClosure(ctx context.Context, id string, userID string) (*ClosureResponse, error) {
+
+	closure, err := s.getClosure(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if closure.Status != StatusDraft {
+		return nil, ErrClosureNotDraft
+	}
+
+	err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		for _, p := range closure.Placements {
+			if p.ToClassRoomID == nil {
+				continue
+			}
+			if err := s.applyPlacement(ctx, closure, p, userID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		closure.Status = StatusApplied
+		closure.AppliedBy = &userID
+		closure.AppliedAt = &now
+		closure.UpdatedAt = now
+
+		return s.ClosureRepository.UpdateClosure(ctx, closure)
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	event.Emit(ctx, s.EventPublisher, closureEvent(event.ClosureApplied, closure, userID))
+
+	return newClosureResponse(closure), nil
+
+}
+
+func (s *closureService) applyPlacement(ctx context.Context, closure *Closure, p *Placement, userID string) error {
+
+	original, err := s.AssignRepository.GetAssignmentByID(ctx, p.AssignmentID)
+	if err != nil {
+		return err
+	}
+	if original == nil || !sameString(original.StudentID, p.StudentID) || !sameString(original.TeacherID, p.TeacherID) {
+		return fmt.Errorf("%w: assignment %s changed", ErrPlanOutdated, p.AssignmentID.Hex())
+	}
+
+	date := p.Date
+	now := time.Now()
+
+	target, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, *p.ToClassRoomID, p.ToSlotNumber, &date, p.ToSessionID)
+	if err != nil {
+		return err
+	}
+
+	p.CreatedTarget = target == nil
+
+	if target != nil {
+		if target.StudentID != nil || !sameString(target.TeacherID, p.ToTeacherID) {
+			return fmt.Errorf("%w: slot %d is no longer free", ErrPlanOutdated, p.ToSlotNumber)
+		}
+		before := *target
+		target.StudentID = p.StudentID
+		target.Type = p.Type
+		target.ClosureID = &closure.ID
+		target.UpdatedAt = now
+		if err := s.AssignmentGuard.CheckAssignment(ctx, &before, target); err != nil {
+			return err
+		}
+		if err := s.AssignRepository.UpdateAssgin(ctx, target.ID, target); err != nil {
+			return err
+		}
+		if err := event.Record(ctx, s.EventPublisher, assign.NewAssignmentEvent(event.AssignmentChanged, target, userID)); err != nil {
+			return err
+		}
+	} else {
+		closed, err := s.closedOn(ctx, *p.ToClassRoomID, p.Date)
+		if err != nil {
+			return err
+		}
+		if closed {
+			return fmt.Errorf("%w: %s", ErrClassroomClosed, p.Date.Format("2006-01-02"))
+		}
+
+		target = &assign.TeacherStudentAssignment{
+			ID:          primitive.NewObjectID(),
+			ClassRoomID: *p.ToClassRoomID,
+			SlotNumber:  p.ToSlotNumber,
+			AssignDate:  p.Date,
+			SessionID:   p.ToSessionID,
+			TeacherID:   p.ToTeacherID,
+			StudentID:   p.StudentID,
+			Type:        p.Type,
+			ClosureID:   &closure.ID,
+			CreatedBy:   userID,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if err := s.AssignmentGuard.CheckAssignment(ctx, nil, target); err != nil {
+			return err
+		}
+		if err := s.AssignRepository.CreateAssignment(ctx, target); err != nil {
+			return err
+		}
+		if err := event.Record(ctx, s.EventPublisher, assign.NewAssignmentEvent(event.AssignmentCreated, target, userID)); err != nil {
+			return err
+		}
+	}
+
+	// A teacher who follows their student into an empty slot leaves the
+	// closed classroom for that session.
+	p.TeacherMoved = p.CreatedTarget && p.TeacherID != nil && sameString(p.ToTeacherID, p.TeacherID)
+
+	before := *original
+	original.StudentID = nil
+	if original.IsGuest() {
+		original.Type = assign.AssignmentTypeRegular
+	}
+	if p.TeacherMoved {
+		original.TeacherID = nil
+	}
+	original.ClosureID = &closure.ID
+	original.UpdatedAt = now
+	if err := s.AssignmentGuard.CheckAssignment(ctx, &before, original); err != nil {
+		return err
+	}
+	if err := s.AssignRepository.UpdateAssgin(ctx, original.ID, original); err != nil {
+		return err
+	}
+	if err := event.Record(ctx, s.EventPublisher, assign.NewAssignmentEvent(event.AssignmentChanged, original, userID)); err != nil {
+		return err
+	}
+
+	p.TargetAssignmentID = &target.ID
+	p.Applied = true
+
+	return nil
+
+}
+
+// RestoreClosure ends an applied closure early. Placements from the given
+// day on are undone and the original assignments get their student and
+// teacher back; a slot someone else took meanwhile is left alone and
+// noted on the placement.
+func (s *closureService) RestoreClosure(ctx context.Context, id string, req *RestoreClosureRequest, userID string) (*ClosureResponse, error) {
+
+	closure, err := s.getClosure(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if closure.Status != StatusApplied {
+		return nil, ErrClosureNotApplied
+	}
+
+	from := today()
+	if req.From != "" {
+		from, err = time.Parse("2006-01-02", req.From)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if from.After(closure.EndDate) {
+		return nil, errors.New("closure is already over on this date")
+	}
+	if from.Before(closure.StartDate) {
+		from = closure.StartDate
+	}
+
+	err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		// The closure ends first, so the closure guard lets the students
+		// back into their slots.
+		now := time.Now()
+		if from.After(closure.StartDate) {
+			closure.EndDate = from.AddDate(0, 0, -1)
+		}
+		closure.Status = StatusRestored
+		closure.RestoredBy = &userID
+		closure.RestoredAt = &now
+		closure.UpdatedAt = now
+
+		if err := s.ClosureRepository.UpdateClosure(ctx, closure); err != nil {
+			return err
+		}
+
+		for _, p := range closure.Placements {
+			if !p.Applied || p.Restored || p.Date.Before(from) {
+				continue
+			}
+			if err := s.restorePlacement(ctx, p, userID); err != nil {
+				return err
+			}
+		}
+
+		return s.ClosureRepository.UpdateClosure(ctx, closure)
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	event.Emit(ctx, s.EventPublisher, closureEvent(event.ClosureRestored, closure, userID))
+
+	return newClosureResponse(closure), nil
+
+}
+
+func (s *closureService) restorePlacement(ctx context.Context, p *Placement, userID string) error {
+
+	now := time.Now()
+
+	if p.TargetAssignmentID != nil {
+		target, err := s.AssignRepository.GetAssignmentByID(ctx, *p.TargetAssignmentID)
+		if err != nil {
+			return err
+		}
+		if target != nil && sameString(target.StudentID, p.StudentID) {
+			before := *target
+			target.StudentID = nil
+			target.Type = assign.AssignmentTypeRegular
+			target.ClosureID = nil
+			target.UpdatedAt = now
+			if err := s.AssignmentGuard.CheckAssignment(ctx, &before, target); err != nil {
+				return err
+			}
+			if p.CreatedTarget {
+				if err := s.AssignRepository.DeleteAssignmentByID(ctx, target.ID); err != nil {
+					return err
+				}
+			} else if err := s.AssignRepository.UpdateAssgin(ctx, target.ID, target); err != nil {
+				return err
+			}
+			if err := event.Record(ctx, s.EventPublisher, assign.NewAssignmentEvent(event.AssignmentChanged, target, userID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	p.Restored = true
+
+	original, err := s.AssignRepository.GetAssignmentByID(ctx, p.AssignmentID)
+	if err != nil {
+		return err
+	}
+	if original == nil {
+		p.Note = "original assignment was removed"
+		return nil
+	}
+	if original.StudentID != nil {
+		p.Note = "original slot was taken meanwhile"
+		return nil
+	}
+
+	before := *original
+	original.StudentID = p.StudentID
+	if p.Type != "" {
+		original.Type = p.Type
+	}
+	if p.TeacherMoved && original.TeacherID == nil {
+		original.TeacherID = p.TeacherID
+	}
+	original.ClosureID = nil
+	original.UpdatedAt = now
+
+	if err := s.AssignmentGuard.CheckAssignment(ctx, &before, original); err != nil {
+		return err
+	}
+
+	if err := s.AssignRepository.UpdateAssgin(ctx, original.ID, original); err != nil {
+		return err
+	}
+
+	return event.Record(ctx, s.EventPublisher, assign.NewAssignmentEvent(event.AssignmentChanged, original, userID))
+
+}
+
+func (s *closureService) DeleteClosure(ctx context.Context, req *DeleteClosureRequest) error {
+
+	closure, err := s.getClosure(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if closure.Status != StatusDraft {
+		return ErrClosureNotDraft
+	}
+
+	return s.ClosureRepository.DeleteClosure(ctx, closure.ID)
+
+}
+
+// EndClosures marks applied closures whose last day has passed as ended.
+// Their placements only ever covered the closed days, so the original
+// arrangement is back in place without further writes.
+func (s *closureService) EndClosures(ctx context.Context) (int, error) {
+
+	closures, err := s.ClosureRepository.GetAppliedClosuresEndedBefore(ctx, today())
+	if err != nil {
+		return 0, err
+	}
+
+	ended := 0
+	for _, c := range closures {
+		c.Status = StatusEnded
+		c.UpdatedAt = time.Now()
+		if err := s.ClosureRepository.UpdateClosure(ctx, c); err != nil {
+			return ended, err
+		}
+		ended++
+	}
+
+	return ended, nil
+
+}
+
+// CheckAssignment implements assign.AssignmentGuard. It refuses to put a
+// student into a classroom on a day it is closed; clearing a slot or
+// keeping the same student is always allowed.
+func (s *closureService) CheckAssignment(ctx context.Context, before, after *assign.TeacherStudentAssignment) error {
+
+	if after == nil || after.StudentID == nil {
+		return nil
+	}
+
+	if before != nil && before.ClassRoomID == after.ClassRoomID && before.AssignDate.Equal(after.AssignDate) && sameString(before.StudentID, after.StudentID) {
+		return nil
+	}
+
+	date := time.Date(after.AssignDate.Year(), after.AssignDate.Month(), after.AssignDate.Day(), 0, 0, 0, 0, after.AssignDate.Location())
+
+	closures, err := s.ClosureRepository.GetClosuresInRange(ctx, []primitive.ObjectID{after.ClassRoomID}, date, date.AddDate(0, 0, 1), []string{StatusApplied})
+	if err != nil {
+		return err
+	}
+
+	if len(closures) > 0 {
+		return fmt.Errorf("%w: %s", ErrClassroomClosed, date.Format("2006-01-02"))
+	}
+
+	return nil
+
+}
@@ -0,0 +1,55 @@
+package coverage
+
+import (
+	"classroom-service/helper"
+	"classroom-service/pkg/constants"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CoverageHandler struct {
+	CoverageService CoverageService
+}
+
+func NewCoverageHandler(coverageService CoverageService) *CoverageHandler {
+	return &CoverageHandler{
+		CoverageService: coverageService,
+	}
+}
+
+func (h *CoverageHandler) GetCoverageReport(c *gin.Context) {
+
+	start := c.Query("start")
+
+	days := 0
+	if d := c.Query("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil {
+			helper.SendError(c, http.StatusBadRequest, fmt.Errorf("invalid days: %v", err), "INVALID_REQUEST")
+			return
+		}
+		days = parsed
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	report, err := h.CoverageService.GetCoverageReport(ctx, start, days)
+
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Coverage Report Successfully", report)
+
+}
@@ -0,0 +1,23 @@
+package coverage
+
+const (
+	FindingUnstaffedSlot   = "unstaffed_slot"
+	FindingTeacherOnlySlot = "teacher_only_slot"
+	FindingLeaderlessDay   = "leaderless_day"
+	FindingRatioViolation  = "ratio_violation"
+)
+
+type Finding struct {
+	Type           string  `json:"type"`
+	OrganizationID string  `json:"organization_id"`
+	ClassRoomID    string  `json:"class_room_id"`
+	ClassroomName  string  `json:"classroom_name"`
+	Date           string  `json:"date"`
+	SessionID      *string `json:"session_id,omitempty"`
+	SlotNumber     *int    `json:"slot_number,omitempty"`
+	TeacherID      *string `json:"teacher_id,omitempty"`
+	StudentID      *string `json:"student_id,omitempty"`
+	StudentCount   int     `json:"student_count,omitempty"`
+	TeacherCount   int     `json:"teacher_count,omitempty"`
+	Detail         string  `json:"detail"`
+}
@@ -0,0 +1,10 @@
+package coverage
+
+type CoverageReportResponse struct {
+	OrganizationID string         `json:"organization_id"`
+	StartDate      string         `json:"start_date"`
+	EndDate        string         `json:"end_date"`
+	Total          int            `json:"total"`
+	CountByType    map[string]int `json:"count_by_type"`
+	Findings       []*Finding     `json:"findings"`
+}
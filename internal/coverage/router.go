@@ -0,0 +1,15 @@
+package coverage
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *CoverageHandler) {
+	coverageGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Coverage Gaps
+		coverageGroup.GET("/coverage-gaps", handler.GetCoverageReport)
+	}
+}
@@ -0,0 +1,356 @@
+package coverage
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/classroom"
+	"classroom-service/internal/leader"
+	"classroom-service/internal/notification"
+	"classroom-service/internal/ratio"
+	"classroom-service/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxCheckDays = 31
+
+type CoverageService interface {
+	GetCoverageReport(ctx context.Context, start string, days int) (*CoverageReportResponse, error)
+	CheckOrganization(ctx context.Context, organizationID string, start time.Time, days int) (*CoverageReportResponse, error)
+	CronCoverageAlerts(ctx context.Context) error
+}
+
+type coverageService struct {
+	ClassroomRepository classroom.ClassroomRepository
+	AssignRepository    assign.AssignRepository
+	LeaderRepository    leader.LeaderRepository
+	UserService         user.UserService
+	Notifier            notification.Notifier
+	RatioService        ratio.RatioService
+	AlertDays           int
+}
+
+// NewCoverageService creates the checker. Ratio violations come from the
+// organization's ratio rules; alertDays is how many days ahead the scheduled
+// alert looks, starting tomorrow.
+func NewCoverageService(classroomRepository classroom.ClassroomRepository,
+	assignRepository assign.AssignRepository,
+	leaderRepository leader.LeaderRepository,
+	userService user.UserService,
+	notifier notification.Notifier,
+	ratioService ratio.RatioService,
+	alertDays int) CoverageService {
+	if alertDays <= 0 {
+		alertDays = 1
+	}
+	return &coverageService{
+		ClassroomRepository: classroomRepository,
+		AssignRepository:    assignRepository,
+		LeaderRepository:    leaderRepository,
+		UserService:         userService,
+		Notifier:            notifier,
+		RatioService:        ratioService,
+		AlertDays:           alertDays,
+	}
+}
+
+func (s *coverageService) GetCoverageReport(ctx context.Context, start string, days int) (*CoverageReportResponse, error) {
+
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if currentUser == nil || currentUser.OrganizationAdmin == nil {
+		return nil, errors.New("user not found")
+	}
+
+	var startParse time.Time
+	if start == "" {
+		now := time.Now()
+		startParse = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	} else {
+		startParse, err = time.Parse("2006-01-02", start)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if days <= 0 {
+		days = 7
+	}
+
+	return s.CheckOrganization(ctx, currentUser.OrganizationAdmin.ID, startParse, days)
+
+}
+
+// CheckOrganization scans every active classroom of the organization for
+// the given days and reports coverage gaps.
+func (s *coverageService) CheckOrganization(ctx context.Context, organizationID string, start time.Time, days int) (*CoverageReportResponse, error) {
+
+	if organizationID == "" {
+		return nil, errors.New("organization id is required")
+	}
+
+	if days <= 0 || days > maxCheckDays {
+		return nil, fmt.Errorf("days must be between 1 and %d", maxCheckDays)
+	}
+
+	end := start.AddDate(0, 0, days)
+
+	classrooms, err := s.ClassroomRepository.GetClassroomsByOrgID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := make([]*Finding, 0)
+	for _, c := range classrooms {
+		if !c.IsActive {
+			continue
+		}
+
+		classroomFindings, err := s.checkClassroom(ctx, c, &start, &end)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, classroomFindings...)
+	}
+
+	checks, err := s.RatioService.CheckOrganization(ctx, organizationID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range checks {
+		if c.Compliant {
+			continue
+		}
+		findings = append(findings, &Finding{
+			Type:           FindingRatioViolation,
+			OrganizationID: organizationID,
+			ClassRoomID:    c.ClassroomID,
+			ClassroomName:  c.ClassroomName,
+			Date:           c.Date,
+			SessionID:      sessionHex(c.SessionID),
+			StudentCount:   c.Children,
+			TeacherCount:   c.Adults,
+			Detail: fmt.Sprintf("%d children for %d adult(s), the ratio rule requires %d",
+				c.Children, c.Adults, c.RequiredAdults),
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Date != findings[j].Date {
+			return findings[i].Date < findings[j].Date
+		}
+		return findings[i].ClassroomName < findings[j].ClassroomName
+	})
+
+	countByType := make(map[string]int)
+	for _, f := range findings {
+		countByType[f.Type]++
+	}
+
+	return &CoverageReportResponse{
+		OrganizationID: organizationID,
+		StartDate:      start.Format("2006-01-02"),
+		EndDate:        end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Total:          len(findings),
+		CountByType:    countByType,
+		Findings:       findings,
+	}, nil
+
+}
+
+func (s *coverageService) checkClassroom(ctx context.Context, c *classroom.ClassRoom, start, end *time.Time) ([]*Finding, error) {
+
+	assignments, err := s.AssignRepository.GetAssignmentsByClassroomID(ctx, c.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// The leader range includes its last day.
+	last := end.AddDate(0, 0, -1)
+	leaders, err := s.LeaderRepository.GetLeadersByClassroomAndRange(ctx, c.ID, start, &last)
+	if err != nil {
+		return nil, err
+	}
+
+	// A leader without a session leads the whole day, a session leader only
+	// their session.
+	type daySession struct {
+		date      string
+		sessionID primitive.ObjectID
+	}
+	keyOf := func(date string, sessionID *primitive.ObjectID) daySession {
+		key := daySession{date: date}
+		if sessionID != nil {
+			key.sessionID = *sessionID
+		}
+		return key
+	}
+
+	ledDays := make(map[string]bool)
+	ledSessions := make(map[daySession]bool)
+	for _, l := range leaders {
+		if l.Owner == nil {
+			continue
+		}
+		date := l.Date.Format("2006-01-02")
+		if l.SessionID == nil {
+			ledDays[date] = true
+		} else {
+			ledSessions[keyOf(date, l.SessionID)] = true
+		}
+	}
+
+	scheduled := make(map[daySession]bool)
+	scheduledOrder := make([]*assign.TeacherStudentAssignment, 0)
+
+	findings := make([]*Finding, 0)
+	newFinding := func(findingType, date, detail string) *Finding {
+		return &Finding{
+			Type:           findingType,
+			OrganizationID: c.OrganizationID,
+			ClassRoomID:    c.ID.Hex(),
+			ClassroomName:  c.Name,
+			Date:           date,
+			Detail:         detail,
+		}
+	}
+
+	for _, a := range assignments {
+		date := a.AssignDate.Format("2006-01-02")
+		hasTeacher := a.TeacherID != nil && *a.TeacherID != ""
+		hasStudent := a.StudentID != nil && *a.StudentID != ""
+
+		if !hasTeacher && !hasStudent {
+			continue
+		}
+
+		if key := keyOf(date, a.SessionID); !scheduled[key] {
+			scheduled[key] = true
+			scheduledOrder = append(scheduledOrder, a)
+		}
+
+		slot := a.SlotNumber
+		switch {
+		case hasStudent && !hasTeacher:
+			f := newFinding(FindingUnstaffedSlot, date, fmt.Sprintf("slot %d has a student but no teacher", slot))
+			f.SlotNumber = &slot
+			f.StudentID = a.StudentID
+			findings = append(findings, f)
+		case hasTeacher && !hasStudent:
+			f := newFinding(FindingTeacherOnlySlot, date, fmt.Sprintf("slot %d has a teacher but no student", slot))
+			f.SlotNumber = &slot
+			f.TeacherID = a.TeacherID
+			findings = append(findings, f)
+		}
+	}
+
+	// Only days with something scheduled need a leader, otherwise every
+	// weekend and holiday would be reported. Each scheduled session needs
+	// a whole-day leader or a leader of its own.
+	for _, a := range scheduledOrder {
+		date := a.AssignDate.Format("2006-01-02")
+		if ledDays[date] || (a.SessionID != nil && ledSessions[keyOf(date, a.SessionID)]) {
+			continue
+		}
+
+		detail := "classroom has assignments but no leader"
+		if a.SessionID != nil {
+			detail = "session has assignments but no leader"
+		}
+		f := newFinding(FindingLeaderlessDay, date, detail)
+		f.SessionID = sessionHex(a.SessionID)
+		findings = append(findings, f)
+	}
+
+	return findings, nil
+
+}
+
+func sessionHex(id *primitive.ObjectID) *string {
+	if id == nil {
+		return nil
+	}
+	hex := id.Hex()
+	return &hex
+}
+
+// CronCoverageAlerts checks every organization for the upcoming days and
+// sends one alert per organization that has gaps.
+func (s *coverageService) CronCoverageAlerts(ctx context.Context) error {
+
+	if s.Notifier == nil {
+		return errors.New("notifier is not configured")
+	}
+
+	orgIDs, err := s.ClassroomRepository.GetOrganizationIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+
+	messages := make([]*notification.Message, 0)
+	for _, orgID := range orgIDs {
+		report, err := s.CheckOrganization(ctx, orgID, start, s.AlertDays)
+		if err != nil {
+			log.Printf("[ERROR] coverage check failed (organization_id=%s): %v", orgID, err)
+			continue
+		}
+
+		if report.Total == 0 {
+			continue
+		}
+
+		messages = append(messages, buildAlert(report, start))
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return s.Notifier.Send(ctx, messages)
+
+}
+
+// buildAlert addresses the organization rather than a person; the
+// notification service fans it out to the organization's admins.
+func buildAlert(report *CoverageReportResponse, scheduledFor time.Time) *notification.Message {
+
+	types := make([]string, 0, len(report.CountByType))
+	for t := range report.CountByType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, fmt.Sprintf("%s: %d", strings.ReplaceAll(t, "_", " "), report.CountByType[t]))
+	}
+
+	return &notification.Message{
+		RecipientID:    report.OrganizationID,
+		RecipientRole:  notification.RecipientRoleAdmin,
+		OrganizationID: report.OrganizationID,
+		Type:           notification.TypeCoverageAlert,
+		Title:          fmt.Sprintf("%d coverage gap(s) between %s and %s", report.Total, report.StartDate, report.EndDate),
+		Body:           strings.Join(parts, "\n"),
+		Data: map[string]interface{}{
+			"start_date": report.StartDate,
+			"end_date":   report.EndDate,
+			"findings":   report.Findings,
+		},
+		ScheduledFor: scheduledFor,
+	}
+
+}
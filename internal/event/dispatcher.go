@@ -0,0 +1,123 @@
+package event
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	defaultDispatchInterval = 5 * time.Second
+	defaultBatchSize        = 100
+	defaultMaxAttempts      = 10
+	defaultLockDuration     = 5 * time.Minute
+)
+
+// Dispatcher polls the outbox and delivers pending events to every sink.
+// An event is only marked delivered once all sinks accepted it, so consumers
+// must treat events as at-least-once and deduplicate on the event id. Every
+// replica runs a dispatcher; each event is claimed by one of them for
+// LockDuration before it is delivered.
+type Dispatcher struct {
+	OutboxRepository OutboxRepository
+	Sinks            []Sink
+	Interval         time.Duration
+	BatchSize        int64
+	MaxAttempts      int
+	LockDuration     time.Duration
+}
+
+func NewDispatcher(repo OutboxRepository, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{
+		OutboxRepository: repo,
+		Sinks:            sinks,
+		Interval:         defaultDispatchInterval,
+		BatchSize:        defaultBatchSize,
+		MaxAttempts:      defaultMaxAttempts,
+		LockDuration:     defaultLockDuration,
+	}
+}
+
+// Run dispatches until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchPending(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[ERROR] event dispatch failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+}
+
+// DispatchPending delivers one batch of due events and returns how many were
+// delivered successfully.
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+
+	if len(d.Sinks) == 0 {
+		return 0, nil
+	}
+
+	delivered := 0
+	for i := int64(0); i < d.BatchSize; i++ {
+		e, err := d.OutboxRepository.ClaimPendingEvent(ctx, time.Now(), d.LockDuration)
+		if err != nil {
+			return delivered, err
+		}
+		if e == nil {
+			break
+		}
+
+		if err := d.deliver(ctx, e); err != nil {
+			attempts := e.Attempts + 1
+			giveUp := attempts >= d.MaxAttempts
+			if giveUp {
+				log.Printf("[ERROR] giving up on event %s (type=%s) after %d attempts: %v", e.ID.Hex(), e.Type, attempts, err)
+			}
+			if err := d.OutboxRepository.MarkAttemptFailed(ctx, e.ID, attempts, err.Error(), time.Now().Add(backoff(attempts)), giveUp); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		if err := d.OutboxRepository.MarkDelivered(ctx, e.ID); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, nil
+
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, e *Event) error {
+
+	for _, sink := range d.Sinks {
+		if err := sink.Deliver(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+// backoff doubles the wait after every failed attempt, capped at one hour.
+func backoff(attempts int) time.Duration {
+
+	wait := time.Duration(1<<uint(attempts-1)) * 10 * time.Second
+	if wait > time.Hour || wait <= 0 {
+		return time.Hour
+	}
+
+	return wait
+
+}
@@ -0,0 +1,98 @@
+package event
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Version is bumped whenever the payload of an existing event type changes
+// in a way consumers have to know about.
+const Version = 1
+
+const (
+	AssignmentCreated         = "assignment.created"
+	AssignmentChanged         = "assignment.changed"
+	AssignmentTemplateChanged = "assignment_template.changed"
+	StudentTransferred        = "student.transferred"
+
+	LeaderAssigned           = "leader.assigned"
+	LeaderRemoved            = "leader.removed"
+	LeaderTemplateAssigned   = "leader_template.assigned"
+	LeaderTemplateRemoved    = "leader_template.removed"
+	LeaderRotaChanged        = "leader_rota.changed"
+	LeaderRotaRemoved        = "leader_rota.removed"
+	ClassroomCreated         = "classroom.created"
+	ClassroomUpdated         = "classroom.updated"
+	ClassroomTemplateApplied = "classroom.template_applied"
+
+	ClassroomLocationOverridden      = "classroom.location_overridden"
+	ClassroomLocationOverrideRemoved = "classroom.location_override_removed"
+
+	ClosureApplied  = "closure.applied"
+	ClosureRestored = "closure.restored"
+
+	TermRolledOver          = "term.rolled_over"
+	TemplateProposalApplied = "template_proposal.applied"
+
+	TemplatePublished  = "assignment_template.published"
+	TemplateRolledBack = "assignment_template.rolled_back"
+
+	RegionCreated = "region.created"
+	RegionUpdated = "region.updated"
+	RegionDeleted = "region.deleted"
+
+	WaitlistOffered       = "waitlist.offered"
+	WaitlistOfferAccepted = "waitlist.offer_accepted"
+	WaitlistOfferExpired  = "waitlist.offer_expired"
+
+	AttendanceRecorded = "attendance.recorded"
+)
+
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusDelivered  = "delivered"
+	StatusFailed     = "failed"
+)
+
+type Event struct {
+	ID            primitive.ObjectID     `json:"id" bson:"_id"`
+	Type          string                 `json:"type" bson:"type"`
+	Version       int                    `json:"version" bson:"version"`
+	AggregateType string                 `json:"aggregate_type" bson:"aggregate_type"`
+	AggregateID   string                 `json:"aggregate_id" bson:"aggregate_id"`
+	Actor         *string                `json:"actor,omitempty" bson:"actor,omitempty"`
+	Data          map[string]interface{} `json:"data" bson:"data"`
+	OccurredAt    time.Time              `json:"occurred_at" bson:"occurred_at"`
+	Status        string                 `json:"-" bson:"status"`
+	Attempts      int                    `json:"-" bson:"attempts"`
+	LastError     *string                `json:"-" bson:"last_error"`
+	NextAttemptAt time.Time              `json:"-" bson:"next_attempt_at"`
+	DeliveredAt   *time.Time             `json:"-" bson:"delivered_at"`
+}
+
+// New builds a pending event for the given aggregate. Data should only hold
+// identifiers and plain values so the payload stays stable for consumers.
+func New(eventType, aggregateType, aggregateID string, data map[string]interface{}) *Event {
+	now := time.Now()
+	return &Event{
+		ID:            primitive.NewObjectID(),
+		Type:          eventType,
+		Version:       Version,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Data:          data,
+		OccurredAt:    now,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+	}
+}
+
+// WithActor records the user that triggered the change.
+func (e *Event) WithActor(userID string) *Event {
+	if userID != "" {
+		e.Actor = &userID
+	}
+	return e
+}
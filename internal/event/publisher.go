@@ -0,0 +1,120 @@
+package event
+
+import (
+	"context"
+	"log"
+)
+
+// Publisher records domain events. Implementations must not block on
+// delivery to consumers; that is the dispatcher's job.
+type Publisher interface {
+	Publish(ctx context.Context, events ...*Event) error
+}
+
+type outboxPublisher struct {
+	OutboxRepository OutboxRepository
+}
+
+// NewOutboxPublisher stores events in the Mongo outbox so the dispatcher can
+// deliver them after the request has finished.
+func NewOutboxPublisher(repo OutboxRepository) Publisher {
+	return &outboxPublisher{
+		OutboxRepository: repo,
+	}
+}
+
+func (p *outboxPublisher) Publish(ctx context.Context, events ...*Event) error {
+
+	for _, e := range events {
+		if err := p.OutboxRepository.InsertEvent(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+type sinkPublisher struct {
+	sinks []Sink
+}
+
+// NewSinkPublisher hands events straight to the given sinks without going
+// through the outbox, e.g. a MemorySink when running locally.
+func NewSinkPublisher(sinks ...Sink) Publisher {
+	return &sinkPublisher{
+		sinks: sinks,
+	}
+}
+
+func (p *sinkPublisher) Publish(ctx context.Context, events ...*Event) error {
+
+	for _, e := range events {
+		for _, sink := range p.sinks {
+			if err := sink.Deliver(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+
+}
+
+type multiPublisher struct {
+	publishers []Publisher
+}
+
+// NewMultiPublisher hands events to every publisher in order, with the
+// caller's context, so publishers that write to Mongo join its transaction.
+// A failing publisher does not keep the others from running; the first
+// error is returned.
+func NewMultiPublisher(publishers ...Publisher) Publisher {
+	return &multiPublisher{
+		publishers: publishers,
+	}
+}
+
+func (p *multiPublisher) Publish(ctx context.Context, events ...*Event) error {
+
+	var first error
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, events...); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+
+}
+
+// Record publishes events as part of the change that produced them. It is
+// called inside the writer's transaction, so publishers that write to Mongo,
+// such as the template history, commit or roll back together with the change,
+// and a failed publish fails the change.
+func Record(ctx context.Context, publisher Publisher, events ...*Event) error {
+
+	if publisher == nil || len(events) == 0 {
+		return nil
+	}
+
+	return publisher.Publish(ctx, events...)
+
+}
+
+// Emit publishes events on a best-effort basis. The change that produced the
+// events has already been written, so failures are logged instead of being
+// returned to the caller.
+func Emit(ctx context.Context, publisher Publisher, events ...*Event) {
+
+	if publisher == nil || len(events) == 0 {
+		return
+	}
+
+	if err := publisher.Publish(ctx, events...); err != nil {
+		for _, e := range events {
+			log.Printf("[ERROR] publish event failed (type=%s, aggregate_id=%s): %v", e.Type, e.AggregateID, err)
+		}
+	}
+
+}
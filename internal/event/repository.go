@@ -0,0 +1,119 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OutboxRepository interface {
+	InsertEvent(ctx context.Context, event *Event) error
+	ClaimPendingEvent(ctx context.Context, now time.Time, lockFor time.Duration) (*Event, error)
+	MarkDelivered(ctx context.Context, id primitive.ObjectID) error
+	MarkAttemptFailed(ctx context.Context, id primitive.ObjectID, attempts int, lastError string, nextAttemptAt time.Time, giveUp bool) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type outboxRepository struct {
+	outboxCollection *mongo.Collection
+}
+
+func NewOutboxRepository(outboxCollection *mongo.Collection) OutboxRepository {
+	return &outboxRepository{
+		outboxCollection: outboxCollection,
+	}
+}
+
+func (r *outboxRepository) InsertEvent(ctx context.Context, event *Event) error {
+
+	_, err := r.outboxCollection.InsertOne(ctx, event)
+	return err
+
+}
+
+// ClaimPendingEvent atomically takes the oldest due event and marks it
+// processing until now+lockFor, so that dispatchers on other replicas skip
+// it. An event whose claim expired, because its dispatcher died, is due
+// again.
+func (r *outboxRepository) ClaimPendingEvent(ctx context.Context, now time.Time, lockFor time.Duration) (*Event, error) {
+
+	filter := bson.M{
+		"status":          bson.M{"$in": []string{StatusPending, StatusProcessing}},
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          StatusProcessing,
+			"next_attempt_at": now.Add(lockFor),
+		},
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "occurred_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var event Event
+	err := r.outboxCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &event, nil
+
+}
+
+func (r *outboxRepository) MarkDelivered(ctx context.Context, id primitive.ObjectID) error {
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":       StatusDelivered,
+			"delivered_at": now,
+			"last_error":   nil,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	_, err := r.outboxCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+
+}
+
+func (r *outboxRepository) MarkAttemptFailed(ctx context.Context, id primitive.ObjectID, attempts int, lastError string, nextAttemptAt time.Time, giveUp bool) error {
+
+	status := StatusPending
+	if giveUp {
+		status = StatusFailed
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"attempts":        attempts,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		},
+	}
+
+	_, err := r.outboxCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+
+}
+
+func (r *outboxRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.outboxCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "aggregate_type", Value: 1}, {Key: "aggregate_id", Value: 1}, {Key: "occurred_at", Value: 1}}},
+	})
+	return err
+
+}
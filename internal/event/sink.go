@@ -0,0 +1,124 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Sink is a destination the dispatcher delivers events to.
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, event *Event) error
+}
+
+type WebhookSink struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		URL:    url,
+		Secret: secret,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook:" + s.URL
+}
+
+// Deliver POSTs the event as JSON. When a secret is configured the body is
+// signed with HMAC-SHA256 in the X-Event-Signature header.
+func (s *WebhookSink) Deliver(ctx context.Context, event *Event) error {
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID.Hex())
+	req.Header.Set("X-Event-Type", event.Type)
+	req.Header.Set("X-Event-Version", fmt.Sprintf("%d", event.Version))
+
+	if s.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Event-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with status %d", s.URL, resp.StatusCode)
+	}
+
+	return nil
+
+}
+
+// MemorySink keeps delivered events in memory. It is meant for local runs
+// and tests that want to assert on what was published.
+type MemorySink struct {
+	mu     sync.Mutex
+	events []*Event
+}
+
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (s *MemorySink) Name() string {
+	return "memory"
+}
+
+func (s *MemorySink) Deliver(ctx context.Context, event *Event) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, event)
+	return nil
+
+}
+
+// Events returns a copy of everything delivered so far.
+func (s *MemorySink) Events() []*Event {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]*Event, len(s.events))
+	copy(events, s.events)
+	return events
+
+}
+
+// Reset drops all recorded events.
+func (s *MemorySink) Reset() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = nil
+
+}
@@ -0,0 +1,237 @@
+package history
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/leader"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type position struct {
+	classroomID primitive.ObjectID
+	slot        int
+	session     string
+}
+
+func (p position) less(other position) bool {
+	if p.classroomID != other.classroomID {
+		return p.classroomID.Hex() < other.classroomID.Hex()
+	}
+	if p.slot != other.slot {
+		return p.slot < other.slot
+	}
+	return p.session < other.session
+}
+
+func sessionPointer(session string) *string {
+	if session == "" {
+		return nil
+	}
+	return &session
+}
+
+func (p position) toPosition() *Position {
+	return &Position{
+		ClassRoomID: p.classroomID.Hex(),
+		SlotNumber:  p.slot,
+		SessionID:   sessionPointer(p.session),
+	}
+}
+
+// positionsOf groups the slots of the templates by the person pick returns.
+func positionsOf(templates []*assign.ClassRoomTemplateAssignment, pick func(*assign.ClassRoomTemplateAssignment) *string) map[string]map[position]bool {
+
+	result := make(map[string]map[position]bool)
+	for _, t := range templates {
+		id := pick(t)
+		if id == nil || *id == "" {
+			continue
+		}
+		if result[*id] == nil {
+			result[*id] = make(map[position]bool)
+		}
+		result[*id][position{t.ClassRoomID, t.SlotNumber, sessionKey(t.SessionID)}] = true
+	}
+
+	return result
+
+}
+
+// missing returns the positions of a not in b, in order.
+func missing(a, b map[position]bool) []position {
+
+	var result []position
+	for p := range a {
+		if !b[p] {
+			result = append(result, p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].less(result[j]) })
+
+	return result
+
+}
+
+type slotChange struct {
+	added   []string
+	removed []string
+	moved   []*Moved
+}
+
+// changes compares where each person sits on both sides. A slot a person
+// left is paired with a slot they joined as a move; what is left over is
+// an addition or a removal.
+func changes(from, to map[string]map[position]bool) map[position]*slotChange {
+
+	result := make(map[position]*slotChange)
+	at := func(p position) *slotChange {
+		if result[p] == nil {
+			result[p] = &slotChange{}
+		}
+		return result[p]
+	}
+
+	ids := make(map[string]bool)
+	for id := range from {
+		ids[id] = true
+	}
+	for id := range to {
+		ids[id] = true
+	}
+
+	for id := range ids {
+		left := missing(from[id], to[id])
+		joined := missing(to[id], from[id])
+
+		i := 0
+		for ; i < len(left) && i < len(joined); i++ {
+			c := at(joined[i])
+			c.moved = append(c.moved, &Moved{ID: id, From: left[i].toPosition()})
+		}
+		for _, p := range joined[i:] {
+			c := at(p)
+			c.added = append(c.added, id)
+		}
+		for _, p := range left[i:] {
+			c := at(p)
+			c.removed = append(c.removed, id)
+		}
+	}
+
+	for _, c := range result {
+		sort.Strings(c.added)
+		sort.Strings(c.removed)
+		sort.Slice(c.moved, func(i, j int) bool { return c.moved[i].ID < c.moved[j].ID })
+	}
+
+	return result
+
+}
+
+func diff(fromAssignments, toAssignments []*assign.ClassRoomTemplateAssignment, fromLeaders, toLeaders []*leader.LeaderTemplate) *TemplateDiffResponse {
+
+	student := func(t *assign.ClassRoomTemplateAssignment) *string { return t.StudentID }
+	teacher := func(t *assign.ClassRoomTemplateAssignment) *string { return t.TeacherID }
+
+	students := changes(positionsOf(fromAssignments, student), positionsOf(toAssignments, student))
+	teachers := changes(positionsOf(fromAssignments, teacher), positionsOf(toAssignments, teacher))
+
+	slots := make(map[position]*SlotDiff)
+	slotAt := func(p position) *SlotDiff {
+		if slots[p] == nil {
+			slots[p] = &SlotDiff{
+				ClassRoomID:     p.classroomID.Hex(),
+				SlotNumber:      p.slot,
+				SessionID:       sessionPointer(p.session),
+				StudentsAdded:   make([]string, 0),
+				StudentsRemoved: make([]string, 0),
+				StudentsMoved:   make([]*Moved, 0),
+				TeachersAdded:   make([]string, 0),
+				TeachersRemoved: make([]string, 0),
+				TeachersMoved:   make([]*Moved, 0),
+			}
+		}
+		return slots[p]
+	}
+
+	for p, c := range students {
+		d := slotAt(p)
+		d.StudentsAdded = append(d.StudentsAdded, c.added...)
+		d.StudentsRemoved = append(d.StudentsRemoved, c.removed...)
+		d.StudentsMoved = append(d.StudentsMoved, c.moved...)
+	}
+	for p, c := range teachers {
+		d := slotAt(p)
+		d.TeachersAdded = append(d.TeachersAdded, c.added...)
+		d.TeachersRemoved = append(d.TeachersRemoved, c.removed...)
+		d.TeachersMoved = append(d.TeachersMoved, c.moved...)
+	}
+
+	order := make([]position, 0, len(slots))
+	for p := range slots {
+		order = append(order, p)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i].less(order[j]) })
+
+	res := &TemplateDiffResponse{
+		Slots:   make([]*SlotDiff, 0, len(order)),
+		Leaders: leaderChanges(fromLeaders, toLeaders),
+	}
+	for _, p := range order {
+		res.Slots = append(res.Slots, slots[p])
+	}
+
+	return res
+
+}
+
+// leaderChanges lists the classroom sessions whose leader differs.
+func leaderChanges(fromLeaders, toLeaders []*leader.LeaderTemplate) []*LeaderDiff {
+
+	type key struct {
+		classroomID primitive.ObjectID
+		session     string
+	}
+
+	from := make(map[key]*leader.Owner)
+	to := make(map[key]*leader.Owner)
+	for _, l := range fromLeaders {
+		from[key{l.ClassRoomID, sessionKey(l.SessionID)}] = l.Owner
+	}
+	for _, l := range toLeaders {
+		to[key{l.ClassRoomID, sessionKey(l.SessionID)}] = l.Owner
+	}
+
+	keys := make(map[key]bool)
+	for k := range from {
+		keys[k] = true
+	}
+	for k := range to {
+		keys[k] = true
+	}
+
+	result := make([]*LeaderDiff, 0)
+	for k := range keys {
+		a, b := from[k], to[k]
+		if (a == nil && b == nil) || (a != nil && b != nil && *a == *b) {
+			continue
+		}
+		result = append(result, &LeaderDiff{
+			ClassRoomID: k.classroomID.Hex(),
+			SessionID:   sessionPointer(k.session),
+			From:        a,
+			To:          b,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ClassRoomID != result[j].ClassRoomID {
+			return result[i].ClassRoomID < result[j].ClassRoomID
+		}
+		return deref(result[i].SessionID) < deref(result[j].SessionID)
+	})
+
+	return result
+
+}
@@ -0,0 +1,111 @@
+package history
+
+import (
+	"classroom-service/helper"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HistoryHandler struct {
+	HistoryService HistoryService
+}
+
+func NewHistoryHandler(historyService HistoryService) *HistoryHandler {
+	return &HistoryHandler{
+		HistoryService: historyService,
+	}
+}
+
+func historyErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrVersionNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *HistoryHandler) GetVersions(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	if classroomID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("classroom_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	termID := c.Query("term_id")
+	if termID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("term_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	versions, err := h.HistoryService.GetVersions(c, classroomID, termID)
+
+	if err != nil {
+		statusCode, errorCode := historyErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Template Versions Successfully", versions)
+
+}
+
+// GetDiff compares two versions of a classroom's templates when
+// from_version is given, otherwise the templates of from_term_id and
+// to_term_id.
+func (h *HistoryHandler) GetDiff(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+
+	var (
+		res *TemplateDiffResponse
+		err error
+	)
+
+	if value := c.Query("from_version"); value != "" {
+		from, parseErr := strconv.Atoi(value)
+		if parseErr != nil {
+			helper.SendError(c, http.StatusBadRequest, fmt.Errorf("from_version must be a number"), "INVALID_REQUEST")
+			return
+		}
+
+		to := 0
+		if value := c.Query("to_version"); value != "" {
+			to, parseErr = strconv.Atoi(value)
+			if parseErr != nil {
+				helper.SendError(c, http.StatusBadRequest, fmt.Errorf("to_version must be a number"), "INVALID_REQUEST")
+				return
+			}
+		}
+
+		if classroomID == "" {
+			helper.SendError(c, http.StatusBadRequest, errors.New("classroom_id is required"), "INVALID_REQUEST")
+			return
+		}
+
+		res, err = h.HistoryService.DiffVersions(c, classroomID, c.Query("term_id"), from, to)
+	} else {
+		fromTermID := c.Query("from_term_id")
+		toTermID := c.Query("to_term_id")
+		if fromTermID == "" || toTermID == "" {
+			helper.SendError(c, http.StatusBadRequest, errors.New("from_version or from_term_id and to_term_id are required"), "INVALID_REQUEST")
+			return
+		}
+
+		res, err = h.HistoryService.DiffTerms(c, classroomID, fromTermID, toTermID)
+	}
+
+	if err != nil {
+		statusCode, errorCode := historyErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Template Diff Successfully", res)
+
+}
@@ -0,0 +1,31 @@
+package history
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/leader"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TemplateVersion is a snapshot of a classroom's assignment and leader
+// templates for a term, taken when a change to them, to the leader rota or
+// to the published version is written. Versions are numbered per classroom
+// and term starting at 1.
+type TemplateVersion struct {
+	ID          primitive.ObjectID                    `json:"id" bson:"_id"`
+	ClassRoomID primitive.ObjectID                    `json:"class_room_id" bson:"class_room_id"`
+	TermID      primitive.ObjectID                    `json:"term_id" bson:"term_id"`
+	Version     int                                   `json:"version" bson:"version"`
+	Assignments []*assign.ClassRoomTemplateAssignment `json:"assignments" bson:"assignments"`
+	Leaders     []*leader.LeaderTemplate              `json:"leaders" bson:"leaders"`
+	Rota        *leader.LeaderRota                    `json:"rota,omitempty" bson:"rota,omitempty"`
+	// Publication is the number of the publication current at the time,
+	// nil while the classroom was never published.
+	Publication *int `json:"publication,omitempty" bson:"publication,omitempty"`
+	// EventID and EventType identify the change that led to the snapshot.
+	EventID   primitive.ObjectID `json:"event_id" bson:"event_id"`
+	EventType string             `json:"event_type" bson:"event_type"`
+	ChangedBy *string            `json:"changed_by" bson:"changed_by"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
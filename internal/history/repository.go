@@ -0,0 +1,129 @@
+package history
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type HistoryRepository interface {
+	CreateVersion(ctx context.Context, version *TemplateVersion) error
+	GetLatestVersion(ctx context.Context, classroomID, termID primitive.ObjectID) (*TemplateVersion, error)
+	GetVersion(ctx context.Context, classroomID, termID primitive.ObjectID, version int) (*TemplateVersion, error)
+	GetVersions(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*TemplateVersion, error)
+	ExistsForEvent(ctx context.Context, eventID, classroomID primitive.ObjectID) (bool, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type historyRepository struct {
+	versionCollection *mongo.Collection
+}
+
+func NewHistoryRepository(versionCollection *mongo.Collection) HistoryRepository {
+	return &historyRepository{
+		versionCollection: versionCollection,
+	}
+}
+
+func (r *historyRepository) CreateVersion(ctx context.Context, version *TemplateVersion) error {
+
+	_, err := r.versionCollection.InsertOne(ctx, version)
+	return err
+
+}
+
+func (r *historyRepository) GetLatestVersion(ctx context.Context, classroomID, termID primitive.ObjectID) (*TemplateVersion, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+	}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	return r.findVersion(ctx, filter, opts)
+
+}
+
+func (r *historyRepository) GetVersion(ctx context.Context, classroomID, termID primitive.ObjectID, version int) (*TemplateVersion, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"version":       version,
+	}
+
+	return r.findVersion(ctx, filter)
+
+}
+
+func (r *historyRepository) findVersion(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*TemplateVersion, error) {
+
+	var version TemplateVersion
+	err := r.versionCollection.FindOne(ctx, filter, opts...).Decode(&version)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &version, nil
+
+}
+
+func (r *historyRepository) GetVersions(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*TemplateVersion, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := r.versionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*TemplateVersion
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+func (r *historyRepository) ExistsForEvent(ctx context.Context, eventID, classroomID primitive.ObjectID) (bool, error) {
+
+	count, err := r.versionCollection.CountDocuments(ctx, bson.M{
+		"event_id":      eventID,
+		"class_room_id": classroomID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+
+}
+
+// EnsureIndexes makes version numbers unique per classroom and term, so two
+// writers numbering a snapshot at the same time cannot both store it.
+func (r *historyRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.versionCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "class_room_id", Value: 1}, {Key: "term_id", Value: 1}, {Key: "version", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "event_id", Value: 1}, {Key: "class_room_id", Value: 1}}},
+	})
+	return err
+
+}
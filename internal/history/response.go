@@ -0,0 +1,44 @@
+package history
+
+import "classroom-service/internal/leader"
+
+// TemplateDiffResponse lists what changed between two template versions of
+// a classroom, or between the templates of two terms.
+type TemplateDiffResponse struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Slots   []*SlotDiff   `json:"slots"`
+	Leaders []*LeaderDiff `json:"leaders"`
+}
+
+// SlotDiff holds the changes of one slot. A person who left one slot and
+// joined another is listed as moved in the slot they joined.
+type SlotDiff struct {
+	ClassRoomID     string   `json:"class_room_id"`
+	SlotNumber      int      `json:"slot_number"`
+	SessionID       *string  `json:"session_id"`
+	StudentsAdded   []string `json:"students_added"`
+	StudentsRemoved []string `json:"students_removed"`
+	StudentsMoved   []*Moved `json:"students_moved"`
+	TeachersAdded   []string `json:"teachers_added"`
+	TeachersRemoved []string `json:"teachers_removed"`
+	TeachersMoved   []*Moved `json:"teachers_moved"`
+}
+
+type Moved struct {
+	ID   string    `json:"id"`
+	From *Position `json:"from"`
+}
+
+type Position struct {
+	ClassRoomID string  `json:"class_room_id"`
+	SlotNumber  int     `json:"slot_number"`
+	SessionID   *string `json:"session_id"`
+}
+
+type LeaderDiff struct {
+	ClassRoomID string        `json:"class_room_id"`
+	SessionID   *string       `json:"session_id"`
+	From        *leader.Owner `json:"from"`
+	To          *leader.Owner `json:"to"`
+}
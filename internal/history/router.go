@@ -0,0 +1,16 @@
+package history
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *HistoryHandler) {
+	historyGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Template Versions
+		historyGroup.GET("/template-versions", handler.GetVersions)
+		historyGroup.GET("/template-versions/diff", handler.GetDiff)
+	}
+}
@@ -0,0 +1,439 @@
+package history
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/event"
+	"classroom-service/internal/leader"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrVersionNotFound = errors.New("template version not found")
+
+// maxVersionAttempts bounds how often a snapshot is numbered again after
+// another writer stored the same version number first.
+const maxVersionAttempts = 5
+
+type HistoryService interface {
+	GetVersions(ctx context.Context, classroomID, termID string) ([]*TemplateVersion, error)
+	DiffVersions(ctx context.Context, classroomID, termID string, from, to int) (*TemplateDiffResponse, error)
+	DiffTerms(ctx context.Context, classroomID, fromTermID, toTermID string) (*TemplateDiffResponse, error)
+	// HistoryService is one of the event publishers and takes a snapshot
+	// whenever a template change is published.
+	event.Publisher
+}
+
+type historyService struct {
+	HistoryRepository HistoryRepository
+	AssignRepository  assign.AssignRepository
+	LeaderRepository  leader.LeaderRepository
+}
+
+func NewHistoryService(historyRepository HistoryRepository,
+	assignRepository assign.AssignRepository,
+	leaderRepository leader.LeaderRepository) HistoryService {
+	return &historyService{
+		HistoryRepository: historyRepository,
+		AssignRepository:  assignRepository,
+		LeaderRepository:  leaderRepository,
+	}
+}
+
+// Publish implements event.Publisher. Writers record template events with
+// event.Record inside their transaction, right after the change is written,
+// so the snapshot is read and stored in that transaction and holds the
+// classroom's templates as the change left them. An error fails the change.
+// Snapshots equal to the latest version are skipped.
+func (s *historyService) Publish(ctx context.Context, events ...*event.Event) error {
+
+	for _, e := range events {
+		if err := s.record(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+func (s *historyService) record(ctx context.Context, e *event.Event) error {
+
+	switch e.Type {
+	case event.AssignmentTemplateChanged, event.LeaderTemplateAssigned, event.LeaderTemplateRemoved,
+		event.TemplatePublished, event.TemplateRolledBack, event.LeaderRotaChanged, event.LeaderRotaRemoved:
+		classroomID, err := dataObjectID(e, "class_room_id")
+		if err != nil {
+			return err
+		}
+		termID, err := dataObjectID(e, "term_id")
+		if err != nil {
+			return err
+		}
+		return s.snapshot(ctx, e, classroomID, termID)
+
+	case event.TermRolledOver:
+		termID, err := dataObjectID(e, "to_term_id")
+		if err != nil {
+			return err
+		}
+		return s.snapshotTerm(ctx, e, termID)
+
+	case event.TemplateProposalApplied:
+		termID, err := dataObjectID(e, "term_id")
+		if err != nil {
+			return err
+		}
+		return s.snapshotTerm(ctx, e, termID)
+	}
+
+	return nil
+
+}
+
+func dataObjectID(e *event.Event, key string) (primitive.ObjectID, error) {
+
+	value, _ := e.Data[key].(string)
+
+	id, err := primitive.ObjectIDFromHex(value)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("event %s has no valid %s: %v", e.ID.Hex(), key, err)
+	}
+
+	return id, nil
+
+}
+
+// snapshotTerm snapshots every classroom with templates in the term.
+func (s *historyService) snapshotTerm(ctx context.Context, e *event.Event, termID primitive.ObjectID) error {
+
+	templates, err := s.AssignRepository.GetAssignmentTemplateByTermID(ctx, termID)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[primitive.ObjectID]bool)
+	for _, t := range templates {
+		if seen[t.ClassRoomID] {
+			continue
+		}
+		seen[t.ClassRoomID] = true
+
+		if err := s.snapshot(ctx, e, t.ClassRoomID, termID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+func (s *historyService) snapshot(ctx context.Context, e *event.Event, classroomID, termID primitive.ObjectID) error {
+
+	exists, err := s.HistoryRepository.ExistsForEvent(ctx, e.ID, classroomID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	assignments, leaders, err := s.current(ctx, classroomID, termID)
+	if err != nil {
+		return err
+	}
+
+	rota, err := s.LeaderRepository.GetLeaderRotaByClassID(ctx, classroomID, termID)
+	if err != nil {
+		return err
+	}
+
+	var publication *int
+	current, err := s.AssignRepository.GetCurrentTemplatePublication(ctx, classroomID, termID)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		publication = &current.Version
+	}
+
+	version := &TemplateVersion{
+		ClassRoomID: classroomID,
+		TermID:      termID,
+		Assignments: assignments,
+		Leaders:     leaders,
+		Rota:        rota,
+		Publication: publication,
+	}
+
+	// Version numbers are unique per classroom and term. When another writer
+	// took the number first, the latest version is read again and the next
+	// number is tried. Inside a transaction the clash usually shows up as a
+	// write conflict instead, and the driver runs the whole transaction again.
+	for attempt := 1; ; attempt++ {
+		latest, err := s.HistoryRepository.GetLatestVersion(ctx, classroomID, termID)
+		if err != nil {
+			return err
+		}
+
+		number := 1
+		if latest != nil {
+			if fingerprint(latest) == fingerprint(version) {
+				return nil
+			}
+			number = latest.Version + 1
+		}
+
+		err = s.HistoryRepository.CreateVersion(ctx, &TemplateVersion{
+			ID:          primitive.NewObjectID(),
+			ClassRoomID: classroomID,
+			TermID:      termID,
+			Version:     number,
+			Assignments: assignments,
+			Leaders:     leaders,
+			Rota:        rota,
+			Publication: publication,
+			EventID:     e.ID,
+			EventType:   e.Type,
+			ChangedBy:   e.Actor,
+			CreatedAt:   time.Now(),
+		})
+		if err == nil || !mongo.IsDuplicateKeyError(err) || attempt >= maxVersionAttempts {
+			return err
+		}
+	}
+
+}
+
+// current returns the classroom's templates for the term as they are now.
+func (s *historyService) current(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*assign.ClassRoomTemplateAssignment, []*leader.LeaderTemplate, error) {
+
+	assignments, err := s.AssignRepository.GetAssignmentTemplateByClassroomID(ctx, classroomID, termID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leaders, err := s.LeaderRepository.GetLeaderTemplatesByClassID(ctx, classroomID, termID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if assignments == nil {
+		assignments = make([]*assign.ClassRoomTemplateAssignment, 0)
+	}
+	if leaders == nil {
+		leaders = make([]*leader.LeaderTemplate, 0)
+	}
+
+	return assignments, leaders, nil
+
+}
+
+// fingerprint describes the content of a snapshot, leaving out ids and
+// timestamps, so that saving a template unchanged does not make a version.
+func fingerprint(v *TemplateVersion) string {
+
+	lines := make([]string, 0, len(v.Assignments)+len(v.Leaders)+2)
+
+	for _, a := range v.Assignments {
+		attendance, _ := json.Marshal(a.Attendance)
+		lines = append(lines, fmt.Sprintf("slot|%d|%s|%s|%s|%s|%s|%s",
+			a.SlotNumber, sessionKey(a.SessionID), deref(a.TeacherID), deref(a.StudentID),
+			attendance, dateKey(a.StartDate), dateKey(a.EndDate)))
+	}
+
+	for _, l := range v.Leaders {
+		var owner leader.Owner
+		if l.Owner != nil {
+			owner = *l.Owner
+		}
+		lines = append(lines, fmt.Sprintf("leader|%s|%s|%s", sessionKey(l.SessionID), owner.OwnerID, owner.OwnerRole))
+	}
+
+	if v.Rota != nil {
+		owners := make([]string, 0, len(v.Rota.Owners))
+		for _, o := range v.Rota.Owners {
+			owners = append(owners, fmt.Sprintf("%s/%s", o.OwnerID, o.OwnerRole))
+		}
+		lines = append(lines, fmt.Sprintf("rota|%s|%d|%v|%s|%s|%s",
+			v.Rota.RotationPeriod, v.Rota.RotationEvery, v.Rota.Weekdays,
+			dateKey(&v.Rota.StartDate), dateKey(&v.Rota.EndDate), strings.Join(owners, ",")))
+	}
+
+	if v.Publication != nil {
+		lines = append(lines, fmt.Sprintf("publication|%d", *v.Publication))
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+
+}
+
+func sessionKey(id *primitive.ObjectID) string {
+	if id == nil {
+		return ""
+	}
+	return id.Hex()
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func dateKey(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func parseClassroomTerm(classroomID, termID string) (primitive.ObjectID, primitive.ObjectID, error) {
+
+	classroomObjID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fmt.Errorf("invalid classroom id: %v", err)
+	}
+
+	termObjID, err := primitive.ObjectIDFromHex(termID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fmt.Errorf("invalid term id: %v", err)
+	}
+
+	return classroomObjID, termObjID, nil
+
+}
+
+func (s *historyService) GetVersions(ctx context.Context, classroomID, termID string) ([]*TemplateVersion, error) {
+
+	classroomObjID, termObjID, err := parseClassroomTerm(classroomID, termID)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := s.HistoryRepository.GetVersions(ctx, classroomObjID, termObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	if versions == nil {
+		versions = make([]*TemplateVersion, 0)
+	}
+
+	return versions, nil
+
+}
+
+// DiffVersions compares two versions of the classroom's templates. A to of
+// 0 means the latest version.
+func (s *historyService) DiffVersions(ctx context.Context, classroomID, termID string, from, to int) (*TemplateDiffResponse, error) {
+
+	classroomObjID, termObjID, err := parseClassroomTerm(classroomID, termID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromVersion, err := s.HistoryRepository.GetVersion(ctx, classroomObjID, termObjID, from)
+	if err != nil {
+		return nil, err
+	}
+	if fromVersion == nil {
+		return nil, fmt.Errorf("%w: version %d", ErrVersionNotFound, from)
+	}
+
+	var toVersion *TemplateVersion
+	if to == 0 {
+		toVersion, err = s.HistoryRepository.GetLatestVersion(ctx, classroomObjID, termObjID)
+	} else {
+		toVersion, err = s.HistoryRepository.GetVersion(ctx, classroomObjID, termObjID, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if toVersion == nil {
+		return nil, fmt.Errorf("%w: version %d", ErrVersionNotFound, to)
+	}
+
+	res := diff(fromVersion.Assignments, toVersion.Assignments, fromVersion.Leaders, toVersion.Leaders)
+	res.From = fmt.Sprintf("version %d", fromVersion.Version)
+	res.To = fmt.Sprintf("version %d", toVersion.Version)
+
+	return res, nil
+
+}
+
+// DiffTerms compares the current templates of two terms, for one classroom
+// or, without classroomID, for every classroom that has templates in either
+// term.
+func (s *historyService) DiffTerms(ctx context.Context, classroomID, fromTermID, toTermID string) (*TemplateDiffResponse, error) {
+
+	fromTermObjID, err := primitive.ObjectIDFromHex(fromTermID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from_term_id: %v", err)
+	}
+
+	toTermObjID, err := primitive.ObjectIDFromHex(toTermID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to_term_id: %v", err)
+	}
+
+	var classroomIDs []primitive.ObjectID
+	if classroomID != "" {
+		objID, err := primitive.ObjectIDFromHex(classroomID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid classroom id: %v", err)
+		}
+		classroomIDs = append(classroomIDs, objID)
+	} else {
+		seen := make(map[primitive.ObjectID]bool)
+		for _, termID := range []primitive.ObjectID{fromTermObjID, toTermObjID} {
+			templates, err := s.AssignRepository.GetAssignmentTemplateByTermID(ctx, termID)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range templates {
+				if !seen[t.ClassRoomID] {
+					seen[t.ClassRoomID] = true
+					classroomIDs = append(classroomIDs, t.ClassRoomID)
+				}
+			}
+		}
+	}
+
+	var (
+		fromAssignments, toAssignments []*assign.ClassRoomTemplateAssignment
+		fromLeaders, toLeaders         []*leader.LeaderTemplate
+	)
+	for _, id := range classroomIDs {
+		assignments, leaders, err := s.current(ctx, id, fromTermObjID)
+		if err != nil {
+			return nil, err
+		}
+		fromAssignments = append(fromAssignments, assignments...)
+		fromLeaders = append(fromLeaders, leaders...)
+
+		assignments, leaders, err = s.current(ctx, id, toTermObjID)
+		if err != nil {
+			return nil, err
+		}
+		toAssignments = append(toAssignments, assignments...)
+		toLeaders = append(toLeaders, leaders...)
+	}
+
+	res := diff(fromAssignments, toAssignments, fromLeaders, toLeaders)
+	res.From = fmt.Sprintf("term %s", fromTermID)
+	res.To = fmt.Sprintf("term %s", toTermID)
+
+	return res, nil
+
+}
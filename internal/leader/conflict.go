@@ -0,0 +1,186 @@
+package leader
+
+import (
+	"classroom-service/internal/session"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// An owner may lead several classrooms of the organization at the same time
+// as long as the sessions do not overlap. A rota leads its classroom for the
+// whole day. Every path that writes leaders, leader templates or rotas checks
+// the owner with the helpers below first.
+
+func organizationOf(ctx context.Context, repo LeaderRepository, classroomID primitive.ObjectID) (string, error) {
+
+	orgID, err := repo.GetClassroomOrganizationID(ctx, classroomID)
+	if err != nil {
+		return "", err
+	}
+
+	if orgID == nil {
+		return "", fmt.Errorf("%w: %s", ErrClassroomNotFound, classroomID.Hex())
+	}
+
+	return *orgID, nil
+
+}
+
+// CheckOwnerOnDates fails with ErrLeaderConflict when the owner already leads
+// another classroom in an overlapping session on one of the dates, either as
+// a daily leader or through a rota.
+func CheckOwnerOnDates(ctx context.Context, repo LeaderRepository, sessionRepo session.SessionRepository, classroomID primitive.ObjectID, ownerID string, sessionID *primitive.ObjectID, dates []time.Time) error {
+
+	if len(dates) == 0 {
+		return nil
+	}
+
+	orgID, err := organizationOf(ctx, repo, classroomID)
+	if err != nil {
+		return err
+	}
+
+	start, end := dates[0], dates[0]
+	for _, d := range dates {
+		if d.Before(start) {
+			start = d
+		}
+		if d.After(end) {
+			end = d
+		}
+	}
+
+	leaders, err := repo.GetOwnerLeadersInRange(ctx, orgID, ownerID, &start, &end, classroomID)
+	if err != nil {
+		return err
+	}
+
+	rotas, err := repo.GetOwnerLeaderRotas(ctx, orgID, ownerID, classroomID)
+	if err != nil {
+		return err
+	}
+
+	byDate := make(map[string][]*primitive.ObjectID)
+	for _, l := range leaders {
+		key := l.Date.Format("2006-01-02")
+		byDate[key] = append(byDate[key], l.SessionID)
+	}
+
+	for _, d := range dates {
+		key := d.Format("2006-01-02")
+
+		others := byDate[key]
+		for _, r := range rotas {
+			if owner := r.OwnerForDate(d); owner != nil && owner.OwnerID == ownerID {
+				others = append(others, nil)
+			}
+		}
+
+		i, err := session.FindConflict(ctx, sessionRepo, sessionID, others)
+		if err != nil {
+			return err
+		}
+
+		if i >= 0 {
+			return fmt.Errorf("%w on %s", ErrLeaderConflict, key)
+		}
+	}
+
+	return nil
+
+}
+
+// CheckOwnerInTerm fails with ErrLeaderConflict when the owner already has a
+// leader template in another classroom for an overlapping session of the
+// term, or is part of another classroom's rota for the term.
+func CheckOwnerInTerm(ctx context.Context, repo LeaderRepository, sessionRepo session.SessionRepository, classroomID, termID primitive.ObjectID, ownerID string, sessionID *primitive.ObjectID) error {
+
+	orgID, err := organizationOf(ctx, repo, classroomID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkOwnerTemplates(ctx, repo, sessionRepo, orgID, classroomID, termID, ownerID, sessionID); err != nil {
+		return err
+	}
+
+	rotas, err := repo.GetOwnerLeaderRotas(ctx, orgID, ownerID, classroomID)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rotas {
+		if r.TermID == termID {
+			return fmt.Errorf("%w in this term", ErrLeaderConflict)
+		}
+	}
+
+	return nil
+
+}
+
+// checkOwnerRota checks every owner of a rota on the days the rota hands
+// them the classroom, against the leader templates of the term in other
+// classrooms and against daily leaders and rotas on those days.
+func checkOwnerRota(ctx context.Context, repo LeaderRepository, sessionRepo session.SessionRepository, rota *LeaderRota) error {
+
+	orgID, err := organizationOf(ctx, repo, rota.ClassRoomID)
+	if err != nil {
+		return err
+	}
+
+	days := make(map[string][]time.Time)
+	for d := rota.StartDate; !d.After(rota.EndDate); d = d.AddDate(0, 0, 1) {
+		if owner := rota.OwnerForDate(d); owner != nil {
+			days[owner.OwnerID] = append(days[owner.OwnerID], d)
+		}
+	}
+
+	for _, owner := range rota.Owners {
+		if len(days[owner.OwnerID]) == 0 {
+			continue
+		}
+
+		if err := checkOwnerTemplates(ctx, repo, sessionRepo, orgID, rota.ClassRoomID, rota.TermID, owner.OwnerID, nil); err != nil {
+			return err
+		}
+
+		if err := CheckOwnerOnDates(ctx, repo, sessionRepo, rota.ClassRoomID, owner.OwnerID, nil, days[owner.OwnerID]); err != nil {
+			return err
+		}
+
+		// An owner listed twice is only checked once.
+		delete(days, owner.OwnerID)
+	}
+
+	return nil
+
+}
+
+func checkOwnerTemplates(ctx context.Context, repo LeaderRepository, sessionRepo session.SessionRepository, organizationID string, classroomID, termID primitive.ObjectID, ownerID string, sessionID *primitive.ObjectID) error {
+
+	others, err := repo.GetOwnerLeaderTemplatesInTerm(ctx, organizationID, ownerID, termID, classroomID)
+	if err != nil {
+		return err
+	}
+
+	otherSessions := make([]*primitive.ObjectID, 0, len(others))
+	for _, t := range others {
+		otherSessions = append(otherSessions, t.SessionID)
+	}
+
+	i, err := session.FindConflict(ctx, sessionRepo, sessionID, otherSessions)
+	if err != nil {
+		return err
+	}
+
+	if i >= 0 {
+		return fmt.Errorf("%w in this term", ErrLeaderConflict)
+	}
+
+	return nil
+
+}
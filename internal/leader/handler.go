@@ -2,6 +2,10 @@ package leader
 
 import (
 	"classroom-service/helper"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,19 +21,44 @@ func NewLeaderHandler(leaderService LeaderService) *LeaderHandler {
 	}
 }
 
+// leaderErrorStatus maps leader validation errors to the matching 4xx status.
+func leaderErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrClassroomNotFound),
+		errors.Is(err, ErrOwnerNotFound),
+		errors.Is(err, ErrLeaderNotFound),
+		errors.Is(err, ErrLeaderTemplateNotFound),
+		errors.Is(err, ErrLeaderRotaNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, ErrLeaderConflict):
+		return http.StatusConflict, helper.ErrConflict
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
 func (r *LeaderHandler) AddLeader(c *gin.Context) {
 
 	var req CreateLeaderRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
-		return 
+		return
 	}
 
-	err := r.LeaderService.AddLeader(c, &req)
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := r.LeaderService.AddLeader(ctx, &req)
 
 	if err != nil {
-		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
 		return
 	}
 
@@ -43,18 +72,27 @@ func (r *LeaderHandler) DeleteLeader(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
-		return 
+		return
 	}
 
-	err := r.LeaderService.DeleteLeader(c, &req)
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := r.LeaderService.DeleteLeader(ctx, &req)
 
 	if err != nil {
-		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
 		return
 	}
 
 	helper.SendSuccess(c, http.StatusOK, "Delete Leader Successfully", nil)
-	
+
 }
 
 func (r *LeaderHandler) CreateLeaderTemplate(c *gin.Context) {
@@ -63,13 +101,22 @@ func (r *LeaderHandler) CreateLeaderTemplate(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
-		return 
+		return
 	}
 
-	err := r.LeaderService.CreateLeaderTemplate(c, &req)
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := r.LeaderService.CreateLeaderTemplate(ctx, &req)
 
 	if err != nil {
-		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
 		return
 	}
 
@@ -83,16 +130,213 @@ func (r *LeaderHandler) DeleteLeaderTemplate(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
-		return 
+		return
 	}
 
-	err := r.LeaderService.DeleteLeaderTemplate(c, &req)
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := r.LeaderService.DeleteLeaderTemplate(ctx, &req)
 
 	if err != nil {
-		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
 		return
 	}
 
 	helper.SendSuccess(c, http.StatusOK, "Delete Leader Template Successfully", nil)
-	
-}
\ No newline at end of file
+
+}
+
+func (r *LeaderHandler) CreateLeaderRota(c *gin.Context) {
+
+	var req CreateLeaderRotaRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := r.LeaderService.CreateLeaderRota(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Create Leader Rota Successfully", nil)
+
+}
+
+func (r *LeaderHandler) DeleteLeaderRota(c *gin.Context) {
+
+	var req DeleteLeaderRotaRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := r.LeaderService.DeleteLeaderRota(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete Leader Rota Successfully", nil)
+
+}
+
+func (r *LeaderHandler) PreviewLeaderRota(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	if classroomID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("classroom_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	termID := c.Query("term_id")
+	if termID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("term_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	days, err := r.LeaderService.PreviewLeaderRota(ctx, classroomID, termID)
+
+	if err != nil {
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Preview Leader Rota Successfully", days)
+
+}
+
+func (r *LeaderHandler) GetLeadersByClassroom(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	if classroomID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("classroom_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	start := c.Query("start")
+	end := c.Query("end")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	leaders, err := r.LeaderService.GetLeadersByClassroom(ctx, classroomID, start, end)
+
+	if err != nil {
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Leaders Successfully", leaders)
+
+}
+
+func (r *LeaderHandler) GetLeadersByOwner(c *gin.Context) {
+
+	ownerID := c.Query("owner_id")
+	if ownerID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("owner_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	start := c.Query("start")
+	end := c.Query("end")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	leaders, err := r.LeaderService.GetLeadersByOwner(ctx, ownerID, start, end)
+
+	if err != nil {
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Leaders Successfully", leaders)
+
+}
+
+func (r *LeaderHandler) GetLeadersByRegion(c *gin.Context) {
+
+	regionID := c.Query("region_id")
+	if regionID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("region_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	date := c.Query("date")
+	if date == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("date is required"), "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	leaders, err := r.LeaderService.GetLeadersByRegion(ctx, regionID, date)
+
+	if err != nil {
+		statusCode, errorCode := leaderErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Leaders Successfully", leaders)
+
+}
@@ -7,24 +7,123 @@ import (
 )
 
 type Leader struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id"`
-	Owner       *Owner             `json:"owner" bson:"owner"`
-	Date        time.Time          `json:"date" bson:"date"`
-	ClassRoomID primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ID             primitive.ObjectID  `json:"id" bson:"_id"`
+	Owner          *Owner              `json:"owner" bson:"owner"`
+	Date           time.Time           `json:"date" bson:"date"`
+	ClassRoomID    primitive.ObjectID  `json:"class_room_id" bson:"class_room_id"`
+	SessionID      *primitive.ObjectID `json:"session_id" bson:"session_id"`
+	IsNotification bool                `json:"is_notification" bson:"is_notification"`
+	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+type OwnerRole string
+
+const (
+	OwnerRoleTeacher OwnerRole = "teacher"
+	OwnerRoleStaff   OwnerRole = "staff"
+)
+
+func (r OwnerRole) IsValid() bool {
+	return r == OwnerRoleTeacher || r == OwnerRoleStaff
 }
 
 type Owner struct {
-	OwnerID   string `json:"owner_id" bson:"owner_id"`
-	OwnerRole string `json:"owner_role" bson:"owner_role"`
+	OwnerID   string    `json:"owner_id" bson:"owner_id"`
+	OwnerRole OwnerRole `json:"owner_role" bson:"owner_role"`
 }
 
 type LeaderTemplate struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id"`
-	Owner       *Owner             `json:"owner" bson:"owner"`
-	TermID      primitive.ObjectID `json:"term_id" bson:"term_id"`
-	ClassRoomID primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	ID          primitive.ObjectID  `json:"id" bson:"_id"`
+	Owner       *Owner              `json:"owner" bson:"owner"`
+	TermID      primitive.ObjectID  `json:"term_id" bson:"term_id"`
+	ClassRoomID primitive.ObjectID  `json:"class_room_id" bson:"class_room_id"`
+	SessionID   *primitive.ObjectID `json:"session_id" bson:"session_id"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+const (
+	RotationPeriodDaily  = "daily"
+	RotationPeriodWeekly = "weekly"
+)
+
+// LeaderRota rotates the leader role of a classroom among an ordered list of
+// owners. Each owner holds the role for RotationEvery periods before handing
+// over to the next one. When Weekdays is empty a leader is assigned every day.
+// The rota ends with its term.
+type LeaderRota struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	TermID         primitive.ObjectID `json:"term_id" bson:"term_id"`
+	ClassRoomID    primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
+	Owners         []*Owner           `json:"owners" bson:"owners"`
+	RotationPeriod string             `json:"rotation_period" bson:"rotation_period"`
+	RotationEvery  int                `json:"rotation_every" bson:"rotation_every"`
+	Weekdays       []time.Weekday     `json:"weekdays" bson:"weekdays"`
+	StartDate      time.Time          `json:"start_date" bson:"start_date"`
+	EndDate        time.Time          `json:"end_date" bson:"end_date"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// IncludesDay reports whether the rota assigns a leader on the weekday of d.
+func (r *LeaderRota) IncludesDay(d time.Time) bool {
+	if len(r.Weekdays) == 0 {
+		return true
+	}
+	for _, w := range r.Weekdays {
+		if w == d.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnerForDate returns the owner leading on d, or nil when the rota does not
+// cover that day.
+func (r *LeaderRota) OwnerForDate(d time.Time) *Owner {
+
+	if len(r.Owners) == 0 || !r.IncludesDay(d) {
+		return nil
+	}
+
+	start := time.Date(r.StartDate.Year(), r.StartDate.Month(), r.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(start) {
+		return nil
+	}
+
+	if !r.EndDate.IsZero() {
+		end := time.Date(r.EndDate.Year(), r.EndDate.Month(), r.EndDate.Day(), 0, 0, 0, 0, time.UTC)
+		if day.After(end) {
+			return nil
+		}
+	}
+
+	every := r.RotationEvery
+	if every <= 0 {
+		every = 1
+	}
+
+	periods := 0
+	switch r.RotationPeriod {
+	case RotationPeriodWeekly:
+		periods = int(startOfWeek(day).Sub(startOfWeek(start)).Hours() / 24 / 7)
+	default:
+		// Daily rotation only counts the days on which a leader is assigned,
+		// so skipped weekdays do not consume an owner's turn.
+		for cur := start; cur.Before(day); cur = cur.AddDate(0, 0, 1) {
+			if r.IncludesDay(cur) {
+				periods++
+			}
+		}
+	}
+
+	return r.Owners[(periods/every)%len(r.Owners)]
+
+}
+
+func startOfWeek(d time.Time) time.Time {
+	offset := (int(d.Weekday()) + 6) % 7
+	return d.AddDate(0, 0, -offset)
 }
@@ -11,34 +11,82 @@ import (
 )
 
 type LeaderRepository interface {
+	RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 	CreateLeader(ctx context.Context, leader *Leader) error
-	GetLeaderByClassIDAndDate(ctx context.Context, classroomID primitive.ObjectID, date *time.Time) (*Leader, error)
+	GetLeaderByClassIDAndDate(ctx context.Context, classroomID primitive.ObjectID, date *time.Time, sessionID *primitive.ObjectID) (*Leader, error)
 	GetLeaderByClassID(ctx context.Context, classroomID primitive.ObjectID, start, end *time.Time, page, limit int) ([]*Leader, error)
-	DeleteLeader(ctx context.Context, classroomID primitive.ObjectID, date *time.Time) error
+	DeleteLeader(ctx context.Context, classroomID primitive.ObjectID, date *time.Time, sessionID *primitive.ObjectID) error
 	CountLeaderByClassroomID(ctx context.Context, classroomID primitive.ObjectID, start, end *time.Time) (int, error)
 	// Leader Template
 	CreateLeaderTemplate(ctx context.Context, leader *LeaderTemplate) error
-	DeleteLeaderTemplate(ctx context.Context, classroomID primitive.ObjectID) error
+	DeleteLeaderTemplate(ctx context.Context, classroomID, termID primitive.ObjectID, sessionID *primitive.ObjectID) error
 	GetLeaderTemplateByClassID(ctx context.Context, classroomID, termID primitive.ObjectID) (*LeaderTemplate, error)
+	GetLeaderTemplateBySession(ctx context.Context, classroomID, termID primitive.ObjectID, sessionID *primitive.ObjectID) (*LeaderTemplate, error)
+	GetLeaderTemplatesByClassID(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*LeaderTemplate, error)
+	// Leader Rota
+	CreateLeaderRota(ctx context.Context, rota *LeaderRota) error
+	GetLeaderRotaByClassID(ctx context.Context, classroomID, termID primitive.ObjectID) (*LeaderRota, error)
+	DeleteLeaderRota(ctx context.Context, classroomID, termID primitive.ObjectID) error
+	// Validation
+	GetClassroomOrganizationID(ctx context.Context, classroomID primitive.ObjectID) (*string, error)
+	GetOwnerLeadersInRange(ctx context.Context, organizationID, ownerID string, start, end *time.Time, excludeClassroomID primitive.ObjectID) ([]*Leader, error)
+	GetOwnerLeaderTemplatesInTerm(ctx context.Context, organizationID, ownerID string, termID, excludeClassroomID primitive.ObjectID) ([]*LeaderTemplate, error)
+	GetOwnerLeaderRotas(ctx context.Context, organizationID, ownerID string, excludeClassroomID primitive.ObjectID) ([]*LeaderRota, error)
+	// Leader Queries
+	GetLeadersByClassroomAndRange(ctx context.Context, classroomID primitive.ObjectID, start, end *time.Time) ([]*Leader, error)
+	GetLeadersByOwnerAndRange(ctx context.Context, ownerID string, start, end *time.Time) ([]*Leader, error)
+	GetLeadersByRegionAndDate(ctx context.Context, regionID primitive.ObjectID, date *time.Time) ([]*Leader, error)
+	EnsureIndexes(ctx context.Context) error
+	// Notifications
+	GetUnnotifiedLeadersByDate(ctx context.Context, date *time.Time) ([]*Leader, error)
+	MarkLeadersNotified(ctx context.Context, ids []primitive.ObjectID) error
 }
 
 type leaderRepository struct {
 	leaderCollection         *mongo.Collection
 	leaderTemplateCollection *mongo.Collection
+	leaderRotaCollection     *mongo.Collection
+	classroomCollection      *mongo.Collection
 }
 
-func NewLeaderRepository(leaderCollection, leaderTemplateCollection *mongo.Collection) LeaderRepository {
+func NewLeaderRepository(leaderCollection, leaderTemplateCollection, leaderRotaCollection, classroomCollection *mongo.Collection) LeaderRepository {
 	return &leaderRepository{
 		leaderCollection:         leaderCollection,
 		leaderTemplateCollection: leaderTemplateCollection,
+		leaderRotaCollection:     leaderRotaCollection,
+		classroomCollection:      classroomCollection,
 	}
 }
 
+// RunInTransaction runs fn in a MongoDB transaction, like the assignment
+// repository does. The driver retries fn on transient errors, so fn must be
+// safe to run more than once.
+func (r *leaderRepository) RunInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+
+	// A call made inside another transaction joins it.
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := r.leaderCollection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+
+}
+
 func (r *leaderRepository) CreateLeader(ctx context.Context, leader *Leader) error {
 
 	filter := bson.M{
 		"class_room_id": leader.ClassRoomID,
 		"date":          leader.Date,
+		"session_id":    leader.SessionID,
 	}
 
 	_, err := r.leaderCollection.DeleteMany(ctx, filter)
@@ -54,17 +102,26 @@ func (r *leaderRepository) CreateLeader(ctx context.Context, leader *Leader) err
 	return nil
 }
 
-func (r *leaderRepository) GetLeaderByClassIDAndDate(ctx context.Context, classroomID primitive.ObjectID, date *time.Time) (*Leader, error) {
+// dateRange matches the days from start through end. Leaders are stored per
+// day, so end is inclusive: a range of a single day returns that day.
+func dateRange(start, end *time.Time) bson.M {
+
+	from := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	to := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, end.Location()).AddDate(0, 0, 1)
+
+	return bson.M{
+		"$gte": from,
+		"$lt":  to,
+	}
+
+}
 
-	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	end := start.Add(24 * time.Hour)
+func (r *leaderRepository) GetLeaderByClassIDAndDate(ctx context.Context, classroomID primitive.ObjectID, date *time.Time, sessionID *primitive.ObjectID) (*Leader, error) {
 
 	filter := bson.M{
 		"class_room_id": classroomID,
-		"date": bson.M{
-			"$gte": start,
-			"$lt":  end,
-		},
+		"session_id":    sessionID,
+		"date":          dateRange(date, date),
 	}
 
 	var leader Leader
@@ -99,10 +156,7 @@ func (r leaderRepository) GetLeaderByClassID(ctx context.Context, classroomID pr
 
 	filter := bson.M{
 		"class_room_id": classroomID,
-		"date": bson.M{
-			"$gte": start,
-			"$lt":  end,
-		},
+		"date":          dateRange(start, end),
 	}
 
 	cursor, err := r.leaderCollection.Find(ctx, filter, opts)
@@ -120,17 +174,12 @@ func (r leaderRepository) GetLeaderByClassID(ctx context.Context, classroomID pr
 
 }
 
-func (r *leaderRepository) DeleteLeader(ctx context.Context, classroomID primitive.ObjectID, date *time.Time) error {
-
-	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	end := start.Add(24 * time.Hour)
+func (r *leaderRepository) DeleteLeader(ctx context.Context, classroomID primitive.ObjectID, date *time.Time, sessionID *primitive.ObjectID) error {
 
 	filter := bson.M{
 		"class_room_id": classroomID,
-		"date": bson.M{
-			"$gte": start,
-			"$lt":  end,
-		},
+		"session_id":    sessionID,
+		"date":          dateRange(date, date),
 	}
 
 	_, err := r.leaderCollection.DeleteOne(ctx, filter)
@@ -142,10 +191,7 @@ func (r *leaderRepository) CountLeaderByClassroomID(ctx context.Context, classro
 
 	filter := bson.M{
 		"class_room_id": classroomID,
-		"date": bson.M{
-			"$gte": start,
-			"$lt":  end,
-		},
+		"date":          dateRange(start, end),
 	}
 
 	count, err := r.leaderCollection.CountDocuments(ctx, filter)
@@ -161,6 +207,7 @@ func (r *leaderRepository) CreateLeaderTemplate(ctx context.Context, leader *Lea
 	filter := bson.M{
 		"class_room_id": leader.ClassRoomID,
 		"term_id":       leader.TermID,
+		"session_id":    leader.SessionID,
 	}
 
 	_, err := r.leaderTemplateCollection.DeleteMany(ctx, filter)
@@ -173,10 +220,12 @@ func (r *leaderRepository) CreateLeaderTemplate(ctx context.Context, leader *Lea
 
 }
 
-func (r *leaderRepository) DeleteLeaderTemplate(ctx context.Context, classroomID primitive.ObjectID) error {
+func (r *leaderRepository) DeleteLeaderTemplate(ctx context.Context, classroomID, termID primitive.ObjectID, sessionID *primitive.ObjectID) error {
 
 	filter := bson.M{
 		"class_room_id": classroomID,
+		"term_id":       termID,
+		"session_id":    sessionID,
 	}
 
 	_, err := r.leaderTemplateCollection.DeleteOne(ctx, filter)
@@ -203,3 +252,351 @@ func (r *leaderRepository) GetLeaderTemplateByClassID(ctx context.Context, class
 	return &leader, nil
 
 }
+
+func (r *leaderRepository) GetLeaderTemplateBySession(ctx context.Context, classroomID, termID primitive.ObjectID, sessionID *primitive.ObjectID) (*LeaderTemplate, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"session_id":    sessionID,
+	}
+
+	var leader LeaderTemplate
+	err := r.leaderTemplateCollection.FindOne(ctx, filter).Decode(&leader)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &leader, nil
+
+}
+
+func (r *leaderRepository) GetLeaderTemplatesByClassID(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*LeaderTemplate, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+	}
+
+	return r.findLeaderTemplates(ctx, filter)
+
+}
+
+func (r *leaderRepository) findLeaderTemplates(ctx context.Context, filter bson.M) ([]*LeaderTemplate, error) {
+
+	cursor, err := r.leaderTemplateCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*LeaderTemplate
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+func (r *leaderRepository) CreateLeaderRota(ctx context.Context, rota *LeaderRota) error {
+
+	filter := bson.M{
+		"class_room_id": rota.ClassRoomID,
+		"term_id":       rota.TermID,
+	}
+
+	_, err := r.leaderRotaCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.leaderRotaCollection.InsertOne(ctx, rota)
+	return err
+
+}
+
+func (r *leaderRepository) GetLeaderRotaByClassID(ctx context.Context, classroomID, termID primitive.ObjectID) (*LeaderRota, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+	}
+
+	var rota LeaderRota
+	err := r.leaderRotaCollection.FindOne(ctx, filter).Decode(&rota)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rota, nil
+
+}
+
+func (r *leaderRepository) DeleteLeaderRota(ctx context.Context, classroomID, termID primitive.ObjectID) error {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+	}
+
+	_, err := r.leaderRotaCollection.DeleteMany(ctx, filter)
+	return err
+
+}
+
+func (r *leaderRepository) GetClassroomOrganizationID(ctx context.Context, classroomID primitive.ObjectID) (*string, error) {
+
+	var classroom struct {
+		OrganizationID string `bson:"organization_id"`
+	}
+
+	opts := options.FindOne().SetProjection(bson.M{"organization_id": 1})
+
+	err := r.classroomCollection.FindOne(ctx, bson.M{"_id": classroomID}, opts).Decode(&classroom)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &classroom.OrganizationID, nil
+
+}
+
+func (r *leaderRepository) getClassroomIDsByOrganization(ctx context.Context, organizationID string) ([]primitive.ObjectID, error) {
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.classroomCollection.Find(ctx, bson.M{"organization_id": organizationID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var classrooms []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &classrooms); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(classrooms))
+	for _, c := range classrooms {
+		ids = append(ids, c.ID)
+	}
+
+	return ids, nil
+
+}
+
+// GetOwnerLeadersInRange returns the daily leaders of the owner in the other
+// classrooms of the organization, from start through end.
+func (r *leaderRepository) GetOwnerLeadersInRange(ctx context.Context, organizationID, ownerID string, start, end *time.Time, excludeClassroomID primitive.ObjectID) ([]*Leader, error) {
+
+	classroomIDs, err := r.getClassroomIDsByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"owner.owner_id": ownerID,
+		"class_room_id": bson.M{
+			"$in": classroomIDs,
+			"$ne": excludeClassroomID,
+		},
+		"date": dateRange(start, end),
+	}
+
+	return r.findLeaders(ctx, filter)
+
+}
+
+func (r *leaderRepository) GetOwnerLeaderTemplatesInTerm(ctx context.Context, organizationID, ownerID string, termID, excludeClassroomID primitive.ObjectID) ([]*LeaderTemplate, error) {
+
+	classroomIDs, err := r.getClassroomIDsByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"owner.owner_id": ownerID,
+		"term_id":        termID,
+		"class_room_id": bson.M{
+			"$in": classroomIDs,
+			"$ne": excludeClassroomID,
+		},
+	}
+
+	return r.findLeaderTemplates(ctx, filter)
+
+}
+
+// GetOwnerLeaderRotas returns the rotas of the other classrooms of the
+// organization that name the owner.
+func (r *leaderRepository) GetOwnerLeaderRotas(ctx context.Context, organizationID, ownerID string, excludeClassroomID primitive.ObjectID) ([]*LeaderRota, error) {
+
+	classroomIDs, err := r.getClassroomIDsByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"owners.owner_id": ownerID,
+		"class_room_id": bson.M{
+			"$in": classroomIDs,
+			"$ne": excludeClassroomID,
+		},
+	}
+
+	cursor, err := r.leaderRotaCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rotas []*LeaderRota
+	if err := cursor.All(ctx, &rotas); err != nil {
+		return nil, err
+	}
+
+	return rotas, nil
+
+}
+
+func (r *leaderRepository) findLeaders(ctx context.Context, filter bson.M) ([]*Leader, error) {
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+
+	cursor, err := r.leaderCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []*Leader
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+
+}
+
+func (r *leaderRepository) GetLeadersByClassroomAndRange(ctx context.Context, classroomID primitive.ObjectID, start, end *time.Time) ([]*Leader, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"date":          dateRange(start, end),
+	}
+
+	return r.findLeaders(ctx, filter)
+
+}
+
+func (r *leaderRepository) GetLeadersByOwnerAndRange(ctx context.Context, ownerID string, start, end *time.Time) ([]*Leader, error) {
+
+	filter := bson.M{
+		"owner.owner_id": ownerID,
+		"date":           dateRange(start, end),
+	}
+
+	return r.findLeaders(ctx, filter)
+
+}
+
+func (r *leaderRepository) GetLeadersByRegionAndDate(ctx context.Context, regionID primitive.ObjectID, date *time.Time) ([]*Leader, error) {
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.classroomCollection.Find(ctx, bson.M{"region_id": regionID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var classrooms []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &classrooms); err != nil {
+		return nil, err
+	}
+
+	classroomIDs := make([]primitive.ObjectID, 0, len(classrooms))
+	for _, c := range classrooms {
+		classroomIDs = append(classroomIDs, c.ID)
+	}
+
+	filter := bson.M{
+		"class_room_id": bson.M{"$in": classroomIDs},
+		"date":          dateRange(date, date),
+	}
+
+	return r.findLeaders(ctx, filter)
+
+}
+
+// EnsureIndexes creates the indexes backing the leader lookups by classroom,
+// owner and date.
+func (r *leaderRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.leaderCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "class_room_id", Value: 1}, {Key: "date", Value: 1}}},
+		{Keys: bson.D{{Key: "owner.owner_id", Value: 1}, {Key: "date", Value: 1}}},
+		{Keys: bson.D{{Key: "date", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.leaderTemplateCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "class_room_id", Value: 1}, {Key: "term_id", Value: 1}}},
+		{Keys: bson.D{{Key: "owner.owner_id", Value: 1}, {Key: "term_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.leaderRotaCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "class_room_id", Value: 1}, {Key: "term_id", Value: 1}},
+	})
+	return err
+
+}
+
+func (r *leaderRepository) GetUnnotifiedLeadersByDate(ctx context.Context, date *time.Time) ([]*Leader, error) {
+
+	filter := bson.M{
+		"date":            dateRange(date, date),
+		"is_notification": bson.M{"$ne": true},
+	}
+
+	return r.findLeaders(ctx, filter)
+
+}
+
+func (r *leaderRepository) MarkLeadersNotified(ctx context.Context, ids []primitive.ObjectID) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	update := bson.M{
+		"$set": bson.M{
+			"is_notification": true,
+			"updated_at":      time.Now(),
+		},
+	}
+
+	_, err := r.leaderCollection.UpdateMany(ctx, filter, update)
+	return err
+
+}
@@ -4,10 +4,28 @@ type CreateLeaderRequest struct {
 	TermID      string `json:"term_id" bson:"term_id"`
 	ClassroomID string `json:"classroom_id" bson:"classroom_id"`
 	Date        string `json:"date" bson:"date"`
+	SessionID   string `json:"session_id" bson:"session_id"`
 	Owner       Owner  `json:"owner" bson:"owner"`
 }
 
 type DeleteLeaderRequest struct {
+	TermID      string `json:"term_id" bson:"term_id"`
 	ClassroomID string `json:"classroom_id" bson:"classroom_id"`
 	Date        string `json:"date" bson:"date"`
+	SessionID   string `json:"session_id" bson:"session_id"`
+}
+
+type CreateLeaderRotaRequest struct {
+	TermID         string  `json:"term_id"`
+	ClassroomID    string  `json:"classroom_id"`
+	Owners         []Owner `json:"owners"`
+	RotationPeriod string  `json:"rotation_period"`
+	RotationEvery  int     `json:"rotation_every"`
+	Weekdays       []int   `json:"weekdays"`
+	StartDate      string  `json:"start_date"`
+}
+
+type DeleteLeaderRotaRequest struct {
+	TermID      string `json:"term_id"`
+	ClassroomID string `json:"classroom_id"`
 }
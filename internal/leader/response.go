@@ -0,0 +1,17 @@
+package leader
+
+import "classroom-service/internal/user"
+
+type LeaderRotaDayResponse struct {
+	Date  string `json:"date"`
+	Owner *Owner `json:"owner"`
+}
+
+type LeaderResponse struct {
+	ID          string          `json:"id"`
+	ClassRoomID string          `json:"class_room_id"`
+	Date        string          `json:"date"`
+	SessionID   *string         `json:"session_id"`
+	Owner       *Owner          `json:"owner"`
+	User        *user.UserInfor `json:"user"`
+}
@@ -15,5 +15,15 @@ func RegisterRoutes(r *gin.Engine, handler *LeaderHandler) {
 		// Leader Template
 		leaderGroup.POST("/leader-templates", handler.CreateLeaderTemplate)
 		leaderGroup.POST("/remove/leader-templates", handler.DeleteLeaderTemplate)
+
+		// Leader Rota
+		leaderGroup.POST("/leader-rotas", handler.CreateLeaderRota)
+		leaderGroup.POST("/remove/leader-rotas", handler.DeleteLeaderRota)
+		leaderGroup.GET("/leader-rotas/preview", handler.PreviewLeaderRota)
+
+		// Leader Queries
+		leaderGroup.GET("/leaders/classroom", handler.GetLeadersByClassroom)
+		leaderGroup.GET("/leaders/owner", handler.GetLeadersByOwner)
+		leaderGroup.GET("/leaders/region", handler.GetLeadersByRegion)
 	}
 }
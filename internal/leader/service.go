@@ -1,32 +1,127 @@
 package leader
 
 import (
+	"classroom-service/internal/event"
+	"classroom-service/internal/session"
+	"classroom-service/internal/term"
+	"classroom-service/internal/user"
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrClassroomNotFound      = errors.New("classroom not found")
+	ErrOwnerNotFound          = errors.New("owner not found")
+	ErrInvalidOwnerRole       = fmt.Errorf("owner_role must be %s or %s", OwnerRoleTeacher, OwnerRoleStaff)
+	ErrLeaderNotFound         = errors.New("leader not found")
+	ErrLeaderTemplateNotFound = errors.New("leader template not found")
+	ErrLeaderRotaNotFound     = errors.New("leader rota not found")
+	ErrLeaderConflict         = errors.New("owner already leads another classroom")
+)
+
 type LeaderService interface {
-	AddLeader(c *gin.Context, req *CreateLeaderRequest) error
-	DeleteLeader(c *gin.Context, req *DeleteLeaderRequest) error
+	AddLeader(ctx context.Context, req *CreateLeaderRequest) error
+	DeleteLeader(ctx context.Context, req *DeleteLeaderRequest) error
 	// Leader Template
-	CreateLeaderTemplate(c *gin.Context, req *CreateLeaderRequest) error
-	DeleteLeaderTemplate(c *gin.Context, req *DeleteLeaderRequest) error
+	CreateLeaderTemplate(ctx context.Context, req *CreateLeaderRequest) error
+	DeleteLeaderTemplate(ctx context.Context, req *DeleteLeaderRequest) error
+	// Leader Rota
+	CreateLeaderRota(ctx context.Context, req *CreateLeaderRotaRequest) error
+	DeleteLeaderRota(ctx context.Context, req *DeleteLeaderRotaRequest) error
+	PreviewLeaderRota(ctx context.Context, classroomID, termID string) ([]*LeaderRotaDayResponse, error)
+	// Leader Queries
+	GetLeadersByClassroom(ctx context.Context, classroomID, start, end string) ([]*LeaderResponse, error)
+	GetLeadersByOwner(ctx context.Context, ownerID, start, end string) ([]*LeaderResponse, error)
+	GetLeadersByRegion(ctx context.Context, regionID, date string) ([]*LeaderResponse, error)
 }
 
 type leaderService struct {
-	LeaderRepository LeaderRepository
+	LeaderRepository  LeaderRepository
+	UserService       user.UserService
+	TermService       term.TermService
+	EventPublisher    event.Publisher
+	SessionRepository session.SessionRepository
 }
 
-func NewLeaderService(leaderRepository LeaderRepository) LeaderService {
+func NewLeaderService(leaderRepository LeaderRepository, userService user.UserService, termService term.TermService, publisher event.Publisher, sessionRepository session.SessionRepository) LeaderService {
 	return &leaderService{
-		LeaderRepository: leaderRepository,
+		LeaderRepository:  leaderRepository,
+		UserService:       userService,
+		TermService:       termService,
+		EventPublisher:    publisher,
+		SessionRepository: sessionRepository,
+	}
+}
+
+// resolveSession returns the id of the requested session after checking it
+// belongs to the classroom. Nil means the whole day.
+func (s *leaderService) resolveSession(ctx context.Context, classroomID primitive.ObjectID, sessionID string) (*primitive.ObjectID, error) {
+
+	sess, err := session.ResolveForClassroom(ctx, s.SessionRepository, classroomID, sessionID)
+	if err != nil || sess == nil {
+		return nil, err
+	}
+
+	return &sess.ID, nil
+
+}
+
+func sessionHex(id *primitive.ObjectID) *string {
+	if id == nil {
+		return nil
 	}
+	hex := id.Hex()
+	return &hex
 }
 
-func (s *leaderService) AddLeader(c *gin.Context, req *CreateLeaderRequest) error {
+// validateOwner checks the role of an owner and verifies through the user
+// gateway that the person exists.
+func (s *leaderService) validateOwner(ctx context.Context, owner *Owner) error {
+
+	if owner.OwnerID == "" {
+		return errors.New("owner_id is required")
+	}
+
+	if !owner.OwnerRole.IsValid() {
+		return ErrInvalidOwnerRole
+	}
+
+	var (
+		infor *user.UserInfor
+		err   error
+	)
+
+	switch owner.OwnerRole {
+	case OwnerRoleTeacher:
+		infor, err = s.UserService.GetTeacherInfor(ctx, owner.OwnerID)
+	case OwnerRoleStaff:
+		infor, err = s.UserService.GetStaffInfor(ctx, owner.OwnerID)
+	}
+
+	if err != nil {
+		return err
+	}
+	if infor == nil {
+		return fmt.Errorf("%w: %s %s", ErrOwnerNotFound, owner.OwnerRole, owner.OwnerID)
+	}
+
+	return nil
+
+}
+
+// classroomOrganization returns the organization of a classroom, failing with
+// ErrClassroomNotFound when it does not exist.
+func (s *leaderService) classroomOrganization(ctx context.Context, classroomID primitive.ObjectID) (string, error) {
+
+	return organizationOf(ctx, s.LeaderRepository, classroomID)
+
+}
+
+func (s *leaderService) AddLeader(ctx context.Context, req *CreateLeaderRequest) error {
 
 	if req.Owner == (Owner{}) {
 		return fmt.Errorf("owner is required")
@@ -50,19 +145,48 @@ func (s *leaderService) AddLeader(c *gin.Context, req *CreateLeaderRequest) erro
 		return err
 	}
 
+	if _, err := s.classroomOrganization(ctx, objClassroomID); err != nil {
+		return err
+	}
+
+	sessionID, err := s.resolveSession(ctx, objClassroomID, req.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.validateOwner(ctx, &req.Owner); err != nil {
+		return err
+	}
+
+	if err := CheckOwnerOnDates(ctx, s.LeaderRepository, s.SessionRepository, objClassroomID, req.Owner.OwnerID, sessionID, []time.Time{dateParse}); err != nil {
+		return err
+	}
+
 	data := &Leader{
 		ID:          primitive.NewObjectID(),
 		Owner:       &req.Owner,
 		ClassRoomID: objClassroomID,
+		SessionID:   sessionID,
 		Date:        dateParse,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	return s.LeaderRepository.CreateLeader(c, data)
+	if err := s.LeaderRepository.CreateLeader(ctx, data); err != nil {
+		return err
+	}
+
+	event.Emit(ctx, s.EventPublisher, event.New(event.LeaderAssigned, "leader", data.ID.Hex(), map[string]interface{}{
+		"class_room_id": data.ClassRoomID.Hex(),
+		"date":          data.Date.Format("2006-01-02"),
+		"session_id":    sessionHex(data.SessionID),
+		"owner_id":      data.Owner.OwnerID,
+		"owner_role":    data.Owner.OwnerRole,
+	}))
+	return nil
 }
 
-func (s *leaderService) DeleteLeader(c *gin.Context, req *DeleteLeaderRequest) error {
+func (s *leaderService) DeleteLeader(ctx context.Context, req *DeleteLeaderRequest) error {
 
 	if req.ClassroomID == "" {
 		return fmt.Errorf("classroom_id is required")
@@ -82,10 +206,33 @@ func (s *leaderService) DeleteLeader(c *gin.Context, req *DeleteLeaderRequest) e
 		return err
 	}
 
-	return s.LeaderRepository.DeleteLeader(c, objClassroomID, &dateParse)
+	sessionID, err := s.resolveSession(ctx, objClassroomID, req.SessionID)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.LeaderRepository.GetLeaderByClassIDAndDate(ctx, objClassroomID, &dateParse, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return ErrLeaderNotFound
+	}
+
+	if err := s.LeaderRepository.DeleteLeader(ctx, objClassroomID, &dateParse, sessionID); err != nil {
+		return err
+	}
+
+	event.Emit(ctx, s.EventPublisher, event.New(event.LeaderRemoved, "leader", existing.ID.Hex(), map[string]interface{}{
+		"class_room_id": objClassroomID.Hex(),
+		"date":          req.Date,
+		"session_id":    sessionHex(sessionID),
+	}))
+	return nil
 }
 
-func (s *leaderService) CreateLeaderTemplate(c *gin.Context, req *CreateLeaderRequest) error {
+func (s *leaderService) CreateLeaderTemplate(ctx context.Context, req *CreateLeaderRequest) error {
 
 	if req.Owner == (Owner{}) {
 		return fmt.Errorf("owner is required")
@@ -95,6 +242,10 @@ func (s *leaderService) CreateLeaderTemplate(c *gin.Context, req *CreateLeaderRe
 		return fmt.Errorf("classroom_id is required")
 	}
 
+	if req.TermID == "" {
+		return fmt.Errorf("term_id is required")
+	}
+
 	objClassroomID, err := primitive.ObjectIDFromHex(req.ClassroomID)
 	if err != nil {
 		return err
@@ -105,30 +256,458 @@ func (s *leaderService) CreateLeaderTemplate(c *gin.Context, req *CreateLeaderRe
 		return err
 	}
 
+	if _, err := s.classroomOrganization(ctx, objClassroomID); err != nil {
+		return err
+	}
+
+	sessionID, err := s.resolveSession(ctx, objClassroomID, req.SessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.validateOwner(ctx, &req.Owner); err != nil {
+		return err
+	}
+
+	if err := CheckOwnerInTerm(ctx, s.LeaderRepository, s.SessionRepository, objClassroomID, objTermID, req.Owner.OwnerID, sessionID); err != nil {
+		return err
+	}
+
 	data := &LeaderTemplate{
 		ID:          primitive.NewObjectID(),
 		Owner:       &req.Owner,
 		ClassRoomID: objClassroomID,
+		SessionID:   sessionID,
 		TermID:      objTermID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	return s.LeaderRepository.CreateLeaderTemplate(c, data)
+	return s.LeaderRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		if err := s.LeaderRepository.CreateLeaderTemplate(ctx, data); err != nil {
+			return err
+		}
+
+		return event.Record(ctx, s.EventPublisher, event.New(event.LeaderTemplateAssigned, "leader_template", data.ID.Hex(), map[string]interface{}{
+			"class_room_id": data.ClassRoomID.Hex(),
+			"term_id":       data.TermID.Hex(),
+			"session_id":    sessionHex(data.SessionID),
+			"owner_id":      data.Owner.OwnerID,
+			"owner_role":    data.Owner.OwnerRole,
+		}))
+
+	})
 
 }
 
-func (s *leaderService) DeleteLeaderTemplate(c *gin.Context, req *DeleteLeaderRequest) error {
+func (s *leaderService) DeleteLeaderTemplate(ctx context.Context, req *DeleteLeaderRequest) error {
 
 	if req.ClassroomID == "" {
 		return fmt.Errorf("classroom_id is required")
 	}
 
+	if req.TermID == "" {
+		return fmt.Errorf("term_id is required")
+	}
+
+	objClassroomID, err := primitive.ObjectIDFromHex(req.ClassroomID)
+	if err != nil {
+		return err
+	}
+
+	objTermID, err := primitive.ObjectIDFromHex(req.TermID)
+	if err != nil {
+		return err
+	}
+
+	sessionID, err := s.resolveSession(ctx, objClassroomID, req.SessionID)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.LeaderRepository.GetLeaderTemplateBySession(ctx, objClassroomID, objTermID, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return ErrLeaderTemplateNotFound
+	}
+
+	return s.LeaderRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		if err := s.LeaderRepository.DeleteLeaderTemplate(ctx, objClassroomID, objTermID, sessionID); err != nil {
+			return err
+		}
+
+		return event.Record(ctx, s.EventPublisher, event.New(event.LeaderTemplateRemoved, "leader_template", existing.ID.Hex(), map[string]interface{}{
+			"class_room_id": objClassroomID.Hex(),
+			"term_id":       objTermID.Hex(),
+			"session_id":    sessionHex(sessionID),
+		}))
+
+	})
+
+}
+
+func (s *leaderService) CreateLeaderRota(ctx context.Context, req *CreateLeaderRotaRequest) error {
+
+	if req.ClassroomID == "" {
+		return errors.New("classroom_id is required")
+	}
+
+	if req.TermID == "" {
+		return errors.New("term_id is required")
+	}
+
+	if len(req.Owners) == 0 {
+		return errors.New("owners are required")
+	}
+
+	objClassroomID, err := primitive.ObjectIDFromHex(req.ClassroomID)
+	if err != nil {
+		return err
+	}
+
+	objTermID, err := primitive.ObjectIDFromHex(req.TermID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.classroomOrganization(ctx, objClassroomID); err != nil {
+		return err
+	}
+
+	owners := make([]*Owner, 0, len(req.Owners))
+	for i := range req.Owners {
+		if err := s.validateOwner(ctx, &req.Owners[i]); err != nil {
+			return fmt.Errorf("owner at position %d: %w", i, err)
+		}
+		owners = append(owners, &req.Owners[i])
+	}
+
+	period := req.RotationPeriod
+	if period == "" {
+		period = RotationPeriodWeekly
+	}
+
+	if period != RotationPeriodDaily && period != RotationPeriodWeekly {
+		return fmt.Errorf("rotation_period must be %s or %s", RotationPeriodDaily, RotationPeriodWeekly)
+	}
+
+	every := req.RotationEvery
+	if every <= 0 {
+		every = 1
+	}
+
+	weekdays := make([]time.Weekday, 0, len(req.Weekdays))
+	for _, w := range req.Weekdays {
+		if w < int(time.Sunday) || w > int(time.Saturday) {
+			return fmt.Errorf("invalid weekday %d, must be between 0 (sunday) and 6 (saturday)", w)
+		}
+		weekdays = append(weekdays, time.Weekday(w))
+	}
+
+	termInfor, err := s.TermService.GetTermByID(ctx, req.TermID)
+	if err != nil {
+		return err
+	}
+
+	startDate, err := time.Parse("2006-01-02", termInfor.StartDate)
+	if err != nil {
+		return err
+	}
+
+	if req.StartDate != "" {
+		startDate, err = time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	endDate, err := time.Parse("2006-01-02", termInfor.EndDate)
+	if err != nil {
+		return err
+	}
+
+	data := &LeaderRota{
+		ID:             primitive.NewObjectID(),
+		TermID:         objTermID,
+		ClassRoomID:    objClassroomID,
+		Owners:         owners,
+		RotationPeriod: period,
+		RotationEvery:  every,
+		Weekdays:       weekdays,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := checkOwnerRota(ctx, s.LeaderRepository, s.SessionRepository, data); err != nil {
+		return err
+	}
+
+	ownerIDs := make([]string, 0, len(owners))
+	for _, o := range owners {
+		ownerIDs = append(ownerIDs, o.OwnerID)
+	}
+
+	return s.LeaderRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		if err := s.LeaderRepository.CreateLeaderRota(ctx, data); err != nil {
+			return err
+		}
+
+		return event.Record(ctx, s.EventPublisher, event.New(event.LeaderRotaChanged, "leader_rota", data.ID.Hex(), map[string]interface{}{
+			"class_room_id":   data.ClassRoomID.Hex(),
+			"term_id":         data.TermID.Hex(),
+			"owner_ids":       ownerIDs,
+			"rotation_period": data.RotationPeriod,
+			"rotation_every":  data.RotationEvery,
+			"start_date":      data.StartDate.Format("2006-01-02"),
+		}))
+
+	})
+
+}
+
+func (s *leaderService) DeleteLeaderRota(ctx context.Context, req *DeleteLeaderRotaRequest) error {
+
+	if req.ClassroomID == "" {
+		return errors.New("classroom_id is required")
+	}
+
+	if req.TermID == "" {
+		return errors.New("term_id is required")
+	}
+
 	objClassroomID, err := primitive.ObjectIDFromHex(req.ClassroomID)
 	if err != nil {
 		return err
 	}
 
-	return s.LeaderRepository.DeleteLeaderTemplate(c, objClassroomID)
+	objTermID, err := primitive.ObjectIDFromHex(req.TermID)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.LeaderRepository.GetLeaderRotaByClassID(ctx, objClassroomID, objTermID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return ErrLeaderRotaNotFound
+	}
+
+	return s.LeaderRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		if err := s.LeaderRepository.DeleteLeaderRota(ctx, objClassroomID, objTermID); err != nil {
+			return err
+		}
+
+		return event.Record(ctx, s.EventPublisher, event.New(event.LeaderRotaRemoved, "leader_rota", existing.ID.Hex(), map[string]interface{}{
+			"class_room_id": objClassroomID.Hex(),
+			"term_id":       objTermID.Hex(),
+		}))
+
+	})
+
+}
+
+func (s *leaderService) PreviewLeaderRota(ctx context.Context, classroomID, termID string) ([]*LeaderRotaDayResponse, error) {
+
+	objClassroomID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	objTermID, err := primitive.ObjectIDFromHex(termID)
+	if err != nil {
+		return nil, err
+	}
+
+	rota, err := s.LeaderRepository.GetLeaderRotaByClassID(ctx, objClassroomID, objTermID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rota == nil {
+		return nil, ErrLeaderRotaNotFound
+	}
 
-}
\ No newline at end of file
+	termInfor, err := s.TermService.GetTermByID(ctx, termID)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := time.Parse("2006-01-02", termInfor.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := time.Parse("2006-01-02", termInfor.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make([]*LeaderRotaDayResponse, 0)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		owner := rota.OwnerForDate(d)
+		if owner == nil {
+			continue
+		}
+		days = append(days, &LeaderRotaDayResponse{
+			Date:  d.Format("2006-01-02"),
+			Owner: owner,
+		})
+	}
+
+	return days, nil
+
+}
+
+func parseDateRange(start, end string) (*time.Time, *time.Time, error) {
+
+	if start == "" {
+		return nil, nil, errors.New("start date is required")
+	}
+
+	if end == "" {
+		return nil, nil, errors.New("end date is required")
+	}
+
+	startParse, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endParse, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if endParse.Before(startParse) {
+		return nil, nil, errors.New("end date must not be before start date")
+	}
+
+	return &startParse, &endParse, nil
+
+}
+
+// buildLeaderResponses resolves the owner of each leader through the user
+// gateway, looking every person up only once.
+func (s *leaderService) buildLeaderResponses(ctx context.Context, leaders []*Leader) []*LeaderResponse {
+
+	resolved := make(map[string]*user.UserInfor)
+	responses := make([]*LeaderResponse, 0, len(leaders))
+
+	for _, l := range leaders {
+		if l.Owner == nil {
+			continue
+		}
+
+		key := string(l.Owner.OwnerRole) + ":" + l.Owner.OwnerID
+		infor, ok := resolved[key]
+		if !ok {
+			var err error
+			switch l.Owner.OwnerRole {
+			case OwnerRoleTeacher:
+				infor, err = s.UserService.GetTeacherInfor(ctx, l.Owner.OwnerID)
+			case OwnerRoleStaff:
+				infor, err = s.UserService.GetStaffInfor(ctx, l.Owner.OwnerID)
+			}
+			if err != nil || infor == nil {
+				infor = &user.UserInfor{
+					UserID:   l.Owner.OwnerID,
+					UserName: "Deleted",
+				}
+			}
+			resolved[key] = infor
+		}
+
+		responses = append(responses, &LeaderResponse{
+			ID:          l.ID.Hex(),
+			ClassRoomID: l.ClassRoomID.Hex(),
+			Date:        l.Date.Format("2006-01-02"),
+			SessionID:   sessionHex(l.SessionID),
+			Owner:       l.Owner,
+			User:        infor,
+		})
+	}
+
+	return responses
+
+}
+
+func (s *leaderService) GetLeadersByClassroom(ctx context.Context, classroomID, start, end string) ([]*LeaderResponse, error) {
+
+	objClassroomID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	startParse, endParse, err := parseDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.classroomOrganization(ctx, objClassroomID); err != nil {
+		return nil, err
+	}
+
+	leaders, err := s.LeaderRepository.GetLeadersByClassroomAndRange(ctx, objClassroomID, startParse, endParse)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildLeaderResponses(ctx, leaders), nil
+
+}
+
+func (s *leaderService) GetLeadersByOwner(ctx context.Context, ownerID, start, end string) ([]*LeaderResponse, error) {
+
+	if ownerID == "" {
+		return nil, errors.New("owner_id is required")
+	}
+
+	startParse, endParse, err := parseDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	leaders, err := s.LeaderRepository.GetLeadersByOwnerAndRange(ctx, ownerID, startParse, endParse)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildLeaderResponses(ctx, leaders), nil
+
+}
+
+func (s *leaderService) GetLeadersByRegion(ctx context.Context, regionID, date string) ([]*LeaderResponse, error) {
+
+	objRegionID, err := primitive.ObjectIDFromHex(regionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if date == "" {
+		return nil, errors.New("date is required")
+	}
+
+	dateParse, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
+
+	leaders, err := s.LeaderRepository.GetLeadersByRegionAndDate(ctx, objRegionID, &dateParse)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildLeaderResponses(ctx, leaders), nil
+
+}
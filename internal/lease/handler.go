@@ -0,0 +1,31 @@
+package lease
+
+import (
+	"classroom-service/helper"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaseHandler struct {
+	LeaseService LeaseService
+}
+
+func NewLeaseHandler(leaseService LeaseService) *LeaseHandler {
+	return &LeaseHandler{
+		LeaseService: leaseService,
+	}
+}
+
+func (h *LeaseHandler) GetLeases(c *gin.Context) {
+
+	leases, err := h.LeaseService.GetLeases(c)
+
+	if err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Leases Successfully", leases)
+
+}
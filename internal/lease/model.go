@@ -0,0 +1,22 @@
+package lease
+
+import "time"
+
+// Lease is a named lock held by one replica at a time. Token is increased on
+// every acquisition; renewals, releases and run records are only accepted
+// with the current token.
+type Lease struct {
+	Name       string     `json:"name" bson:"_id"`
+	Holder     string     `json:"holder" bson:"holder"`
+	Token      int64      `json:"token" bson:"token"`
+	AcquiredAt time.Time  `json:"acquired_at" bson:"acquired_at"`
+	RenewedAt  time.Time  `json:"renewed_at" bson:"renewed_at"`
+	ExpiresAt  time.Time  `json:"expires_at" bson:"expires_at"`
+	LastRunKey *string    `json:"last_run_key" bson:"last_run_key"`
+	LastRunAt  *time.Time `json:"last_run_at" bson:"last_run_at"`
+}
+
+// IsHeld reports whether the lease is still valid at now.
+func (l *Lease) IsHeld(now time.Time) bool {
+	return l.ExpiresAt.After(now)
+}
@@ -0,0 +1,174 @@
+package lease
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LeaseRepository interface {
+	AcquireLease(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (*Lease, error)
+	RenewLease(ctx context.Context, name, holder string, token int64, now time.Time, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string, token int64, now time.Time) error
+	RecordRun(ctx context.Context, name, holder string, token int64, runKey string, now time.Time) (bool, error)
+	GetLease(ctx context.Context, name string) (*Lease, error)
+	GetLeases(ctx context.Context) ([]*Lease, error)
+}
+
+type leaseRepository struct {
+	leaseCollection *mongo.Collection
+}
+
+func NewLeaseRepository(leaseCollection *mongo.Collection) LeaseRepository {
+	return &leaseRepository{
+		leaseCollection: leaseCollection,
+	}
+}
+
+// AcquireLease takes the lease when it is free, expired or already held by
+// holder. It returns nil when another holder owns a valid lease.
+func (r *leaseRepository) AcquireLease(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (*Lease, error) {
+
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": now}},
+			bson.M{"holder": holder},
+		},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"holder":      holder,
+			"acquired_at": now,
+			"renewed_at":  now,
+			"expires_at":  now.Add(ttl),
+		},
+		"$inc": bson.M{"token": int64(1)},
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var lease Lease
+	err := r.leaseCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&lease)
+	if err != nil {
+		// The upsert collides with the existing _id when somebody else
+		// holds a lease that has not expired yet.
+		if mongo.IsDuplicateKeyError(err) || err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &lease, nil
+
+}
+
+func (r *leaseRepository) RenewLease(ctx context.Context, name, holder string, token int64, now time.Time, ttl time.Duration) (bool, error) {
+
+	filter := bson.M{
+		"_id":        name,
+		"holder":     holder,
+		"token":      token,
+		"expires_at": bson.M{"$gt": now},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"renewed_at": now,
+			"expires_at": now.Add(ttl),
+		},
+	}
+
+	result, err := r.leaseCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+
+}
+
+func (r *leaseRepository) ReleaseLease(ctx context.Context, name, holder string, token int64, now time.Time) error {
+
+	filter := bson.M{
+		"_id":    name,
+		"holder": holder,
+		"token":  token,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"expires_at": now,
+		},
+	}
+
+	_, err := r.leaseCollection.UpdateOne(ctx, filter, update)
+	return err
+
+}
+
+// RecordRun stores the key of a completed run, fenced by the lease token so
+// a holder that lost the lease cannot overwrite it.
+func (r *leaseRepository) RecordRun(ctx context.Context, name, holder string, token int64, runKey string, now time.Time) (bool, error) {
+
+	filter := bson.M{
+		"_id":    name,
+		"holder": holder,
+		"token":  token,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"last_run_key": runKey,
+			"last_run_at":  now,
+		},
+	}
+
+	result, err := r.leaseCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+
+}
+
+func (r *leaseRepository) GetLease(ctx context.Context, name string) (*Lease, error) {
+
+	var lease Lease
+	err := r.leaseCollection.FindOne(ctx, bson.M{"_id": name}).Decode(&lease)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &lease, nil
+
+}
+
+func (r *leaseRepository) GetLeases(ctx context.Context) ([]*Lease, error) {
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := r.leaseCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var leases []*Lease
+	if err := cursor.All(ctx, &leases); err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+
+}
@@ -0,0 +1,7 @@
+package lease
+
+type LeaseResponse struct {
+	*Lease
+	Held   bool `json:"held"`
+	IsMine bool `json:"is_mine"`
+}
@@ -0,0 +1,15 @@
+package lease
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *LeaseHandler) {
+	leaseGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Scheduler Leases
+		leaseGroup.GET("/leases", handler.GetLeases)
+	}
+}
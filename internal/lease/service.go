@@ -0,0 +1,174 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const DefaultTTL = 30 * time.Second
+
+var ErrLeaseLost = errors.New("lease lost")
+
+type LeaseService interface {
+	Acquire(ctx context.Context, name string) (*Lease, error)
+	Renew(ctx context.Context, lease *Lease) error
+	Release(ctx context.Context, lease *Lease) error
+	RunExclusive(ctx context.Context, name, runKey string, fn func(ctx context.Context) error) (bool, error)
+	GetLeases(ctx context.Context) ([]*LeaseResponse, error)
+}
+
+type leaseService struct {
+	LeaseRepository LeaseRepository
+	Holder          string
+	TTL             time.Duration
+}
+
+func NewLeaseService(leaseRepository LeaseRepository, ttl time.Duration) LeaseService {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &leaseService{
+		LeaseRepository: leaseRepository,
+		Holder:          DefaultHolder(),
+		TTL:             ttl,
+	}
+}
+
+// DefaultHolder identifies this process as hostname:pid.
+func DefaultHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", hostname, os.Getpid())
+}
+
+// Acquire returns the lease, or nil when another replica holds it.
+func (s *leaseService) Acquire(ctx context.Context, name string) (*Lease, error) {
+
+	if name == "" {
+		return nil, errors.New("lease name is required")
+	}
+
+	return s.LeaseRepository.AcquireLease(ctx, name, s.Holder, time.Now(), s.TTL)
+
+}
+
+func (s *leaseService) Renew(ctx context.Context, lease *Lease) error {
+
+	now := time.Now()
+	ok, err := s.LeaseRepository.RenewLease(ctx, lease.Name, lease.Holder, lease.Token, now, s.TTL)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrLeaseLost
+	}
+
+	lease.RenewedAt = now
+	lease.ExpiresAt = now.Add(s.TTL)
+	return nil
+
+}
+
+func (s *leaseService) Release(ctx context.Context, lease *Lease) error {
+	return s.LeaseRepository.ReleaseLease(ctx, lease.Name, lease.Holder, lease.Token, time.Now())
+}
+
+// RunExclusive runs fn while holding the named lease and keeps renewing it in
+// the background. It returns false without running fn when another replica
+// holds the lease or already completed the run identified by runKey. If the
+// lease is lost while fn runs, the context passed to fn is cancelled; writes
+// fn already has in flight are not fenced, so jobs must stay safe to run
+// twice.
+func (s *leaseService) RunExclusive(ctx context.Context, name, runKey string, fn func(ctx context.Context) error) (bool, error) {
+
+	lease, err := s.Acquire(ctx, name)
+	if err != nil {
+		return false, err
+	}
+
+	if lease == nil {
+		return false, nil
+	}
+
+	if runKey != "" && lease.LastRunKey != nil && *lease.LastRunKey == runKey {
+		if err := s.Release(ctx, lease); err != nil {
+			log.Printf("[ERROR] release lease %s failed: %v", name, err)
+		}
+		return false, nil
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(s.TTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Renew(runCtx, lease); err != nil {
+					log.Printf("[ERROR] renew lease %s (token=%d) failed: %v", name, lease.Token, err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	runErr := fn(runCtx)
+	close(done)
+	wg.Wait()
+
+	if runErr == nil && runKey != "" {
+		recorded, err := s.LeaseRepository.RecordRun(ctx, name, lease.Holder, lease.Token, runKey, time.Now())
+		if err != nil {
+			runErr = err
+		} else if !recorded {
+			runErr = ErrLeaseLost
+		}
+	}
+
+	if err := s.Release(ctx, lease); err != nil {
+		log.Printf("[ERROR] release lease %s failed: %v", name, err)
+	}
+
+	return true, runErr
+
+}
+
+func (s *leaseService) GetLeases(ctx context.Context) ([]*LeaseResponse, error) {
+
+	leases, err := s.LeaseRepository.GetLeases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	responses := make([]*LeaseResponse, 0, len(leases))
+	for _, l := range leases {
+		responses = append(responses, &LeaseResponse{
+			Lease:  l,
+			Held:   l.IsHeld(now),
+			IsMine: l.Holder == s.Holder,
+		})
+	}
+
+	return responses, nil
+
+}
@@ -0,0 +1,30 @@
+package notification
+
+import "time"
+
+const (
+	TypeTeacherDigest = "classroom_teacher_digest"
+	TypeLeaderDigest  = "classroom_leader_digest"
+	TypeCoverageAlert = "classroom_coverage_alert"
+)
+
+const (
+	RecipientRoleTeacher = "teacher"
+	RecipientRoleStaff   = "staff"
+	RecipientRoleAdmin   = "admin"
+)
+
+type Message struct {
+	RecipientID    string                 `json:"recipient_id"`
+	RecipientRole  string                 `json:"recipient_role"`
+	OrganizationID string                 `json:"organization_id,omitempty"`
+	Type           string                 `json:"type"`
+	Title          string                 `json:"title"`
+	Body           string                 `json:"body"`
+	Data           map[string]interface{} `json:"data,omitempty"`
+	ScheduledFor   time.Time              `json:"scheduled_for"`
+}
+
+type SendNotificationsRequest struct {
+	Messages []*Message `json:"messages"`
+}
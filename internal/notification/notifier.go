@@ -0,0 +1,176 @@
+package notification
+
+import (
+	"classroom-service/pkg/constants"
+	"classroom-service/pkg/consul"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// Notifier delivers messages to people. Implementations must deliver the
+// whole batch or return an error so the caller can retry later.
+type Notifier interface {
+	Send(ctx context.Context, messages []*Message) error
+}
+
+type notificationGateway struct {
+	client *callAPI
+}
+
+type callAPI struct {
+	client       consul.ServiceDiscovery
+	clientServer *api.CatalogService
+}
+
+var (
+	mainService = "go-main-service"
+)
+
+func NewNotificationGateway(client *api.Client) Notifier {
+	mainServiceAPI := NewServiceAPI(client, mainService)
+	return &notificationGateway{
+		client: mainServiceAPI,
+	}
+}
+
+func NewServiceAPI(client *api.Client, serviceName string) *callAPI {
+
+	sd, err := consul.NewServiceDiscovery(client, serviceName)
+	if err != nil {
+		fmt.Printf("Error creating service discovery: %v\n", err)
+		return nil
+	}
+
+	var service *api.CatalogService
+
+	for i := 0; i < 10; i++ {
+		service, err = sd.DiscoverService()
+		if err == nil && service != nil {
+			break
+		}
+		fmt.Printf("Waiting for service %s... retry %d/10\n", serviceName, i+1)
+		time.Sleep(3 * time.Second)
+	}
+
+	if service == nil {
+		fmt.Printf("Service %s not found after retries, continuing anyway...\n", serviceName)
+	}
+
+	return &callAPI{
+		client:       sd,
+		clientServer: service,
+	}
+}
+
+func (g *notificationGateway) Send(ctx context.Context, messages []*Message) error {
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	token, ok := ctx.Value(constants.TokenKey).(string)
+
+	if !ok {
+		return fmt.Errorf("token not found in context")
+	}
+
+	return g.client.sendNotifications(token, SendNotificationsRequest{Messages: messages})
+
+}
+
+func (c *callAPI) sendNotifications(token string, req SendNotificationsRequest) error {
+
+	endpoint := "/v1/gateway/notifications"
+
+	header := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + token,
+	}
+
+	jsonReq, err := json.Marshal(req)
+	if err != nil {
+		fmt.Printf("Error marshalling request: %v\n", err)
+		return err
+	}
+
+	_, err = c.client.CallAPI(c.clientServer, endpoint, http.MethodPost, jsonReq, header)
+	if err != nil {
+		fmt.Printf("Error calling API: %v\n", err)
+		return err
+	}
+
+	return nil
+
+}
+
+// MemoryNotifier keeps messages in memory, for local runs and tests.
+type MemoryNotifier struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Send(ctx context.Context, messages []*Message) error {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.messages = append(n.messages, messages...)
+	return nil
+
+}
+
+// Messages returns a copy of everything sent so far.
+func (n *MemoryNotifier) Messages() []*Message {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	messages := make([]*Message, len(n.messages))
+	copy(messages, n.messages)
+	return messages
+
+}
+
+// FileNotifier appends every message as one JSON line to a file, which is
+// handy when running the service locally without the notification gateway.
+type FileNotifier struct {
+	mu   sync.Mutex
+	Path string
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{Path: path}
+}
+
+func (n *FileNotifier) Send(ctx context.Context, messages []*Message) error {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for _, m := range messages {
+		if err := encoder.Encode(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,56 @@
+package planner
+
+import (
+	"classroom-service/internal/leader"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ProposalStatusDraft   = "draft"
+	ProposalStatusApplied = "applied"
+)
+
+const (
+	ConstraintPlacement       = "placement"
+	ConstraintSiblings        = "keep_siblings_together"
+	ConstraintPreviousTeacher = "keep_previous_teacher"
+	ConstraintTeacherCapacity = "max_students_per_teacher"
+	ConstraintTermUniqueness  = "one_class_per_student_per_term"
+)
+
+type TemplateProposal struct {
+	ID          primitive.ObjectID     `json:"id" bson:"_id"`
+	TermID      primitive.ObjectID     `json:"term_id" bson:"term_id"`
+	Status      string                 `json:"status" bson:"status"`
+	Assignments []*ProposedAssignment  `json:"assignments" bson:"assignments"`
+	Leaders     []*ProposedLeader      `json:"leaders" bson:"leaders"`
+	Score       float64                `json:"score" bson:"score"`
+	Violations  []*ConstraintViolation `json:"violations" bson:"violations"`
+	CreatedBy   string                 `json:"created_by" bson:"created_by"`
+	AppliedBy   *string                `json:"applied_by" bson:"applied_by"`
+	AppliedAt   *time.Time             `json:"applied_at" bson:"applied_at"`
+	CreatedAt   time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
+type ProposedAssignment struct {
+	ClassRoomID primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
+	SlotNumber  int                `json:"slot_number" bson:"slot_number"`
+	TeacherID   *string            `json:"teacher_id" bson:"teacher_id"`
+	StudentID   *string            `json:"student_id" bson:"student_id"`
+}
+
+type ProposedLeader struct {
+	ClassRoomID primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
+	Owner       *leader.Owner      `json:"owner" bson:"owner"`
+}
+
+type ConstraintViolation struct {
+	Constraint  string              `json:"constraint" bson:"constraint"`
+	ClassRoomID *primitive.ObjectID `json:"class_room_id,omitempty" bson:"class_room_id,omitempty"`
+	TeacherID   *string             `json:"teacher_id,omitempty" bson:"teacher_id,omitempty"`
+	StudentIDs  []string            `json:"student_ids,omitempty" bson:"student_ids,omitempty"`
+	Reason      string              `json:"reason" bson:"reason"`
+}
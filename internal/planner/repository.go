@@ -0,0 +1,52 @@
+package planner
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ProposalRepository interface {
+	CreateProposal(ctx context.Context, proposal *TemplateProposal) error
+	GetProposalByID(ctx context.Context, id primitive.ObjectID) (*TemplateProposal, error)
+	UpdateProposal(ctx context.Context, id primitive.ObjectID, proposal *TemplateProposal) error
+}
+
+type proposalRepository struct {
+	proposalCollection *mongo.Collection
+}
+
+func NewProposalRepository(proposalCollection *mongo.Collection) ProposalRepository {
+	return &proposalRepository{
+		proposalCollection: proposalCollection,
+	}
+}
+
+func (r *proposalRepository) CreateProposal(ctx context.Context, proposal *TemplateProposal) error {
+
+	_, err := r.proposalCollection.InsertOne(ctx, proposal)
+	return err
+
+}
+
+func (r *proposalRepository) GetProposalByID(ctx context.Context, id primitive.ObjectID) (*TemplateProposal, error) {
+
+	var proposal TemplateProposal
+	err := r.proposalCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&proposal)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &proposal, nil
+
+}
+
+func (r *proposalRepository) UpdateProposal(ctx context.Context, id primitive.ObjectID, proposal *TemplateProposal) error {
+	_, err := r.proposalCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": proposal})
+	return err
+}
@@ -0,0 +1,21 @@
+package planner
+
+type GenerateTemplateRequest struct {
+	TermID         string              `json:"term_id"`
+	PreviousTermID *string             `json:"previous_term_id"`
+	Classrooms     []ClassroomCapacity `json:"classrooms"`
+	TeacherIDs     []string            `json:"teacher_ids"`
+	StudentIDs     []string            `json:"student_ids"`
+	Constraints    GenerateConstraints `json:"constraints"`
+}
+
+type ClassroomCapacity struct {
+	ClassroomID string `json:"classroom_id"`
+	Capacity    int    `json:"capacity"`
+}
+
+type GenerateConstraints struct {
+	MaxStudentsPerTeacher int        `json:"max_students_per_teacher"`
+	SiblingGroups         [][]string `json:"sibling_groups"`
+	KeepPreviousTeacher   bool       `json:"keep_previous_teacher"`
+}
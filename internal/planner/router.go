@@ -0,0 +1,17 @@
+package planner
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *PlannerHandler) {
+	plannerGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Template Proposal
+		plannerGroup.POST("/template-proposals", handler.GenerateTemplate)
+		plannerGroup.GET("/template-proposals/:id", handler.GetProposal)
+		plannerGroup.POST("/template-proposals/:id/apply", handler.ApplyProposal)
+	}
+}
@@ -0,0 +1,647 @@
+package planner
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/classroom"
+	"classroom-service/internal/event"
+	"classroom-service/internal/leader"
+	"classroom-service/internal/session"
+	"classroom-service/internal/teacher"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxSlotNumber = 15
+
+type PlannerService interface {
+	GenerateTemplate(ctx context.Context, req *GenerateTemplateRequest, userID string) (*TemplateProposal, error)
+	GetProposal(ctx context.Context, id string) (*TemplateProposal, error)
+	ApplyProposal(ctx context.Context, id string, userID string) error
+}
+
+type plannerService struct {
+	ProposalRepository  ProposalRepository
+	ClassroomRepository classroom.ClassroomRepository
+	AssignRepository    assign.AssignRepository
+	LeaderRepository    leader.LeaderRepository
+	TeacherRepository   teacher.TeacherRepository
+	EventPublisher      event.Publisher
+	SessionRepository   session.SessionRepository
+}
+
+func NewPlannerService(proposalRepository ProposalRepository,
+	classroomRepository classroom.ClassroomRepository,
+	assignRepository assign.AssignRepository,
+	leaderRepository leader.LeaderRepository,
+	teacherRepository teacher.TeacherRepository,
+	publisher event.Publisher,
+	sessionRepository session.SessionRepository) PlannerService {
+	return &plannerService{
+		ProposalRepository:  proposalRepository,
+		ClassroomRepository: classroomRepository,
+		AssignRepository:    assignRepository,
+		LeaderRepository:    leaderRepository,
+		TeacherRepository:   teacherRepository,
+		EventPublisher:      publisher,
+		SessionRepository:   sessionRepository,
+	}
+}
+
+// classroomState tracks the free slots and the teachers placed in one
+// classroom while the solver runs.
+type classroomState struct {
+	id        primitive.ObjectID
+	freeSlots []int
+	teachers  []string
+	placed    int
+	// ineligible holds teachers that lack the classroom's qualifications.
+	ineligible map[string]bool
+}
+
+// solver is a greedy placement of students into classrooms. Students are
+// grouped into units (sibling groups or single students) and each unit is
+// placed in the classroom that best satisfies the soft constraints.
+type solver struct {
+	classrooms       []*classroomState
+	teacherOrder     []string
+	teacherAvailable map[string]bool
+	teacherClassroom map[string]*classroomState
+	teacherLoad      map[string]int
+	maxPerTeacher    int
+	previousTeacher  map[string]string
+
+	assignments []*ProposedAssignment
+	violations  []*ConstraintViolation
+	total       int
+	satisfied   int
+}
+
+func (s *plannerService) GenerateTemplate(ctx context.Context, req *GenerateTemplateRequest, userID string) (*TemplateProposal, error) {
+
+	if req.TermID == "" {
+		return nil, errors.New("term_id is required")
+	}
+
+	if len(req.Classrooms) == 0 {
+		return nil, errors.New("classrooms are required")
+	}
+
+	if len(req.StudentIDs) == 0 {
+		return nil, errors.New("student_ids are required")
+	}
+
+	termObjID, err := primitive.ObjectIDFromHex(req.TermID)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPerTeacher := req.Constraints.MaxStudentsPerTeacher
+	if maxPerTeacher <= 0 {
+		maxPerTeacher = math.MaxInt
+	}
+
+	sv := &solver{
+		teacherAvailable: make(map[string]bool),
+		teacherClassroom: make(map[string]*classroomState),
+		teacherLoad:      make(map[string]int),
+		maxPerTeacher:    maxPerTeacher,
+		previousTeacher:  make(map[string]string),
+	}
+
+	for _, c := range req.Classrooms {
+		classroomObjID, err := primitive.ObjectIDFromHex(c.ClassroomID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid classroom id %s: %v", c.ClassroomID, err)
+		}
+
+		if _, err := s.ClassroomRepository.GetClassroomByID(ctx, classroomObjID); err != nil {
+			return nil, fmt.Errorf("classroom %s not found", c.ClassroomID)
+		}
+
+		capacity := c.Capacity
+		if capacity <= 0 || capacity > maxSlotNumber {
+			capacity = maxSlotNumber
+		}
+
+		existing, err := s.AssignRepository.GetAssignmentTemplateByClassroomID(ctx, classroomObjID, termObjID)
+		if err != nil {
+			return nil, err
+		}
+
+		occupied := make(map[int]bool)
+		for _, a := range existing {
+			occupied[a.SlotNumber] = true
+		}
+
+		state := &classroomState{id: classroomObjID, ineligible: make(map[string]bool)}
+		for slot := 1; slot <= capacity; slot++ {
+			if !occupied[slot] {
+				state.freeSlots = append(state.freeSlots, slot)
+			}
+		}
+
+		sv.classrooms = append(sv.classrooms, state)
+	}
+
+	for _, teacherID := range req.TeacherIDs {
+		if teacherID == "" || sv.teacherAvailable[teacherID] {
+			continue
+		}
+		sv.teacherAvailable[teacherID] = true
+		sv.teacherOrder = append(sv.teacherOrder, teacherID)
+	}
+
+	profiles, err := s.TeacherRepository.GetTeacherProfilesByTeacherIDs(ctx, sv.teacherOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	// A template spans the term, so only qualifications count here.
+	// Teachers without a profile are not constrained.
+	profileByTeacher := make(map[string]*teacher.TeacherProfile, len(profiles))
+	for _, p := range profiles {
+		profileByTeacher[p.TeacherID] = p
+	}
+
+	for _, c := range sv.classrooms {
+		requirements, err := s.TeacherRepository.GetClassroomRequirements(ctx, c.id)
+		if err != nil {
+			return nil, err
+		}
+		for _, teacherID := range sv.teacherOrder {
+			if len(teacher.CheckEligibility(profileByTeacher[teacherID], requirements, nil)) > 0 {
+				c.ineligible[teacherID] = true
+			}
+		}
+	}
+
+	if req.Constraints.KeepPreviousTeacher && req.PreviousTermID != nil && *req.PreviousTermID != "" {
+		previousTermObjID, err := primitive.ObjectIDFromHex(*req.PreviousTermID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous term id: %v", err)
+		}
+
+		previous, err := s.AssignRepository.GetAssignmentTemplateByTermID(ctx, previousTermObjID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range previous {
+			if a.StudentID != nil && a.TeacherID != nil && *a.TeacherID != "" {
+				sv.previousTeacher[*a.StudentID] = *a.TeacherID
+			}
+		}
+	}
+
+	eligible := make(map[string]bool)
+	var students []string
+	for _, studentID := range req.StudentIDs {
+		if studentID == "" || eligible[studentID] {
+			continue
+		}
+
+		exists, err := s.AssignRepository.CheckStudentExistingInTerm(ctx, termObjID, studentID)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			sv.violations = append(sv.violations, &ConstraintViolation{
+				Constraint: ConstraintTermUniqueness,
+				StudentIDs: []string{studentID},
+				Reason:     "student is already assigned to a class in this term",
+			})
+			continue
+		}
+
+		eligible[studentID] = true
+		students = append(students, studentID)
+	}
+
+	for _, unit := range buildUnits(students, req.Constraints.SiblingGroups) {
+		sv.placeUnit(unit)
+	}
+
+	leaders, err := s.proposeLeaders(ctx, sv, termObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	score := 100.0
+	if sv.total > 0 {
+		score = math.Round(float64(sv.satisfied)/float64(sv.total)*10000) / 100
+	}
+
+	proposal := &TemplateProposal{
+		ID:          primitive.NewObjectID(),
+		TermID:      termObjID,
+		Status:      ProposalStatusDraft,
+		Assignments: sv.assignments,
+		Leaders:     leaders,
+		Score:       score,
+		Violations:  sv.violations,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if proposal.Assignments == nil {
+		proposal.Assignments = []*ProposedAssignment{}
+	}
+
+	if proposal.Violations == nil {
+		proposal.Violations = []*ConstraintViolation{}
+	}
+
+	if err := s.ProposalRepository.CreateProposal(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+
+}
+
+func (s *plannerService) proposeLeaders(ctx context.Context, sv *solver, termID primitive.ObjectID) ([]*ProposedLeader, error) {
+
+	leaders := make([]*ProposedLeader, 0)
+
+	for _, c := range sv.classrooms {
+		if c.placed == 0 || len(c.teachers) == 0 {
+			continue
+		}
+
+		existing, err := s.LeaderRepository.GetLeaderTemplateByClassID(ctx, c.id, termID)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing != nil {
+			continue
+		}
+
+		best := c.teachers[0]
+		for _, teacherID := range c.teachers[1:] {
+			if sv.teacherLoad[teacherID] > sv.teacherLoad[best] {
+				best = teacherID
+			}
+		}
+
+		leaders = append(leaders, &ProposedLeader{
+			ClassRoomID: c.id,
+			Owner: &leader.Owner{
+				OwnerID:   best,
+				OwnerRole: leader.OwnerRoleTeacher,
+			},
+		})
+	}
+
+	return leaders, nil
+
+}
+
+// buildUnits groups students so that siblings are placed together. Sibling
+// groups are only kept for students that are part of the request.
+func buildUnits(students []string, siblingGroups [][]string) [][]string {
+
+	requested := make(map[string]bool)
+	for _, studentID := range students {
+		requested[studentID] = true
+	}
+
+	grouped := make(map[string]bool)
+	var units [][]string
+
+	for _, group := range siblingGroups {
+		var unit []string
+		for _, studentID := range group {
+			if requested[studentID] && !grouped[studentID] {
+				grouped[studentID] = true
+				unit = append(unit, studentID)
+			}
+		}
+		if len(unit) > 0 {
+			units = append(units, unit)
+		}
+	}
+
+	for _, studentID := range students {
+		if !grouped[studentID] {
+			units = append(units, []string{studentID})
+		}
+	}
+
+	sort.SliceStable(units, func(i, j int) bool {
+		return len(units[i]) > len(units[j])
+	})
+
+	return units
+
+}
+
+func (sv *solver) placeUnit(unit []string) {
+
+	if len(unit) > 1 {
+		sv.total++
+	}
+
+	target := sv.chooseClassroom(unit)
+
+	if target == nil {
+		if len(unit) > 1 {
+			sv.violations = append(sv.violations, &ConstraintViolation{
+				Constraint: ConstraintSiblings,
+				StudentIDs: unit,
+				Reason:     "no classroom has enough free slots to keep the siblings together",
+			})
+			for _, studentID := range unit {
+				sv.placeUnit([]string{studentID})
+			}
+			return
+		}
+
+		sv.total++
+		sv.violations = append(sv.violations, &ConstraintViolation{
+			Constraint: ConstraintPlacement,
+			StudentIDs: unit,
+			Reason:     "no classroom has a free slot",
+		})
+		return
+	}
+
+	if len(unit) > 1 {
+		sv.satisfied++
+	}
+
+	for _, studentID := range unit {
+		sv.placeStudent(target, studentID)
+	}
+
+}
+
+func (sv *solver) chooseClassroom(unit []string) *classroomState {
+
+	var best *classroomState
+	bestScore := -1
+
+	for _, c := range sv.classrooms {
+		if len(c.freeSlots) < len(unit) {
+			continue
+		}
+
+		score := 0
+		for _, studentID := range unit {
+			teacherID, ok := sv.previousTeacher[studentID]
+			if !ok || !sv.teacherAvailable[teacherID] || c.ineligible[teacherID] {
+				continue
+			}
+			if placedIn, ok := sv.teacherClassroom[teacherID]; ok {
+				if placedIn == c {
+					score += 2
+				}
+			} else {
+				score++
+			}
+		}
+
+		if best == nil || score > bestScore || (score == bestScore && len(c.freeSlots) > len(best.freeSlots)) {
+			best = c
+			bestScore = score
+		}
+	}
+
+	return best
+
+}
+
+func (sv *solver) placeStudent(c *classroomState, studentID string) {
+
+	sv.total++
+	sv.satisfied++
+
+	slot := c.freeSlots[0]
+	c.freeSlots = c.freeSlots[1:]
+	c.placed++
+
+	studentIDCopy := studentID
+	assignment := &ProposedAssignment{
+		ClassRoomID: c.id,
+		SlotNumber:  slot,
+		StudentID:   &studentIDCopy,
+	}
+
+	if teacherID := sv.pickTeacher(c, studentID); teacherID != "" {
+		teacherIDCopy := teacherID
+		assignment.TeacherID = &teacherIDCopy
+		sv.teacherLoad[teacherID]++
+	}
+
+	sv.assignments = append(sv.assignments, assignment)
+
+}
+
+func (sv *solver) pickTeacher(c *classroomState, studentID string) string {
+
+	if previous, ok := sv.previousTeacher[studentID]; ok {
+		sv.total++
+
+		var reason string
+		placedIn, placed := sv.teacherClassroom[previous]
+
+		switch {
+		case !sv.teacherAvailable[previous]:
+			reason = "previous teacher is not in the list of available teachers"
+		case c.ineligible[previous]:
+			reason = "previous teacher is not qualified for this classroom"
+		case placed && placedIn != c:
+			reason = "previous teacher is assigned to another classroom"
+		case sv.teacherLoad[previous] >= sv.maxPerTeacher:
+			reason = "previous teacher has reached the maximum number of students"
+		}
+
+		if reason == "" {
+			sv.satisfied++
+			if !placed {
+				sv.assignTeacher(c, previous)
+			}
+			return previous
+		}
+
+		classroomID := c.id
+		teacherID := previous
+		sv.violations = append(sv.violations, &ConstraintViolation{
+			Constraint:  ConstraintPreviousTeacher,
+			ClassRoomID: &classroomID,
+			TeacherID:   &teacherID,
+			StudentIDs:  []string{studentID},
+			Reason:      reason,
+		})
+	}
+
+	best := ""
+	for _, teacherID := range c.teachers {
+		if sv.teacherLoad[teacherID] >= sv.maxPerTeacher {
+			continue
+		}
+		if best == "" || sv.teacherLoad[teacherID] < sv.teacherLoad[best] {
+			best = teacherID
+		}
+	}
+
+	if best != "" {
+		return best
+	}
+
+	for _, teacherID := range sv.teacherOrder {
+		if _, placed := sv.teacherClassroom[teacherID]; placed || c.ineligible[teacherID] {
+			continue
+		}
+		sv.assignTeacher(c, teacherID)
+		return teacherID
+	}
+
+	sv.total++
+	classroomID := c.id
+	sv.violations = append(sv.violations, &ConstraintViolation{
+		Constraint:  ConstraintTeacherCapacity,
+		ClassRoomID: &classroomID,
+		StudentIDs:  []string{studentID},
+		Reason:      "no available qualified teacher has remaining capacity, slot is left without a teacher",
+	})
+
+	return ""
+
+}
+
+func (sv *solver) assignTeacher(c *classroomState, teacherID string) {
+	sv.teacherClassroom[teacherID] = c
+	c.teachers = append(c.teachers, teacherID)
+}
+
+func (s *plannerService) GetProposal(ctx context.Context, id string) (*TemplateProposal, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	proposal, err := s.ProposalRepository.GetProposalByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal == nil {
+		return nil, errors.New("proposal not found")
+	}
+
+	return proposal, nil
+
+}
+
+func (s *plannerService) ApplyProposal(ctx context.Context, id string, userID string) error {
+
+	proposal, err := s.GetProposal(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if proposal.Status != ProposalStatusDraft {
+		return errors.New("proposal has already been applied")
+	}
+
+	// Everything is written in one transaction so a failure does not leave
+	// the term half applied. The slots are checked again inside it.
+	return s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		// A concurrent apply of the same proposal makes this one fail.
+		current, err := s.ProposalRepository.GetProposalByID(ctx, proposal.ID)
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Status != ProposalStatusDraft {
+			return errors.New("proposal has already been applied")
+		}
+
+		// Proposals plan whole days, so they only compete with templates that
+		// have no session.
+		for _, a := range proposal.Assignments {
+			existing, err := s.AssignRepository.GetAssignmentTemplateBySlot(ctx, a.ClassRoomID, proposal.TermID, a.SlotNumber, nil)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return fmt.Errorf("slot %d in classroom %s is no longer free", a.SlotNumber, a.ClassRoomID.Hex())
+			}
+
+			if a.StudentID != nil {
+				exists, err := s.AssignRepository.CheckStudentExistingInTerm(ctx, proposal.TermID, *a.StudentID)
+				if err != nil {
+					return err
+				}
+				if exists {
+					return fmt.Errorf("student %s already assigned to another class in this term", *a.StudentID)
+				}
+			}
+		}
+
+		for _, a := range proposal.Assignments {
+			err := s.AssignRepository.CreateAssignmentTemplate(ctx, &assign.ClassRoomTemplateAssignment{
+				ID:          primitive.NewObjectID(),
+				ClassRoomID: a.ClassRoomID,
+				TermID:      proposal.TermID,
+				SlotNumber:  a.SlotNumber,
+				TeacherID:   a.TeacherID,
+				StudentID:   a.StudentID,
+				CreatedBy:   userID,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, l := range proposal.Leaders {
+			if err := leader.CheckOwnerInTerm(ctx, s.LeaderRepository, s.SessionRepository, l.ClassRoomID, proposal.TermID, l.Owner.OwnerID, nil); err != nil {
+				return err
+			}
+
+			err := s.LeaderRepository.CreateLeaderTemplate(ctx, &leader.LeaderTemplate{
+				ID:          primitive.NewObjectID(),
+				Owner:       l.Owner,
+				TermID:      proposal.TermID,
+				ClassRoomID: l.ClassRoomID,
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		proposal.Status = ProposalStatusApplied
+		proposal.AppliedBy = &userID
+		proposal.AppliedAt = &now
+		proposal.UpdatedAt = now
+
+		if err := s.ProposalRepository.UpdateProposal(ctx, proposal.ID, proposal); err != nil {
+			return err
+		}
+
+		// The template history snapshots the term as part of this
+		// transaction, after every template above has been written.
+		return event.Record(ctx, s.EventPublisher, event.New(event.TemplateProposalApplied, "template_proposal", proposal.ID.Hex(), map[string]interface{}{
+			"term_id":     proposal.TermID.Hex(),
+			"assignments": len(proposal.Assignments),
+			"leaders":     len(proposal.Leaders),
+		}).WithActor(userID))
+
+	})
+
+}
@@ -0,0 +1,195 @@
+package planner
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newSolver builds a solver over classrooms with the given number of free
+// slots each, as GenerateTemplate does after loading the term.
+func newSolver(teachers []string, maxPerTeacher int, capacities ...int) *solver {
+
+	if maxPerTeacher <= 0 {
+		maxPerTeacher = math.MaxInt
+	}
+
+	sv := &solver{
+		teacherAvailable: make(map[string]bool),
+		teacherClassroom: make(map[string]*classroomState),
+		teacherLoad:      make(map[string]int),
+		maxPerTeacher:    maxPerTeacher,
+		previousTeacher:  make(map[string]string),
+	}
+
+	for _, capacity := range capacities {
+		state := &classroomState{id: primitive.NewObjectID(), ineligible: make(map[string]bool)}
+		for slot := 1; slot <= capacity; slot++ {
+			state.freeSlots = append(state.freeSlots, slot)
+		}
+		sv.classrooms = append(sv.classrooms, state)
+	}
+
+	for _, teacherID := range teachers {
+		sv.teacherAvailable[teacherID] = true
+		sv.teacherOrder = append(sv.teacherOrder, teacherID)
+	}
+
+	return sv
+
+}
+
+func (sv *solver) run(students []string, siblingGroups [][]string) {
+	for _, unit := range buildUnits(students, siblingGroups) {
+		sv.placeUnit(unit)
+	}
+}
+
+func (sv *solver) placement(studentID string) *ProposedAssignment {
+	for _, a := range sv.assignments {
+		if *a.StudentID == studentID {
+			return a
+		}
+	}
+	return nil
+}
+
+func (sv *solver) violated(constraint string) []*ConstraintViolation {
+	var found []*ConstraintViolation
+	for _, v := range sv.violations {
+		if v.Constraint == constraint {
+			found = append(found, v)
+		}
+	}
+	return found
+}
+
+func TestBuildUnits(t *testing.T) {
+
+	units := buildUnits(
+		[]string{"a", "b", "c", "d", "e"},
+		[][]string{{"b", "x"}, {"c", "d", "e"}, {"d", "a"}},
+	)
+
+	want := [][]string{{"c", "d", "e"}, {"b"}, {"a"}}
+	if !reflect.DeepEqual(units, want) {
+		t.Fatalf("buildUnits() = %v, want %v", units, want)
+	}
+
+}
+
+func TestSolverKeepsSiblingsTogether(t *testing.T) {
+
+	sv := newSolver([]string{"t1", "t2"}, 0, 2, 3)
+	sv.run([]string{"s1", "s2", "s3", "s4"}, [][]string{{"s1", "s2", "s3"}})
+
+	small, large := sv.classrooms[0], sv.classrooms[1]
+	for _, studentID := range []string{"s1", "s2", "s3"} {
+		if a := sv.placement(studentID); a == nil || a.ClassRoomID != large.id {
+			t.Errorf("sibling %s not placed in the classroom with room for all siblings", studentID)
+		}
+	}
+	if a := sv.placement("s4"); a == nil || a.ClassRoomID != small.id {
+		t.Errorf("s4 not placed in the remaining classroom")
+	}
+
+	if len(sv.violations) != 0 {
+		t.Errorf("unexpected violations %+v", sv.violations)
+	}
+
+	slots := make(map[primitive.ObjectID]map[int]bool)
+	for _, a := range sv.assignments {
+		if slots[a.ClassRoomID] == nil {
+			slots[a.ClassRoomID] = make(map[int]bool)
+		}
+		if slots[a.ClassRoomID][a.SlotNumber] {
+			t.Errorf("slot %d of %s used twice", a.SlotNumber, a.ClassRoomID.Hex())
+		}
+		slots[a.ClassRoomID][a.SlotNumber] = true
+	}
+
+}
+
+func TestSolverSplitsSiblingsWithoutRoom(t *testing.T) {
+
+	sv := newSolver(nil, 0, 2, 2)
+	sv.run([]string{"s1", "s2", "s3"}, [][]string{{"s1", "s2", "s3"}})
+
+	if len(sv.violated(ConstraintSiblings)) != 1 {
+		t.Fatalf("want one siblings violation, got %+v", sv.violations)
+	}
+	if len(sv.assignments) != 3 {
+		t.Fatalf("want every sibling placed on their own, got %d placements", len(sv.assignments))
+	}
+
+}
+
+func TestSolverReportsFullClassrooms(t *testing.T) {
+
+	sv := newSolver(nil, 0, 1)
+	sv.run([]string{"s1", "s2"}, nil)
+
+	if sv.placement("s1") == nil {
+		t.Fatalf("s1 not placed")
+	}
+	violations := sv.violated(ConstraintPlacement)
+	if len(violations) != 1 || !reflect.DeepEqual(violations[0].StudentIDs, []string{"s2"}) {
+		t.Fatalf("want a placement violation for s2, got %+v", sv.violations)
+	}
+
+}
+
+func TestSolverKeepsPreviousTeacher(t *testing.T) {
+
+	sv := newSolver([]string{"t1", "t2"}, 0, 3, 3)
+	sv.previousTeacher["s1"] = "t2"
+	sv.previousTeacher["s2"] = "t2"
+	sv.run([]string{"s1", "s2"}, nil)
+
+	first, second := sv.placement("s1"), sv.placement("s2")
+	if first.TeacherID == nil || *first.TeacherID != "t2" || second.TeacherID == nil || *second.TeacherID != "t2" {
+		t.Fatalf("students not kept with their previous teacher: %+v %+v", first, second)
+	}
+	if first.ClassRoomID != second.ClassRoomID {
+		t.Errorf("students of one teacher placed in different classrooms")
+	}
+	if sv.satisfied != sv.total {
+		t.Errorf("satisfied %d of %d constraints, want all", sv.satisfied, sv.total)
+	}
+
+}
+
+func TestSolverSkipsIneligiblePreviousTeacher(t *testing.T) {
+
+	sv := newSolver([]string{"t1", "t2"}, 0, 3)
+	sv.classrooms[0].ineligible["t2"] = true
+	sv.previousTeacher["s1"] = "t2"
+	sv.run([]string{"s1"}, nil)
+
+	if a := sv.placement("s1"); a.TeacherID == nil || *a.TeacherID != "t1" {
+		t.Fatalf("want the qualified teacher t1, got %+v", a.TeacherID)
+	}
+	if len(sv.violated(ConstraintPreviousTeacher)) != 1 {
+		t.Fatalf("want a previous teacher violation, got %+v", sv.violations)
+	}
+
+}
+
+func TestSolverRespectsTeacherCapacity(t *testing.T) {
+
+	sv := newSolver([]string{"t1"}, 1, 3)
+	sv.run([]string{"s1", "s2"}, nil)
+
+	if a := sv.placement("s1"); a.TeacherID == nil || *a.TeacherID != "t1" {
+		t.Fatalf("want t1 for s1, got %+v", a.TeacherID)
+	}
+	if a := sv.placement("s2"); a == nil || a.TeacherID != nil {
+		t.Fatalf("want s2 placed without a teacher, got %+v", a)
+	}
+	if len(sv.violated(ConstraintTeacherCapacity)) != 1 {
+		t.Fatalf("want a teacher capacity violation, got %+v", sv.violations)
+	}
+
+}
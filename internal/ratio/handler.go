@@ -0,0 +1,151 @@
+package ratio
+
+import (
+	"classroom-service/helper"
+	"classroom-service/internal/user"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RatioHandler struct {
+	RatioService RatioService
+}
+
+func NewRatioHandler(ratioService RatioService) *RatioHandler {
+	return &RatioHandler{
+		RatioService: ratioService,
+	}
+}
+
+func ratioErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrRuleNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, ErrRuleExists):
+		return http.StatusConflict, helper.ErrConflict
+	case errors.Is(err, user.ErrOrganizationForbidden):
+		return http.StatusForbidden, helper.ErrForbidden
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *RatioHandler) SaveRule(c *gin.Context) {
+
+	var req SaveRatioRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rule, err := h.RatioService.SaveRule(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := ratioErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Save Ratio Rule Successfully", rule)
+
+}
+
+func (h *RatioHandler) GetRules(c *gin.Context) {
+
+	organizationID := c.Query("organization_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rules, err := h.RatioService.GetRules(ctx, organizationID)
+
+	if err != nil {
+		statusCode, errorCode := ratioErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Ratio Rules Successfully", rules)
+
+}
+
+func (h *RatioHandler) DeleteRule(c *gin.Context) {
+
+	var req DeleteRatioRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.RatioService.DeleteRule(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := ratioErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete Ratio Rule Successfully", nil)
+
+}
+
+func (h *RatioHandler) GetComplianceReport(c *gin.Context) {
+
+	organizationID := c.Query("organization_id")
+	regionID := c.Query("region_id")
+	start := c.Query("start_date")
+	end := c.Query("end_date")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	report, err := h.RatioService.GetComplianceReport(ctx, organizationID, regionID, start, end)
+
+	if err != nil {
+		statusCode, errorCode := ratioErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Ratio Compliance Report Successfully", report)
+
+}
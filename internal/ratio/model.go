@@ -0,0 +1,59 @@
+package ratio
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RatioRule is the licensing minimum of adults for an age group: at most
+// MaxChildrenPerAdult children per adult and never fewer than MinAdults
+// adults while children are present. A rule without AgeGroup is the
+// organization default for classrooms no other rule matches. Blocking rules
+// refuse assignment changes that make a breach worse.
+type RatioRule struct {
+	ID                  primitive.ObjectID `json:"id" bson:"_id"`
+	OrganizationID      string             `json:"organization_id" bson:"organization_id"`
+	AgeGroup            *string            `json:"age_group" bson:"age_group"`
+	MaxChildrenPerAdult int                `json:"max_children_per_adult" bson:"max_children_per_adult"`
+	MinAdults           int                `json:"min_adults" bson:"min_adults"`
+	Blocking            bool               `json:"blocking" bson:"blocking"`
+	CreatedBy           string             `json:"created_by" bson:"created_by"`
+	CreatedAt           time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt           time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// RequiredAdults returns how many adults the rule asks for with the given
+// number of children.
+func (r *RatioRule) RequiredAdults(children int) int {
+
+	if children == 0 {
+		return 0
+	}
+
+	required := (children + r.MaxChildrenPerAdult - 1) / r.MaxChildrenPerAdult
+	if required < r.MinAdults {
+		required = r.MinAdults
+	}
+
+	return required
+
+}
+
+// RuleFor picks the rule of an age group, falling back to the default rule.
+func RuleFor(rules []*RatioRule, ageGroup *string) *RatioRule {
+
+	var fallback *RatioRule
+	for _, r := range rules {
+		if r.AgeGroup == nil {
+			fallback = r
+			continue
+		}
+		if ageGroup != nil && *r.AgeGroup == *ageGroup {
+			return r
+		}
+	}
+
+	return fallback
+
+}
@@ -0,0 +1,88 @@
+package ratio
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RatioRepository interface {
+	SaveRule(ctx context.Context, rule *RatioRule) error
+	GetRuleByID(ctx context.Context, id primitive.ObjectID) (*RatioRule, error)
+	GetRulesByOrganization(ctx context.Context, organizationID string) ([]*RatioRule, error)
+	GetRuleByAgeGroup(ctx context.Context, organizationID string, ageGroup *string) (*RatioRule, error)
+	DeleteRule(ctx context.Context, id primitive.ObjectID) error
+}
+
+type ratioRepository struct {
+	ratioRuleCollection *mongo.Collection
+}
+
+func NewRatioRepository(ratioRuleCollection *mongo.Collection) RatioRepository {
+	return &ratioRepository{
+		ratioRuleCollection: ratioRuleCollection,
+	}
+}
+
+func (r *ratioRepository) SaveRule(ctx context.Context, rule *RatioRule) error {
+
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := r.ratioRuleCollection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule, opts)
+	return err
+
+}
+
+func (r *ratioRepository) GetRuleByID(ctx context.Context, id primitive.ObjectID) (*RatioRule, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetRuleByAgeGroup returns the rule written for exactly this age group, a
+// nil age group being the organization default.
+func (r *ratioRepository) GetRuleByAgeGroup(ctx context.Context, organizationID string, ageGroup *string) (*RatioRule, error) {
+	return r.findOne(ctx, bson.M{"organization_id": organizationID, "age_group": ageGroup})
+}
+
+func (r *ratioRepository) findOne(ctx context.Context, filter bson.M) (*RatioRule, error) {
+
+	var rule RatioRule
+	err := r.ratioRuleCollection.FindOne(ctx, filter).Decode(&rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+
+}
+
+func (r *ratioRepository) GetRulesByOrganization(ctx context.Context, organizationID string) ([]*RatioRule, error) {
+
+	opts := options.Find().SetSort(bson.D{{Key: "age_group", Value: 1}})
+
+	cursor, err := r.ratioRuleCollection.Find(ctx, bson.M{"organization_id": organizationID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []*RatioRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+
+}
+
+func (r *ratioRepository) DeleteRule(ctx context.Context, id primitive.ObjectID) error {
+
+	_, err := r.ratioRuleCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+
+}
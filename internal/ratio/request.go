@@ -0,0 +1,14 @@
+package ratio
+
+type SaveRatioRuleRequest struct {
+	ID                  string  `json:"id"`
+	OrganizationID      string  `json:"organization_id"`
+	AgeGroup            *string `json:"age_group"`
+	MaxChildrenPerAdult int     `json:"max_children_per_adult"`
+	MinAdults           int     `json:"min_adults"`
+	Blocking            bool    `json:"blocking"`
+}
+
+type DeleteRatioRuleRequest struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,41 @@
+package ratio
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// RatioCheck is the headcount of one classroom, day and session. Whole-day
+// assignments and leaders count in every session of the day.
+type RatioCheck struct {
+	ClassroomID    string              `json:"classroom_id"`
+	ClassroomName  string              `json:"classroom_name"`
+	RegionID       *primitive.ObjectID `json:"region_id"`
+	Date           string              `json:"date"`
+	SessionID      *primitive.ObjectID `json:"session_id"`
+	AgeGroup       *string             `json:"age_group"`
+	RuleID         primitive.ObjectID  `json:"rule_id"`
+	Children       int                 `json:"children"`
+	Adults         int                 `json:"adults"`
+	RequiredAdults int                 `json:"required_adults"`
+	Compliant      bool                `json:"compliant"`
+}
+
+// RegionRatioCheck adds up the classroom checks of a region for one day and
+// session, for centers that share adults between classrooms.
+type RegionRatioCheck struct {
+	RegionID       primitive.ObjectID  `json:"region_id"`
+	Date           string              `json:"date"`
+	SessionID      *primitive.ObjectID `json:"session_id"`
+	Children       int                 `json:"children"`
+	Adults         int                 `json:"adults"`
+	RequiredAdults int                 `json:"required_adults"`
+	Compliant      bool                `json:"compliant"`
+}
+
+type ComplianceReportResponse struct {
+	OrganizationID    string              `json:"organization_id"`
+	StartDate         string              `json:"start_date"`
+	EndDate           string              `json:"end_date"`
+	ClassroomBreaches int                 `json:"classroom_breaches"`
+	RegionBreaches    int                 `json:"region_breaches"`
+	Classrooms        []*RatioCheck       `json:"classrooms"`
+	Regions           []*RegionRatioCheck `json:"regions"`
+}
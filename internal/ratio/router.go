@@ -0,0 +1,18 @@
+package ratio
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *RatioHandler) {
+	ratioGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Ratio Rules
+		ratioGroup.POST("/ratio-rules", handler.SaveRule)
+		ratioGroup.GET("/ratio-rules", handler.GetRules)
+		ratioGroup.POST("/remove/ratio-rules", handler.DeleteRule)
+		ratioGroup.GET("/ratio-compliance", handler.GetComplianceReport)
+	}
+}
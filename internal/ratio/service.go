@@ -0,0 +1,542 @@
+package ratio
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/classroom"
+	"classroom-service/internal/leader"
+	"classroom-service/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxReportDays = 31
+
+var (
+	ErrRuleNotFound  = errors.New("ratio rule not found")
+	ErrRuleExists    = errors.New("a ratio rule for this age group already exists")
+	ErrRatioBreached = errors.New("staff-to-child ratio breached")
+)
+
+type RatioService interface {
+	SaveRule(ctx context.Context, req *SaveRatioRuleRequest, userID string) (*RatioRule, error)
+	GetRules(ctx context.Context, organizationID string) ([]*RatioRule, error)
+	DeleteRule(ctx context.Context, req *DeleteRatioRuleRequest) error
+	GetComplianceReport(ctx context.Context, organizationID, regionID, start, end string) (*ComplianceReportResponse, error)
+	CheckOrganization(ctx context.Context, organizationID string, start, end time.Time) ([]*RatioCheck, error)
+	assign.AssignmentGuard
+}
+
+type ratioService struct {
+	RatioRepository     RatioRepository
+	ClassroomRepository classroom.ClassroomRepository
+	AssignRepository    assign.AssignRepository
+	LeaderRepository    leader.LeaderRepository
+	UserService         user.UserService
+}
+
+func NewRatioService(ratioRepository RatioRepository,
+	classroomRepository classroom.ClassroomRepository,
+	assignRepository assign.AssignRepository,
+	leaderRepository leader.LeaderRepository,
+	userService user.UserService) RatioService {
+	return &ratioService{
+		RatioRepository:     ratioRepository,
+		ClassroomRepository: classroomRepository,
+		AssignRepository:    assignRepository,
+		LeaderRepository:    leaderRepository,
+		UserService:         userService,
+	}
+}
+
+// organizationID returns the organization the current user acts for; see
+// user.ResolveOrganization.
+func (s *ratioService) organizationID(ctx context.Context, organizationID string) (string, error) {
+	return user.ResolveOrganization(ctx, s.UserService, organizationID)
+}
+
+func (s *ratioService) SaveRule(ctx context.Context, req *SaveRatioRuleRequest, userID string) (*RatioRule, error) {
+
+	if req.MaxChildrenPerAdult <= 0 {
+		return nil, errors.New("max_children_per_adult must be greater than 0")
+	}
+
+	if req.MinAdults < 0 {
+		return nil, errors.New("min_adults cannot be negative")
+	}
+
+	ageGroup := req.AgeGroup
+	if ageGroup != nil && *ageGroup == "" {
+		ageGroup = nil
+	}
+
+	var rule *RatioRule
+	if req.ID != "" {
+		objID, err := primitive.ObjectIDFromHex(req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		rule, err = s.RatioRepository.GetRuleByID(ctx, objID)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRuleNotFound, req.ID)
+		}
+
+		if _, err := s.organizationID(ctx, rule.OrganizationID); err != nil {
+			return nil, err
+		}
+	} else {
+		orgID, err := s.organizationID(ctx, req.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		rule = &RatioRule{
+			ID:             primitive.NewObjectID(),
+			OrganizationID: orgID,
+			CreatedBy:      userID,
+			CreatedAt:      time.Now(),
+		}
+	}
+
+	existing, err := s.RatioRepository.GetRuleByAgeGroup(ctx, rule.OrganizationID, ageGroup)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.ID != rule.ID {
+		return nil, ErrRuleExists
+	}
+
+	rule.AgeGroup = ageGroup
+	rule.MaxChildrenPerAdult = req.MaxChildrenPerAdult
+	rule.MinAdults = req.MinAdults
+	rule.Blocking = req.Blocking
+	rule.UpdatedAt = time.Now()
+
+	if err := s.RatioRepository.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+
+}
+
+func (s *ratioService) GetRules(ctx context.Context, organizationID string) ([]*RatioRule, error) {
+
+	orgID, err := s.organizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.RatioRepository.GetRulesByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		rules = make([]*RatioRule, 0)
+	}
+
+	return rules, nil
+
+}
+
+func (s *ratioService) DeleteRule(ctx context.Context, req *DeleteRatioRuleRequest) error {
+
+	objID, err := primitive.ObjectIDFromHex(req.ID)
+	if err != nil {
+		return err
+	}
+
+	rule, err := s.RatioRepository.GetRuleByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, req.ID)
+	}
+
+	if _, err := s.organizationID(ctx, rule.OrganizationID); err != nil {
+		return err
+	}
+
+	return s.RatioRepository.DeleteRule(ctx, objID)
+
+}
+
+// headcount is the number of distinct children and adults of a classroom
+// in one session of a day.
+type headcount struct {
+	sessionID *primitive.ObjectID
+	children  int
+	adults    int
+}
+
+// countDay counts a classroom day per session. Teachers of the slots and
+// the leaders are the adults; whole-day assignments and leaders count in
+// every session.
+func countDay(assignments []*assign.TeacherStudentAssignment, leaders []*leader.Leader) []*headcount {
+
+	var sessions []*primitive.ObjectID
+	seen := make(map[primitive.ObjectID]bool)
+	addSession := func(id *primitive.ObjectID) {
+		if id != nil && !seen[*id] {
+			seen[*id] = true
+			sessions = append(sessions, id)
+		}
+	}
+	for _, a := range assignments {
+		addSession(a.SessionID)
+	}
+	for _, l := range leaders {
+		addSession(l.SessionID)
+	}
+
+	if len(sessions) == 0 {
+		sessions = []*primitive.ObjectID{nil}
+	}
+
+	inSession := func(id, sessionID *primitive.ObjectID) bool {
+		return id == nil || (sessionID != nil && *id == *sessionID)
+	}
+
+	counts := make([]*headcount, 0, len(sessions))
+	for _, sessionID := range sessions {
+		children := make(map[string]bool)
+		adults := make(map[string]bool)
+
+		for _, a := range assignments {
+			if !inSession(a.SessionID, sessionID) {
+				continue
+			}
+			if a.StudentID != nil {
+				children[*a.StudentID] = true
+			}
+			if a.TeacherID != nil {
+				adults[*a.TeacherID] = true
+			}
+		}
+
+		for _, l := range leaders {
+			if l.Owner != nil && inSession(l.SessionID, sessionID) {
+				adults[l.Owner.OwnerID] = true
+			}
+		}
+
+		counts = append(counts, &headcount{
+			sessionID: sessionID,
+			children:  len(children),
+			adults:    len(adults),
+		})
+	}
+
+	return counts
+
+}
+
+// shortfall is how many adults the day misses over all its sessions.
+func shortfall(rule *RatioRule, counts []*headcount) int {
+
+	missing := 0
+	for _, h := range counts {
+		if required := rule.RequiredAdults(h.children); required > h.adults {
+			missing += required - h.adults
+		}
+	}
+
+	return missing
+
+}
+
+// GetComplianceReport checks every active classroom of the organization,
+// or of one region, for each day from start to end included.
+func (s *ratioService) GetComplianceReport(ctx context.Context, organizationID, regionID, start, end string) (*ComplianceReportResponse, error) {
+
+	if start == "" || end == "" {
+		return nil, errors.New("start_date and end_date are required")
+	}
+
+	startParse, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return nil, err
+	}
+
+	endParse, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return nil, err
+	}
+
+	if endParse.Before(startParse) {
+		return nil, errors.New("end_date must not be before start_date")
+	}
+
+	endExclusive := endParse.AddDate(0, 0, 1)
+	if endExclusive.Sub(startParse) > maxReportDays*24*time.Hour {
+		return nil, fmt.Errorf("date range cannot exceed %d days", maxReportDays)
+	}
+
+	var regionObjID *primitive.ObjectID
+	if regionID != "" {
+		id, err := primitive.ObjectIDFromHex(regionID)
+		if err != nil {
+			return nil, err
+		}
+		regionObjID = &id
+	}
+
+	orgID, err := s.organizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := s.checkOrganization(ctx, orgID, regionObjID, startParse, endExclusive)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(checks, func(i, j int) bool {
+		if checks[i].Date != checks[j].Date {
+			return checks[i].Date < checks[j].Date
+		}
+		return checks[i].ClassroomName < checks[j].ClassroomName
+	})
+
+	regions := regionChecks(checks)
+
+	report := &ComplianceReportResponse{
+		OrganizationID: orgID,
+		StartDate:      start,
+		EndDate:        end,
+		Classrooms:     checks,
+		Regions:        regions,
+	}
+	for _, c := range checks {
+		if !c.Compliant {
+			report.ClassroomBreaches++
+		}
+	}
+	for _, r := range regions {
+		if !r.Compliant {
+			report.RegionBreaches++
+		}
+	}
+
+	return report, nil
+
+}
+
+// CheckOrganization checks every active classroom of the organization for
+// each day from start until end, end excluded.
+func (s *ratioService) CheckOrganization(ctx context.Context, organizationID string, start, end time.Time) ([]*RatioCheck, error) {
+
+	return s.checkOrganization(ctx, organizationID, nil, start, end)
+
+}
+
+func (s *ratioService) checkOrganization(ctx context.Context, organizationID string, regionID *primitive.ObjectID, start, end time.Time) ([]*RatioCheck, error) {
+
+	rules, err := s.RatioRepository.GetRulesByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	classrooms, err := s.ClassroomRepository.GetClassroomsByOrgID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]*RatioCheck, 0)
+	for _, c := range classrooms {
+		if !c.IsActive {
+			continue
+		}
+		if regionID != nil && (c.RegionID == nil || *c.RegionID != *regionID) {
+			continue
+		}
+
+		rule := RuleFor(rules, c.AgeGroup)
+		if rule == nil {
+			continue
+		}
+
+		classroomChecks, err := s.checkClassroom(ctx, c, rule, start, end)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, classroomChecks...)
+	}
+
+	return checks, nil
+
+}
+
+func (s *ratioService) checkClassroom(ctx context.Context, c *classroom.ClassRoom, rule *RatioRule, start, end time.Time) ([]*RatioCheck, error) {
+
+	assignments, err := s.AssignRepository.GetAssignmentsByClassroomID(ctx, c.ID, &start, &end)
+	if err != nil {
+		return nil, err
+	}
+
+	// The leader range includes its last day.
+	last := end.AddDate(0, 0, -1)
+	leaders, err := s.LeaderRepository.GetLeadersByClassroomAndRange(ctx, c.ID, &start, &last)
+	if err != nil {
+		return nil, err
+	}
+
+	assignmentsByDay := make(map[string][]*assign.TeacherStudentAssignment)
+	for _, a := range assignments {
+		date := a.AssignDate.Format("2006-01-02")
+		assignmentsByDay[date] = append(assignmentsByDay[date], a)
+	}
+
+	leadersByDay := make(map[string][]*leader.Leader)
+	for _, l := range leaders {
+		date := l.Date.Format("2006-01-02")
+		leadersByDay[date] = append(leadersByDay[date], l)
+	}
+
+	var checks []*RatioCheck
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+
+		for _, h := range countDay(assignmentsByDay[date], leadersByDay[date]) {
+			// Days without children, such as weekends, have nothing to check.
+			if h.children == 0 {
+				continue
+			}
+
+			required := rule.RequiredAdults(h.children)
+			checks = append(checks, &RatioCheck{
+				ClassroomID:    c.ID.Hex(),
+				ClassroomName:  c.Name,
+				RegionID:       c.RegionID,
+				Date:           date,
+				SessionID:      h.sessionID,
+				AgeGroup:       c.AgeGroup,
+				RuleID:         rule.ID,
+				Children:       h.children,
+				Adults:         h.adults,
+				RequiredAdults: required,
+				Compliant:      h.adults >= required,
+			})
+		}
+	}
+
+	return checks, nil
+
+}
+
+// regionChecks adds up the classroom checks per region, day and session.
+func regionChecks(checks []*RatioCheck) []*RegionRatioCheck {
+
+	type key struct {
+		regionID  primitive.ObjectID
+		date      string
+		sessionID primitive.ObjectID
+	}
+
+	var regions []*RegionRatioCheck
+	byKey := make(map[key]*RegionRatioCheck)
+	for _, c := range checks {
+		if c.RegionID == nil {
+			continue
+		}
+
+		k := key{regionID: *c.RegionID, date: c.Date}
+		if c.SessionID != nil {
+			k.sessionID = *c.SessionID
+		}
+
+		r, ok := byKey[k]
+		if !ok {
+			r = &RegionRatioCheck{
+				RegionID:  *c.RegionID,
+				Date:      c.Date,
+				SessionID: c.SessionID,
+			}
+			byKey[k] = r
+			regions = append(regions, r)
+		}
+
+		r.Children += c.Children
+		r.Adults += c.Adults
+		r.RequiredAdults += c.RequiredAdults
+	}
+
+	for _, r := range regions {
+		r.Compliant = r.Adults >= r.RequiredAdults
+	}
+
+	if regions == nil {
+		regions = make([]*RegionRatioCheck, 0)
+	}
+
+	return regions
+
+}
+
+// CheckAssignment implements assign.AssignmentGuard. Under a blocking rule
+// a change is refused when it leaves the classroom day short of more adults
+// than before, so changes that help an understaffed day still go through.
+func (s *ratioService) CheckAssignment(ctx context.Context, before, after *assign.TeacherStudentAssignment) error {
+
+	c, err := s.ClassroomRepository.GetClassroomByID(ctx, after.ClassRoomID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+
+	rules, err := s.RatioRepository.GetRulesByOrganization(ctx, c.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	rule := RuleFor(rules, c.AgeGroup)
+	if rule == nil || !rule.Blocking {
+		return nil
+	}
+
+	date := after.AssignDate
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	end := start.Add(24 * time.Hour)
+
+	assignments, err := s.AssignRepository.GetAssignmentsByClassroomID(ctx, c.ID, &start, &end)
+	if err != nil {
+		return err
+	}
+
+	leaders, err := s.LeaderRepository.GetLeadersByClassroomAndRange(ctx, c.ID, &start, &start)
+	if err != nil {
+		return err
+	}
+
+	proposed := make([]*assign.TeacherStudentAssignment, 0, len(assignments)+1)
+	for _, a := range assignments {
+		if before != nil && a.ID == before.ID {
+			continue
+		}
+		proposed = append(proposed, a)
+	}
+	proposed = append(proposed, after)
+
+	current := shortfall(rule, countDay(assignments, leaders))
+	next := shortfall(rule, countDay(proposed, leaders))
+	if next > current {
+		return fmt.Errorf("%w: %s would need %d more adult(s) on %s (1 adult per %d children)",
+			ErrRatioBreached, c.Name, next, start.Format("2006-01-02"), rule.MaxChildrenPerAdult)
+	}
+
+	return nil
+
+}
@@ -1,6 +1,7 @@
 package region
 
 import (
+	"classroom-service/internal/classroom"
 	"classroom-service/internal/language"
 	"classroom-service/internal/room"
 	"classroom-service/internal/user"
@@ -10,14 +11,17 @@ import (
 )
 
 type SlotAssignmentResponse struct {
-	SlotNumber     int             `json:"slot_number"`
-	AssignmentID   *string         `json:"assignment_id,omitempty"`
-	AssignmentDate *time.Time      `json:"assignment_date,omitempty"`
-	Teacher        *user.UserInfor `json:"teacher"`
-	Student        *user.UserInfor `json:"student"`
-	IsAssigned     bool            `json:"is_assigned"`
-	CreatedAt      *time.Time      `json:"created_at,omitempty"`
-	UpdatedAt      *time.Time      `json:"updated_at,omitempty"`
+	SlotNumber     int                 `json:"slot_number"`
+	AssignmentID   *string             `json:"assignment_id,omitempty"`
+	AssignmentDate *time.Time          `json:"assignment_date,omitempty"`
+	SessionID      *primitive.ObjectID `json:"session_id,omitempty"`
+	Teacher        *user.UserInfor     `json:"teacher"`
+	Student        *user.UserInfor     `json:"student"`
+	// Type marks guest and trial visits.
+	Type       string     `json:"type,omitempty"`
+	IsAssigned bool       `json:"is_assigned"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
 }
 
 type ClassRoomResponse struct {
@@ -28,11 +32,13 @@ type ClassRoomResponse struct {
 	Icon        string              `json:"icon"`
 	Note        string              `json:"note"`
 	Room        *room.RoomInfor     `json:"location"`
-	Leader      *user.UserInfor     `json:"leader"`
-	IsActive    bool                `json:"is_active"`
-	CreatedBy   string              `json:"created_by"`
-	CreatedAt   time.Time           `json:"created_at"`
-	UpdatedAt   time.Time           `json:"updated_at"`
+	// LocationOverride is set when the classroom is temporarily elsewhere.
+	LocationOverride *classroom.LocationOverride `json:"location_override,omitempty"`
+	Leader           *user.UserInfor             `json:"leader"`
+	IsActive         bool                        `json:"is_active"`
+	CreatedBy        string                      `json:"created_by"`
+	CreatedAt        time.Time                   `json:"created_at"`
+	UpdatedAt        time.Time                   `json:"updated_at"`
 
 	TotalSlots        int                                `json:"total_slots"`
 	AssignedSlots     int                                `json:"assigned_slots"`
@@ -3,6 +3,7 @@ package region
 import (
 	"classroom-service/internal/assign"
 	"classroom-service/internal/classroom"
+	"classroom-service/internal/event"
 	"classroom-service/internal/language"
 	"classroom-service/internal/leader"
 	"classroom-service/internal/room"
@@ -30,6 +31,7 @@ type regionService struct {
 	RoomService         room.RoomService
 	LeaderRepository    leader.LeaderRepository
 	LanguageService     language.MessageLanguageGateway
+	EventPublisher      event.Publisher
 }
 
 func NewRegionService(regionRepository RegionRepository,
@@ -38,7 +40,8 @@ func NewRegionService(regionRepository RegionRepository,
 	userService user.UserService,
 	roomService room.RoomService,
 	leaderRepository leader.LeaderRepository,
-	languageService language.MessageLanguageGateway) RegionService {
+	languageService language.MessageLanguageGateway,
+	publisher event.Publisher) RegionService {
 	return &regionService{
 		RegionRepository:    regionRepository,
 		ClassroomRepository: classroomRepository,
@@ -47,6 +50,7 @@ func NewRegionService(regionRepository RegionRepository,
 		RoomService:         roomService,
 		LeaderRepository:    leaderRepository,
 		LanguageService:     languageService,
+		EventPublisher:      publisher,
 	}
 }
 
@@ -80,6 +84,11 @@ func (r *regionService) CreateRegion(ctx context.Context, req *CreateRegionReque
 		return "", err
 	}
 
+	event.Emit(ctx, r.EventPublisher, event.New(event.RegionCreated, "region", ID.Hex(), map[string]interface{}{
+		"organization_id": data.OrganizationID,
+		"name":            data.Name,
+	}).WithActor(userID))
+
 	return ID.Hex(), nil
 
 }
@@ -110,20 +119,14 @@ func (r *regionService) GetAllRegions(ctx context.Context, organizationID string
 
 		classroomResponses := make([]*ClassRoomResponse, 0)
 		for _, classroom := range classrooms {
-			var roomInfor *room.RoomInfor
-			if classroom.LocationID != nil {
-				roomData, err := r.RoomService.GetRoomByID(ctx, classroom.LocationID.Hex())
-				if err == nil && roomData != nil {
-					roomInfor = roomData
-				} else {
-					roomInfor = &room.RoomInfor{
-						ID:   classroom.LocationID.Hex(),
-						Name: "Deleted",
-					}
-				}
+			roomInfor, locationOverride, err := r.classroomRoom(ctx, classroom, dateParse)
+			if err != nil {
+				return nil, err
 			}
 
-			leader, err := r.LeaderRepository.GetLeaderByClassIDAndDate(ctx, classroom.ID, &dateParse)
+			// The overview shows the whole-day leader; session leaders are
+			// listed on the classroom schedule.
+			leader, err := r.LeaderRepository.GetLeaderByClassIDAndDate(ctx, classroom.ID, &dateParse, nil)
 			if err != nil {
 				return nil, err
 			}
@@ -173,6 +176,8 @@ func (r *regionService) GetAllRegions(ctx context.Context, organizationID string
 					SlotNumber:     assignment.SlotNumber,
 					AssignmentID:   &assignmentID,
 					AssignmentDate: &assignment.AssignDate,
+					SessionID:      assignment.SessionID,
+					Type:           assignment.Type,
 					IsAssigned:     true,
 					CreatedAt:      &assignment.CreatedAt,
 					UpdatedAt:      &assignment.UpdatedAt,
@@ -213,6 +218,7 @@ func (r *regionService) GetAllRegions(ctx context.Context, organizationID string
 				Icon:              getStringValue(classroom.Icon),
 				Note:              getStringValue(classroom.Note),
 				Room:              roomInfor,
+				LocationOverride:  locationOverride,
 				Leader:            leaderInfor,
 				IsActive:          classroom.IsActive,
 				CreatedBy:         classroom.CreatedBy,
@@ -278,20 +284,12 @@ func (r *regionService) GetRegion(ctx context.Context, id string, date string) (
 
 	for _, classroom := range classrooms {
 
-		var roomInfor *room.RoomInfor
-		if classroom.LocationID != nil {
-			roomData, err := r.RoomService.GetRoomByID(ctx, classroom.LocationID.Hex())
-			if err == nil && roomData != nil {
-				roomInfor = roomData
-			} else {
-				roomInfor = &room.RoomInfor{
-					ID:   classroom.LocationID.Hex(),
-					Name: "Deleted",
-				}
-			}
+		roomInfor, locationOverride, err := r.classroomRoom(ctx, classroom, dateParse)
+		if err != nil {
+			return nil, err
 		}
 
-		leader, err := r.LeaderRepository.GetLeaderByClassIDAndDate(ctx, classroom.ID, &dateParse)
+		leader, err := r.LeaderRepository.GetLeaderByClassIDAndDate(ctx, classroom.ID, &dateParse, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -335,6 +333,8 @@ func (r *regionService) GetRegion(ctx context.Context, id string, date string) (
 				SlotNumber:     assignment.SlotNumber,
 				AssignmentID:   &assignmentID,
 				AssignmentDate: &assignment.AssignDate,
+				SessionID:      assignment.SessionID,
+				Type:           assignment.Type,
 				IsAssigned:     true,
 				CreatedAt:      &assignment.CreatedAt,
 				UpdatedAt:      &assignment.UpdatedAt,
@@ -375,6 +375,7 @@ func (r *regionService) GetRegion(ctx context.Context, id string, date string) (
 			Icon:              getStringValue(classroom.Icon),
 			Note:              getStringValue(classroom.Note),
 			Room:              roomInfor,
+			LocationOverride:  locationOverride,
 			Leader:            leaderInfor,
 			IsActive:          classroom.IsActive,
 			CreatedBy:         classroom.CreatedBy,
@@ -401,6 +402,31 @@ func (r *regionService) GetRegion(ctx context.Context, id string, date string) (
 	return res, nil
 }
 
+// classroomRoom returns the room the classroom is in on date, which is
+// another one than its own while a location override applies.
+func (r *regionService) classroomRoom(ctx context.Context, c *classroom.ClassRoom, date time.Time) (*room.RoomInfor, *classroom.LocationOverride, error) {
+
+	locationID, override, err := classroom.LocationForDate(ctx, r.ClassroomRepository, c, date)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if locationID == nil {
+		return nil, override, nil
+	}
+
+	roomData, err := r.RoomService.GetRoomByID(ctx, locationID.Hex())
+	if err == nil && roomData != nil {
+		return roomData, override, nil
+	}
+
+	return &room.RoomInfor{
+		ID:   locationID.Hex(),
+		Name: "Deleted",
+	}, override, nil
+
+}
+
 func getStringValue(s *string) string {
 	if s == nil {
 		return ""
@@ -435,7 +461,15 @@ func (r *regionService) UpdateRegion(ctx context.Context, id string, req *Update
 	region.Name = req.Name
 	region.UpdatedAt = time.Now()
 
-	return r.RegionRepository.UpdateRegion(ctx, objectID, region)
+	if err := r.RegionRepository.UpdateRegion(ctx, objectID, region); err != nil {
+		return err
+	}
+
+	event.Emit(ctx, r.EventPublisher, event.New(event.RegionUpdated, "region", id, map[string]interface{}{
+		"organization_id": region.OrganizationID,
+		"name":            region.Name,
+	}))
+	return nil
 
 }
 
@@ -450,6 +484,11 @@ func (r *regionService) DeleteRegion(ctx context.Context, id string) error {
 		return err
 	}
 
-	return r.RegionRepository.DeleteRegion(ctx, objectID)
+	if err := r.RegionRepository.DeleteRegion(ctx, objectID); err != nil {
+		return err
+	}
+
+	event.Emit(ctx, r.EventPublisher, event.New(event.RegionDeleted, "region", id, map[string]interface{}{}))
+	return nil
 
 }
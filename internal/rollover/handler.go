@@ -0,0 +1,221 @@
+package rollover
+
+import (
+	"classroom-service/helper"
+	"classroom-service/internal/user"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RolloverHandler struct {
+	RolloverService RolloverService
+}
+
+func NewRolloverHandler(rolloverService RolloverService) *RolloverHandler {
+	return &RolloverHandler{
+		RolloverService: rolloverService,
+	}
+}
+
+func rolloverErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrRuleNotFound), errors.Is(err, ErrRolloverNotFound), errors.Is(err, ErrClassroomNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, ErrAlreadyApplied), errors.Is(err, ErrRolloverOutdated):
+		return http.StatusConflict, helper.ErrConflict
+	case errors.Is(err, user.ErrOrganizationForbidden):
+		return http.StatusForbidden, helper.ErrForbidden
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *RolloverHandler) SaveRule(c *gin.Context) {
+
+	var req SaveRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rule, err := h.RolloverService.SaveRule(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := rolloverErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Save Rollover Rule Successfully", rule)
+
+}
+
+func (h *RolloverHandler) GetRules(c *gin.Context) {
+
+	organizationID := c.Query("organization_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rules, err := h.RolloverService.GetRules(ctx, organizationID)
+
+	if err != nil {
+		statusCode, errorCode := rolloverErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Rollover Rules Successfully", rules)
+
+}
+
+func (h *RolloverHandler) DeleteRule(c *gin.Context) {
+
+	var req DeleteRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.RolloverService.DeleteRule(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := rolloverErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete Rollover Rule Successfully", nil)
+
+}
+
+func (h *RolloverHandler) CreateRollover(c *gin.Context) {
+
+	var req CreateRolloverRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rollover, err := h.RolloverService.CreateRollover(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := rolloverErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Create Rollover Successfully", rollover)
+
+}
+
+func (h *RolloverHandler) GetRollover(c *gin.Context) {
+
+	id := c.Param("id")
+	if id == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rollover, err := h.RolloverService.GetRollover(ctx, id)
+
+	if err != nil {
+		statusCode, errorCode := rolloverErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Rollover Successfully", rollover)
+
+}
+
+func (h *RolloverHandler) ApplyRollover(c *gin.Context) {
+
+	id := c.Param("id")
+	if id == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rollover, err := h.RolloverService.ApplyRollover(ctx, id, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := rolloverErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Apply Rollover Successfully", rollover)
+
+}
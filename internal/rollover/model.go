@@ -0,0 +1,108 @@
+package rollover
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/rule"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatusDraft   = "draft"
+	StatusApplied = "applied"
+)
+
+// Rule sends students on to DestinationClassRoomID for the next term.
+// SourceClassRoomID limits it to the students of one classroom, MinAgeMonths
+// and MaxAgeMonths to the children whose age at the start of the next term
+// is in [MinAgeMonths, MaxAgeMonths). A rule needs at least one of the two.
+// When several rules match, lower Priority is tried first and the next one
+// is used when its destination is full.
+type Rule struct {
+	ID                     primitive.ObjectID  `json:"id" bson:"_id"`
+	OrganizationID         string              `json:"organization_id" bson:"organization_id"`
+	SourceClassRoomID      *primitive.ObjectID `json:"source_class_room_id" bson:"source_class_room_id"`
+	MinAgeMonths           *int                `json:"min_age_months" bson:"min_age_months"`
+	MaxAgeMonths           *int                `json:"max_age_months" bson:"max_age_months"`
+	DestinationClassRoomID primitive.ObjectID  `json:"destination_class_room_id" bson:"destination_class_room_id"`
+	Priority               int                 `json:"priority" bson:"priority"`
+	Note                   *string             `json:"note" bson:"note"`
+	CreatedBy              string              `json:"created_by" bson:"created_by"`
+	CreatedAt              time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt              time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// HasAge reports whether the rule depends on the student's age.
+func (r *Rule) HasAge() bool {
+	return r.MinAgeMonths != nil || r.MaxAgeMonths != nil
+}
+
+// Matches reports whether a student of the source classroom, ageMonths old
+// or of unknown age when nil, falls under the rule.
+func (r *Rule) Matches(source primitive.ObjectID, ageMonths *int) bool {
+
+	if r.SourceClassRoomID != nil && *r.SourceClassRoomID != source {
+		return false
+	}
+
+	if !r.HasAge() {
+		return true
+	}
+
+	if ageMonths == nil {
+		return false
+	}
+	if r.MinAgeMonths != nil && *ageMonths < *r.MinAgeMonths {
+		return false
+	}
+	if r.MaxAgeMonths != nil && *ageMonths >= *r.MaxAgeMonths {
+		return false
+	}
+
+	return true
+
+}
+
+// Rollover is a draft of the next term's template assignments built from
+// the current term and the rollover rules. Drafted templates keep the
+// session of the student's current template.
+type Rollover struct {
+	ID             primitive.ObjectID                    `json:"id" bson:"_id"`
+	OrganizationID string                                `json:"organization_id" bson:"organization_id"`
+	FromTermID     primitive.ObjectID                    `json:"from_term_id" bson:"from_term_id"`
+	ToTermID       primitive.ObjectID                    `json:"to_term_id" bson:"to_term_id"`
+	Status         string                                `json:"status" bson:"status"`
+	Assignments    []*assign.ClassRoomTemplateAssignment `json:"assignments" bson:"assignments"`
+	Moves          []*Move                               `json:"moves" bson:"moves"`
+	Unplaced       []*UnplacedStudent                    `json:"unplaced" bson:"unplaced"`
+	CreatedBy      string                                `json:"created_by" bson:"created_by"`
+	AppliedBy      *string                               `json:"applied_by" bson:"applied_by"`
+	AppliedAt      *time.Time                            `json:"applied_at" bson:"applied_at"`
+	CreatedAt      time.Time                             `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time                             `json:"updated_at" bson:"updated_at"`
+
+	// Warnings lists the placement rules the applied templates break
+	// without blocking them; it is only part of the apply response.
+	Warnings []*rule.Violation `json:"warnings,omitempty" bson:"-"`
+}
+
+// Move explains one drafted assignment: where the student came from and
+// the rule that sent them on.
+type Move struct {
+	StudentID       string             `json:"student_id" bson:"student_id"`
+	FromClassRoomID primitive.ObjectID `json:"from_class_room_id" bson:"from_class_room_id"`
+	ToClassRoomID   primitive.ObjectID `json:"to_class_room_id" bson:"to_class_room_id"`
+	SlotNumber      int                `json:"slot_number" bson:"slot_number"`
+	RuleID          primitive.ObjectID `json:"rule_id" bson:"rule_id"`
+	AgeMonths       *int               `json:"age_months,omitempty" bson:"age_months,omitempty"`
+}
+
+// UnplacedStudent is a student of the current term the rollover could not
+// place, with the destinations that were full.
+type UnplacedStudent struct {
+	StudentID       string               `json:"student_id" bson:"student_id"`
+	FromClassRoomID primitive.ObjectID   `json:"from_class_room_id" bson:"from_class_room_id"`
+	Destinations    []primitive.ObjectID `json:"destinations,omitempty" bson:"destinations,omitempty"`
+	Reason          string               `json:"reason" bson:"reason"`
+}
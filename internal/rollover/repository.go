@@ -0,0 +1,125 @@
+package rollover
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RolloverRepository interface {
+	SaveRule(ctx context.Context, rule *Rule) error
+	GetRuleByID(ctx context.Context, id primitive.ObjectID) (*Rule, error)
+	GetRulesByOrganization(ctx context.Context, organizationID string) ([]*Rule, error)
+	DeleteRule(ctx context.Context, id primitive.ObjectID) error
+	CreateRollover(ctx context.Context, rollover *Rollover) error
+	GetRolloverByID(ctx context.Context, id primitive.ObjectID) (*Rollover, error)
+	MarkRolloverApplied(ctx context.Context, id primitive.ObjectID, userID string, at time.Time) (bool, error)
+}
+
+type rolloverRepository struct {
+	ruleCollection     *mongo.Collection
+	rolloverCollection *mongo.Collection
+}
+
+func NewRolloverRepository(ruleCollection, rolloverCollection *mongo.Collection) RolloverRepository {
+	return &rolloverRepository{
+		ruleCollection:     ruleCollection,
+		rolloverCollection: rolloverCollection,
+	}
+}
+
+func (r *rolloverRepository) SaveRule(ctx context.Context, rule *Rule) error {
+
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := r.ruleCollection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule, opts)
+	return err
+
+}
+
+func (r *rolloverRepository) GetRuleByID(ctx context.Context, id primitive.ObjectID) (*Rule, error) {
+
+	var rule Rule
+	err := r.ruleCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+
+}
+
+func (r *rolloverRepository) GetRulesByOrganization(ctx context.Context, organizationID string) ([]*Rule, error) {
+
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := r.ruleCollection.Find(ctx, bson.M{"organization_id": organizationID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []*Rule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+
+}
+
+func (r *rolloverRepository) DeleteRule(ctx context.Context, id primitive.ObjectID) error {
+
+	_, err := r.ruleCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+
+}
+
+func (r *rolloverRepository) CreateRollover(ctx context.Context, rollover *Rollover) error {
+
+	_, err := r.rolloverCollection.InsertOne(ctx, rollover)
+	return err
+
+}
+
+func (r *rolloverRepository) GetRolloverByID(ctx context.Context, id primitive.ObjectID) (*Rollover, error) {
+
+	var rollover Rollover
+	err := r.rolloverCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&rollover)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rollover, nil
+
+}
+
+// MarkRolloverApplied moves a draft rollover to applied. It reports false
+// when the rollover was no longer a draft, so that only one apply wins.
+func (r *rolloverRepository) MarkRolloverApplied(ctx context.Context, id primitive.ObjectID, userID string, at time.Time) (bool, error) {
+
+	result, err := r.rolloverCollection.UpdateOne(ctx, bson.M{"_id": id, "status": StatusDraft}, bson.M{
+		"$set": bson.M{
+			"status":     StatusApplied,
+			"applied_by": userID,
+			"applied_at": at,
+			"updated_at": at,
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+
+}
@@ -0,0 +1,22 @@
+package rollover
+
+type SaveRuleRequest struct {
+	ID                     string  `json:"id"`
+	OrganizationID         string  `json:"organization_id"`
+	SourceClassroomID      string  `json:"source_classroom_id"`
+	MinAgeMonths           *int    `json:"min_age_months"`
+	MaxAgeMonths           *int    `json:"max_age_months"`
+	DestinationClassroomID string  `json:"destination_classroom_id"`
+	Priority               int     `json:"priority"`
+	Note                   *string `json:"note"`
+}
+
+type DeleteRuleRequest struct {
+	ID string `json:"id"`
+}
+
+type CreateRolloverRequest struct {
+	OrganizationID string `json:"organization_id"`
+	FromTermID     string `json:"from_term_id"`
+	ToTermID       string `json:"to_term_id"`
+}
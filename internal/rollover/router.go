@@ -0,0 +1,22 @@
+package rollover
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *RolloverHandler) {
+	rolloverGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Rollover Rules
+		rolloverGroup.POST("/rollover-rules", handler.SaveRule)
+		rolloverGroup.GET("/rollover-rules", handler.GetRules)
+		rolloverGroup.POST("/remove/rollover-rules", handler.DeleteRule)
+
+		// Rollovers
+		rolloverGroup.POST("/rollovers", handler.CreateRollover)
+		rolloverGroup.GET("/rollovers/:id", handler.GetRollover)
+		rolloverGroup.POST("/rollovers/:id/apply", handler.ApplyRollover)
+	}
+}
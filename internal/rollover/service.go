@@ -0,0 +1,644 @@
+package rollover
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/classroom"
+	"classroom-service/internal/event"
+	"classroom-service/internal/rule"
+	"classroom-service/internal/session"
+	"classroom-service/internal/term"
+	"classroom-service/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxSlotNumber = 15
+
+var (
+	ErrRuleNotFound      = errors.New("rollover rule not found")
+	ErrRolloverNotFound  = errors.New("rollover not found")
+	ErrClassroomNotFound = errors.New("classroom not found")
+	ErrAlreadyApplied    = errors.New("rollover has already been applied")
+	ErrRolloverOutdated  = errors.New("rollover no longer matches the next term")
+)
+
+type RolloverService interface {
+	SaveRule(ctx context.Context, req *SaveRuleRequest, userID string) (*Rule, error)
+	GetRules(ctx context.Context, organizationID string) ([]*Rule, error)
+	DeleteRule(ctx context.Context, req *DeleteRuleRequest) error
+	CreateRollover(ctx context.Context, req *CreateRolloverRequest, userID string) (*Rollover, error)
+	GetRollover(ctx context.Context, id string) (*Rollover, error)
+	ApplyRollover(ctx context.Context, id string, userID string) (*Rollover, error)
+}
+
+type rolloverService struct {
+	RolloverRepository  RolloverRepository
+	ClassroomRepository classroom.ClassroomRepository
+	AssignRepository    assign.AssignRepository
+	AssignService       assign.AssignService
+	SessionRepository   session.SessionRepository
+	UserService         user.UserService
+	TermService         term.TermService
+	EventPublisher      event.Publisher
+}
+
+func NewRolloverService(rolloverRepository RolloverRepository,
+	classroomRepository classroom.ClassroomRepository,
+	assignRepository assign.AssignRepository,
+	assignService assign.AssignService,
+	sessionRepository session.SessionRepository,
+	userService user.UserService,
+	termService term.TermService,
+	publisher event.Publisher) RolloverService {
+	return &rolloverService{
+		RolloverRepository:  rolloverRepository,
+		ClassroomRepository: classroomRepository,
+		AssignRepository:    assignRepository,
+		AssignService:       assignService,
+		SessionRepository:   sessionRepository,
+		UserService:         userService,
+		TermService:         termService,
+		EventPublisher:      publisher,
+	}
+}
+
+// organizationID returns the organization the current user acts for; see
+// user.ResolveOrganization.
+func (s *rolloverService) organizationID(ctx context.Context, organizationID string) (string, error) {
+	return user.ResolveOrganization(ctx, s.UserService, organizationID)
+}
+
+// getClassroom returns the classroom when it belongs to the organization.
+func (s *rolloverService) getClassroom(ctx context.Context, id, organizationID string) (*classroom.ClassRoom, error) {
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid classroom id: %v", err)
+	}
+
+	room, err := s.ClassroomRepository.GetClassroomByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if room == nil || room.OrganizationID != organizationID {
+		return nil, fmt.Errorf("%w: %s", ErrClassroomNotFound, id)
+	}
+
+	return room, nil
+
+}
+
+func (s *rolloverService) SaveRule(ctx context.Context, req *SaveRuleRequest, userID string) (*Rule, error) {
+
+	if req.DestinationClassroomID == "" {
+		return nil, errors.New("destination_classroom_id is required")
+	}
+
+	if req.SourceClassroomID == "" && req.MinAgeMonths == nil && req.MaxAgeMonths == nil {
+		return nil, errors.New("a rule needs a source classroom or an age range")
+	}
+
+	if (req.MinAgeMonths != nil && *req.MinAgeMonths < 0) || (req.MaxAgeMonths != nil && *req.MaxAgeMonths <= 0) {
+		return nil, errors.New("ages must be positive")
+	}
+	if req.MinAgeMonths != nil && req.MaxAgeMonths != nil && *req.MaxAgeMonths <= *req.MinAgeMonths {
+		return nil, errors.New("max_age_months must be greater than min_age_months")
+	}
+
+	var rule *Rule
+	if req.ID != "" {
+		objID, err := primitive.ObjectIDFromHex(req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		rule, err = s.RolloverRepository.GetRuleByID(ctx, objID)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRuleNotFound, req.ID)
+		}
+
+		if _, err := s.organizationID(ctx, rule.OrganizationID); err != nil {
+			return nil, err
+		}
+	} else {
+		organizationID, err := s.organizationID(ctx, req.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		rule = &Rule{
+			ID:             primitive.NewObjectID(),
+			OrganizationID: organizationID,
+			CreatedBy:      userID,
+			CreatedAt:      time.Now(),
+		}
+	}
+
+	destination, err := s.getClassroom(ctx, req.DestinationClassroomID, rule.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.SourceClassRoomID = nil
+	if req.SourceClassroomID != "" {
+		source, err := s.getClassroom(ctx, req.SourceClassroomID, rule.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if source.ID == destination.ID && req.MinAgeMonths == nil && req.MaxAgeMonths == nil {
+			return nil, errors.New("source and destination are the same classroom")
+		}
+		rule.SourceClassRoomID = &source.ID
+	}
+
+	rule.MinAgeMonths = req.MinAgeMonths
+	rule.MaxAgeMonths = req.MaxAgeMonths
+	rule.DestinationClassRoomID = destination.ID
+	rule.Priority = req.Priority
+	rule.Note = req.Note
+	rule.UpdatedAt = time.Now()
+
+	if err := s.RolloverRepository.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+
+}
+
+func (s *rolloverService) GetRules(ctx context.Context, organizationID string) ([]*Rule, error) {
+
+	organizationID, err := s.organizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.RolloverRepository.GetRulesByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		rules = make([]*Rule, 0)
+	}
+
+	return rules, nil
+
+}
+
+func (s *rolloverService) DeleteRule(ctx context.Context, req *DeleteRuleRequest) error {
+
+	objID, err := primitive.ObjectIDFromHex(req.ID)
+	if err != nil {
+		return err
+	}
+
+	rule, err := s.RolloverRepository.GetRuleByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, req.ID)
+	}
+
+	if _, err := s.organizationID(ctx, rule.OrganizationID); err != nil {
+		return err
+	}
+
+	return s.RolloverRepository.DeleteRule(ctx, objID)
+
+}
+
+// ageInMonths returns the completed months between dob and date.
+func ageInMonths(dob, date time.Time) int {
+
+	months := (date.Year()-dob.Year())*12 + int(date.Month()-dob.Month())
+	if date.Day() < dob.Day() {
+		months--
+	}
+
+	return months
+
+}
+
+// destination tracks the free slots of a classroom of the next term while
+// the rollover is drafted.
+type destination struct {
+	room *classroom.ClassRoom
+	// sessions are the classroom's effective sessions by id.
+	sessions map[primitive.ObjectID]*session.Session
+	// booked keeps the sessions already taken in each slot of the next
+	// term, nil for a whole day.
+	booked map[int][]*primitive.ObjectID
+	// teachers keeps each slot's teacher of the current term, who is
+	// expected to stay with the classroom.
+	teachers map[int]*string
+}
+
+// session looks a booked session up; a missing or deleted session takes
+// the whole day, as in session.FindConflict.
+func (d *destination) session(id *primitive.ObjectID) *session.Session {
+	if id == nil {
+		return nil
+	}
+	return d.sessions[*id]
+}
+
+// freeSlot returns the first slot with no booking that collides with
+// sessionID, or 0 when every slot is taken.
+func (d *destination) freeSlot(sessionID *primitive.ObjectID) int {
+
+	target := d.session(sessionID)
+	for slot := 1; slot <= maxSlotNumber; slot++ {
+		free := true
+		for _, booked := range d.booked[slot] {
+			if session.Conflicts(target, d.session(booked)) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return slot
+		}
+	}
+
+	return 0
+
+}
+
+// CreateRollover drafts the next term's templates. Every student of the
+// organization's classrooms in the current term is sent through the first
+// matching rule whose destination still has a free slot; students without
+// a matching rule, or whose destinations are all full, are listed as
+// unplaced.
+func (s *rolloverService) CreateRollover(ctx context.Context, req *CreateRolloverRequest, userID string) (*Rollover, error) {
+
+	if req.FromTermID == "" || req.ToTermID == "" {
+		return nil, errors.New("from_term_id and to_term_id are required")
+	}
+
+	fromTermID, err := primitive.ObjectIDFromHex(req.FromTermID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from_term_id: %v", err)
+	}
+
+	toTermID, err := primitive.ObjectIDFromHex(req.ToTermID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to_term_id: %v", err)
+	}
+
+	if fromTermID == toTermID {
+		return nil, errors.New("from_term_id and to_term_id must differ")
+	}
+
+	organizationID, err := s.organizationID(ctx, req.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	toTerm, err := s.TermService.GetTermByID(ctx, req.ToTermID)
+	if err != nil {
+		return nil, err
+	}
+	if toTerm == nil {
+		return nil, errors.New("term not found")
+	}
+
+	termStart, err := time.Parse("2006-01-02", toTerm.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.RolloverRepository.GetRulesByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms, err := s.ClassroomRepository.GetClassroomsByOrgID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	roomByID := make(map[primitive.ObjectID]*classroom.ClassRoom, len(rooms))
+	for _, room := range rooms {
+		roomByID[room.ID] = room
+	}
+
+	fromTemplates, err := s.AssignRepository.GetAssignmentTemplateByTermID(ctx, fromTermID)
+	if err != nil {
+		return nil, err
+	}
+
+	toTemplates, err := s.AssignRepository.GetAssignmentTemplateByTermID(ctx, toTermID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Slots already planned in the next term are taken for their session.
+	used := make(map[primitive.ObjectID]map[int][]*primitive.ObjectID)
+	placedNext := make(map[string]bool)
+	for _, t := range toTemplates {
+		if t.StudentID != nil {
+			placedNext[*t.StudentID] = true
+		}
+		if used[t.ClassRoomID] == nil {
+			used[t.ClassRoomID] = make(map[int][]*primitive.ObjectID)
+		}
+		used[t.ClassRoomID][t.SlotNumber] = append(used[t.ClassRoomID][t.SlotNumber], t.SessionID)
+	}
+
+	destinations := make(map[primitive.ObjectID]*destination)
+	for _, rule := range rules {
+		room, ok := roomByID[rule.DestinationClassRoomID]
+		if !ok || !room.IsActive || destinations[room.ID] != nil {
+			continue
+		}
+		sessions, err := session.EffectiveSessions(ctx, s.SessionRepository, room.ID)
+		if err != nil {
+			return nil, err
+		}
+		d := &destination{
+			room:     room,
+			sessions: make(map[primitive.ObjectID]*session.Session, len(sessions)),
+			booked:   make(map[int][]*primitive.ObjectID),
+			teachers: make(map[int]*string),
+		}
+		for _, sess := range sessions {
+			d.sessions[sess.ID] = sess
+		}
+		for slot, booked := range used[room.ID] {
+			d.booked[slot] = append(d.booked[slot], booked...)
+		}
+		destinations[room.ID] = d
+	}
+
+	// One entry per student, from their first template in classroom and
+	// slot order.
+	sort.SliceStable(fromTemplates, func(i, j int) bool {
+		a, b := fromTemplates[i], fromTemplates[j]
+		if a.ClassRoomID != b.ClassRoomID {
+			return a.ClassRoomID.Hex() < b.ClassRoomID.Hex()
+		}
+		return a.SlotNumber < b.SlotNumber
+	})
+
+	var students []*assign.ClassRoomTemplateAssignment
+	seen := make(map[string]bool)
+	for _, t := range fromTemplates {
+		if _, ok := roomByID[t.ClassRoomID]; !ok {
+			continue
+		}
+		if d := destinations[t.ClassRoomID]; d != nil && t.TeacherID != nil && d.teachers[t.SlotNumber] == nil {
+			d.teachers[t.SlotNumber] = t.TeacherID
+		}
+		if t.StudentID == nil || seen[*t.StudentID] {
+			continue
+		}
+		seen[*t.StudentID] = true
+		students = append(students, t)
+	}
+
+	needAge := false
+	for _, rule := range rules {
+		if rule.HasAge() {
+			needAge = true
+			break
+		}
+	}
+
+	now := time.Now()
+	rollover := &Rollover{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: organizationID,
+		FromTermID:     fromTermID,
+		ToTermID:       toTermID,
+		Status:         StatusDraft,
+		Assignments:    make([]*assign.ClassRoomTemplateAssignment, 0, len(students)),
+		Moves:          make([]*Move, 0, len(students)),
+		Unplaced:       make([]*UnplacedStudent, 0),
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	for _, t := range students {
+		studentID := *t.StudentID
+
+		if placedNext[studentID] {
+			rollover.Unplaced = append(rollover.Unplaced, &UnplacedStudent{
+				StudentID:       studentID,
+				FromClassRoomID: t.ClassRoomID,
+				Reason:          "already assigned in the next term",
+			})
+			continue
+		}
+
+		var ageMonths *int
+		if needAge {
+			info, err := s.UserService.GetStudentInfor(ctx, studentID)
+			if err == nil && info != nil && info.DateOfBirth != nil {
+				age := ageInMonths(*info.DateOfBirth, termStart)
+				ageMonths = &age
+			}
+		}
+
+		var (
+			full      []primitive.ObjectID
+			matched   bool
+			noSession bool
+			placed    bool
+		)
+		for _, rule := range rules {
+			if !rule.Matches(t.ClassRoomID, ageMonths) {
+				continue
+			}
+			matched = true
+
+			d := destinations[rule.DestinationClassRoomID]
+			if d == nil {
+				continue
+			}
+			if t.SessionID != nil && d.sessions[*t.SessionID] == nil {
+				noSession = true
+				continue
+			}
+
+			slot := d.freeSlot(t.SessionID)
+			if slot == 0 {
+				full = append(full, d.room.ID)
+				continue
+			}
+			d.booked[slot] = append(d.booked[slot], t.SessionID)
+
+			rollover.Assignments = append(rollover.Assignments, &assign.ClassRoomTemplateAssignment{
+				ID:          primitive.NewObjectID(),
+				ClassRoomID: d.room.ID,
+				TermID:      toTermID,
+				SlotNumber:  slot,
+				SessionID:   t.SessionID,
+				TeacherID:   d.teachers[slot],
+				StudentID:   t.StudentID,
+				Attendance:  t.Attendance,
+				CreatedBy:   userID,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			})
+			rollover.Moves = append(rollover.Moves, &Move{
+				StudentID:       studentID,
+				FromClassRoomID: t.ClassRoomID,
+				ToClassRoomID:   d.room.ID,
+				SlotNumber:      slot,
+				RuleID:          rule.ID,
+				AgeMonths:       ageMonths,
+			})
+			placed = true
+			break
+		}
+
+		if placed {
+			continue
+		}
+
+		unplaced := &UnplacedStudent{
+			StudentID:       studentID,
+			FromClassRoomID: t.ClassRoomID,
+			Destinations:    full,
+		}
+		switch {
+		case len(full) > 0:
+			unplaced.Reason = "destination classrooms are full"
+		case noSession:
+			unplaced.Reason = "destination classrooms do not offer the student's session"
+		case matched:
+			unplaced.Reason = "destination classrooms are inactive"
+		case needAge && ageMonths == nil:
+			unplaced.Reason = "no rollover rule matches and the date of birth is unknown"
+		default:
+			unplaced.Reason = "no rollover rule matches"
+		}
+		rollover.Unplaced = append(rollover.Unplaced, unplaced)
+	}
+
+	if err := s.RolloverRepository.CreateRollover(ctx, rollover); err != nil {
+		return nil, err
+	}
+
+	return rollover, nil
+
+}
+
+func (s *rolloverService) GetRollover(ctx context.Context, id string) (*Rollover, error) {
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rollover, err := s.RolloverRepository.GetRolloverByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if rollover == nil {
+		return nil, ErrRolloverNotFound
+	}
+
+	if _, err := s.organizationID(ctx, rollover.OrganizationID); err != nil {
+		return nil, err
+	}
+
+	return rollover, nil
+
+}
+
+// ApplyRollover writes the drafted templates. Each one goes through the
+// checks of a new template again, and the rollover only moves from draft to
+// applied in the same transaction, so a second apply writes nothing.
+func (s *rolloverService) ApplyRollover(ctx context.Context, id string, userID string) (*Rollover, error) {
+
+	rollover, err := s.GetRollover(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if rollover.Status != StatusDraft {
+		return nil, ErrAlreadyApplied
+	}
+
+	var applied *Rollover
+	err = s.AssignRepository.RunInTransaction(ctx, func(ctx context.Context) error {
+
+		now := time.Now()
+		ok, err := s.RolloverRepository.MarkRolloverApplied(ctx, rollover.ID, userID, now)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrAlreadyApplied
+		}
+
+		warnings := make([]*rule.Violation, 0)
+		for _, drafted := range rollover.Assignments {
+			a := *drafted
+			a.CreatedBy = userID
+			a.CreatedAt = now
+			a.UpdatedAt = now
+
+			existing, err := s.AssignRepository.GetAssignmentTemplateBySlot(ctx, a.ClassRoomID, rollover.ToTermID, a.SlotNumber, a.SessionID)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return fmt.Errorf("%w: slot %d in classroom %s is no longer free", ErrRolloverOutdated, a.SlotNumber, a.ClassRoomID.Hex())
+			}
+
+			exists, err := s.AssignRepository.CheckStudentExistingInTerm(ctx, rollover.ToTermID, *a.StudentID)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return fmt.Errorf("%w: student %s already assigned to a class in the next term", ErrRolloverOutdated, *a.StudentID)
+			}
+
+			violations, err := s.AssignService.CheckAssignmentTemplate(ctx, &a)
+			if err != nil {
+				return fmt.Errorf("student %s: %w", *a.StudentID, err)
+			}
+			warnings = append(warnings, violations...)
+
+			if err := s.AssignRepository.CreateAssignmentTemplate(ctx, &a); err != nil {
+				return err
+			}
+		}
+
+		result := *rollover
+		result.Status = StatusApplied
+		result.AppliedBy = &userID
+		result.AppliedAt = &now
+		result.UpdatedAt = now
+		result.Warnings = warnings
+		applied = &result
+
+		return event.Record(ctx, s.EventPublisher, event.New(event.TermRolledOver, "rollover", rollover.ID.Hex(), map[string]interface{}{
+			"organization_id": rollover.OrganizationID,
+			"from_term_id":    rollover.FromTermID.Hex(),
+			"to_term_id":      rollover.ToTermID.Hex(),
+			"placed":          len(rollover.Assignments),
+			"unplaced":        len(rollover.Unplaced),
+		}).WithActor(userID))
+
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+
+}
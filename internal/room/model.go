@@ -3,4 +3,6 @@ package room
 type RoomInfor struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
+	// Capacity is nil when the inventory service does not report one.
+	Capacity *int `json:"capacity"`
 }
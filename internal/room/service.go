@@ -89,6 +89,11 @@ func (s *roomService) GetRoomByID(ctx context.Context, id string) (*RoomInfor, e
 		Name: data["name"].(string),
 	}
 
+	if capacity, ok := data["capacity"].(float64); ok && capacity > 0 {
+		c := int(capacity)
+		room.Capacity = &c
+	}
+
 	return room, nil
 }
 
@@ -0,0 +1,153 @@
+package rule
+
+import (
+	"classroom-service/helper"
+	"classroom-service/internal/user"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RuleHandler struct {
+	RuleService RuleService
+}
+
+func NewRuleHandler(ruleService RuleService) *RuleHandler {
+	return &RuleHandler{
+		RuleService: ruleService,
+	}
+}
+
+func ruleErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrRuleNotFound),
+		errors.Is(err, ErrClassroomNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, user.ErrOrganizationForbidden):
+		return http.StatusForbidden, helper.ErrForbidden
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *RuleHandler) SaveRule(c *gin.Context) {
+
+	var req SaveRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rule, err := h.RuleService.SaveRule(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := ruleErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Save Rule Successfully", rule)
+
+}
+
+func (h *RuleHandler) GetRules(c *gin.Context) {
+
+	organizationID := c.Query("organization_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	rules, err := h.RuleService.GetRules(ctx, organizationID)
+
+	if err != nil {
+		statusCode, errorCode := ruleErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Rules Successfully", rules)
+
+}
+
+func (h *RuleHandler) DeleteRule(c *gin.Context) {
+
+	var req DeleteRuleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.RuleService.DeleteRule(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := ruleErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete Rule Successfully", nil)
+
+}
+
+func (h *RuleHandler) GetViolations(c *gin.Context) {
+
+	termID := c.Query("term_id")
+	if termID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("term_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	organizationID := c.Query("organization_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	violations, err := h.RuleService.GetViolations(ctx, organizationID, termID)
+
+	if err != nil {
+		statusCode, errorCode := ruleErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Rule Violations Successfully", violations)
+
+}
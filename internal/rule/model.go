@@ -0,0 +1,51 @@
+package rule
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TypeKeepTogether     = "keep_together"
+	TypeKeepApart        = "keep_apart"
+	TypePreferredTeacher = "preferred_teacher"
+)
+
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Rule constrains where students are placed within an organization.
+// Keep-together and keep-apart rules name two or more students, a
+// preferred-teacher rule names one student and TeacherID. Breaking a rule
+// with SeverityError rejects the change, warnings are only reported.
+type Rule struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	OrganizationID string             `json:"organization_id" bson:"organization_id"`
+	Type           string             `json:"type" bson:"type"`
+	StudentIDs     []string           `json:"student_ids" bson:"student_ids"`
+	TeacherID      *string            `json:"teacher_id" bson:"teacher_id"`
+	Severity       string             `json:"severity" bson:"severity"`
+	Note           *string            `json:"note" bson:"note"`
+	CreatedBy      string             `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Placement is a student sitting in a classroom, with the slot's teacher
+// when there is one.
+type Placement struct {
+	StudentID   string             `json:"student_id"`
+	ClassRoomID primitive.ObjectID `json:"class_room_id"`
+	TeacherID   *string            `json:"teacher_id"`
+}
+
+type Violation struct {
+	RuleID     primitive.ObjectID `json:"rule_id"`
+	Type       string             `json:"type"`
+	Severity   string             `json:"severity"`
+	StudentIDs []string           `json:"student_ids"`
+	Message    string             `json:"message"`
+}
@@ -0,0 +1,116 @@
+package rule
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PlacementProvider looks up where students are placed. It is implemented
+// by the assign repository.
+type PlacementProvider interface {
+	GetPlacementsOnDate(ctx context.Context, date time.Time, studentIDs []string) ([]*Placement, error)
+	GetPlacementsInTerm(ctx context.Context, termID primitive.ObjectID, studentIDs []string) ([]*Placement, error)
+}
+
+type RuleRepository interface {
+	SaveRule(ctx context.Context, rule *Rule) error
+	GetRuleByID(ctx context.Context, id primitive.ObjectID) (*Rule, error)
+	GetRulesByOrganization(ctx context.Context, organizationID string) ([]*Rule, error)
+	GetRulesByStudent(ctx context.Context, organizationID, studentID string) ([]*Rule, error)
+	DeleteRule(ctx context.Context, id primitive.ObjectID) error
+	GetClassroomOrganizationID(ctx context.Context, classroomID primitive.ObjectID) (*string, error)
+}
+
+type ruleRepository struct {
+	ruleCollection      *mongo.Collection
+	classroomCollection *mongo.Collection
+}
+
+func NewRuleRepository(ruleCollection, classroomCollection *mongo.Collection) RuleRepository {
+	return &ruleRepository{
+		ruleCollection:      ruleCollection,
+		classroomCollection: classroomCollection,
+	}
+}
+
+func (r *ruleRepository) SaveRule(ctx context.Context, rule *Rule) error {
+
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := r.ruleCollection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule, opts)
+	return err
+
+}
+
+func (r *ruleRepository) GetRuleByID(ctx context.Context, id primitive.ObjectID) (*Rule, error) {
+
+	var rule Rule
+	err := r.ruleCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &rule, nil
+
+}
+
+func (r *ruleRepository) GetRulesByOrganization(ctx context.Context, organizationID string) ([]*Rule, error) {
+	return r.findRules(ctx, bson.M{"organization_id": organizationID})
+}
+
+func (r *ruleRepository) GetRulesByStudent(ctx context.Context, organizationID, studentID string) ([]*Rule, error) {
+	return r.findRules(ctx, bson.M{"organization_id": organizationID, "student_ids": studentID})
+}
+
+func (r *ruleRepository) findRules(ctx context.Context, filter bson.M) ([]*Rule, error) {
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.ruleCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []*Rule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+
+}
+
+func (r *ruleRepository) DeleteRule(ctx context.Context, id primitive.ObjectID) error {
+
+	_, err := r.ruleCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+
+}
+
+func (r *ruleRepository) GetClassroomOrganizationID(ctx context.Context, classroomID primitive.ObjectID) (*string, error) {
+
+	opts := options.FindOne().SetProjection(bson.M{"organization_id": 1})
+
+	var classroom struct {
+		OrganizationID string `bson:"organization_id"`
+	}
+	err := r.classroomCollection.FindOne(ctx, bson.M{"_id": classroomID}, opts).Decode(&classroom)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &classroom.OrganizationID, nil
+
+}
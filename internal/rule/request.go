@@ -0,0 +1,15 @@
+package rule
+
+type SaveRuleRequest struct {
+	ID             string   `json:"id"`
+	OrganizationID string   `json:"organization_id"`
+	Type           string   `json:"type"`
+	StudentIDs     []string `json:"student_ids"`
+	TeacherID      *string  `json:"teacher_id"`
+	Severity       string   `json:"severity"`
+	Note           *string  `json:"note"`
+}
+
+type DeleteRuleRequest struct {
+	ID string `json:"id"`
+}
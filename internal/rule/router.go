@@ -0,0 +1,18 @@
+package rule
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *RuleHandler) {
+	ruleGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Rules
+		ruleGroup.POST("/rules", handler.SaveRule)
+		ruleGroup.GET("/rules", handler.GetRules)
+		ruleGroup.GET("/rules/violations", handler.GetViolations)
+		ruleGroup.POST("/remove/rules", handler.DeleteRule)
+	}
+}
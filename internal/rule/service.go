@@ -0,0 +1,456 @@
+package rule
+
+import (
+	"classroom-service/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrRuleNotFound      = errors.New("rule not found")
+	ErrClassroomNotFound = errors.New("classroom not found")
+	ErrRuleViolated      = errors.New("placement breaks a rule")
+)
+
+// ViolationError wraps ErrRuleViolated with the broken rules.
+func ViolationError(violations []*Violation) error {
+
+	messages := make([]string, 0, len(violations))
+	for _, v := range violations {
+		messages = append(messages, v.Message)
+	}
+
+	return fmt.Errorf("%w: %s", ErrRuleViolated, strings.Join(messages, "; "))
+
+}
+
+// Blocking returns the violations of rules with SeverityError.
+func Blocking(violations []*Violation) []*Violation {
+
+	var blocking []*Violation
+	for _, v := range violations {
+		if v.Severity == SeverityError {
+			blocking = append(blocking, v)
+		}
+	}
+
+	return blocking
+
+}
+
+type RuleService interface {
+	SaveRule(ctx context.Context, req *SaveRuleRequest, userID string) (*Rule, error)
+	GetRules(ctx context.Context, organizationID string) ([]*Rule, error)
+	DeleteRule(ctx context.Context, req *DeleteRuleRequest) error
+	GetViolations(ctx context.Context, organizationID, termID string) ([]*Violation, error)
+	CheckPlacementOnDate(ctx context.Context, proposed *Placement, date time.Time) ([]*Violation, error)
+	CheckPlacementInTerm(ctx context.Context, proposed *Placement, termID primitive.ObjectID) ([]*Violation, error)
+}
+
+type ruleService struct {
+	RuleRepository    RuleRepository
+	PlacementProvider PlacementProvider
+	UserService       user.UserService
+}
+
+func NewRuleService(ruleRepository RuleRepository, placementProvider PlacementProvider, userService user.UserService) RuleService {
+	return &ruleService{
+		RuleRepository:    ruleRepository,
+		PlacementProvider: placementProvider,
+		UserService:       userService,
+	}
+}
+
+// organizationID returns the organization the current user acts for; see
+// user.ResolveOrganization.
+func (s *ruleService) organizationID(ctx context.Context, organizationID string) (string, error) {
+	return user.ResolveOrganization(ctx, s.UserService, organizationID)
+}
+
+func (s *ruleService) SaveRule(ctx context.Context, req *SaveRuleRequest, userID string) (*Rule, error) {
+
+	studentIDs := make([]string, 0, len(req.StudentIDs))
+	seen := make(map[string]bool, len(req.StudentIDs))
+	for _, id := range req.StudentIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		studentIDs = append(studentIDs, id)
+	}
+
+	switch req.Type {
+	case TypeKeepTogether, TypeKeepApart:
+		if len(studentIDs) < 2 {
+			return nil, fmt.Errorf("%s rule needs at least two students", req.Type)
+		}
+		if req.TeacherID != nil {
+			return nil, fmt.Errorf("%s rule does not take a teacher", req.Type)
+		}
+	case TypePreferredTeacher:
+		if len(studentIDs) != 1 {
+			return nil, errors.New("preferred_teacher rule needs exactly one student")
+		}
+		if req.TeacherID == nil || *req.TeacherID == "" {
+			return nil, errors.New("teacher_id is required")
+		}
+	default:
+		return nil, fmt.Errorf("invalid rule type %q", req.Type)
+	}
+
+	severity := req.Severity
+	if severity == "" {
+		severity = SeverityWarning
+	}
+	if severity != SeverityWarning && severity != SeverityError {
+		return nil, fmt.Errorf("invalid severity %q", severity)
+	}
+
+	var rule *Rule
+	if req.ID != "" {
+		objID, err := primitive.ObjectIDFromHex(req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		rule, err = s.RuleRepository.GetRuleByID(ctx, objID)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRuleNotFound, req.ID)
+		}
+
+		if _, err := s.organizationID(ctx, rule.OrganizationID); err != nil {
+			return nil, err
+		}
+	} else {
+		orgID, err := s.organizationID(ctx, req.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		rule = &Rule{
+			ID:             primitive.NewObjectID(),
+			OrganizationID: orgID,
+			CreatedBy:      userID,
+			CreatedAt:      time.Now(),
+		}
+	}
+
+	rule.Type = req.Type
+	rule.StudentIDs = studentIDs
+	rule.TeacherID = req.TeacherID
+	rule.Severity = severity
+	rule.Note = req.Note
+	rule.UpdatedAt = time.Now()
+
+	if err := s.RuleRepository.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+
+}
+
+func (s *ruleService) GetRules(ctx context.Context, organizationID string) ([]*Rule, error) {
+
+	orgID, err := s.organizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.RuleRepository.GetRulesByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		rules = make([]*Rule, 0)
+	}
+
+	return rules, nil
+
+}
+
+func (s *ruleService) DeleteRule(ctx context.Context, req *DeleteRuleRequest) error {
+
+	objID, err := primitive.ObjectIDFromHex(req.ID)
+	if err != nil {
+		return err
+	}
+
+	rule, err := s.RuleRepository.GetRuleByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, req.ID)
+	}
+
+	if _, err := s.organizationID(ctx, rule.OrganizationID); err != nil {
+		return err
+	}
+
+	return s.RuleRepository.DeleteRule(ctx, objID)
+
+}
+
+// GetViolations evaluates every rule of the organization against the term
+// templates.
+func (s *ruleService) GetViolations(ctx context.Context, organizationID, termID string) ([]*Violation, error) {
+
+	termObjID, err := primitive.ObjectIDFromHex(termID)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := s.organizationID(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.RuleRepository.GetRulesByOrganization(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]*Violation, 0)
+	if len(rules) == 0 {
+		return violations, nil
+	}
+
+	placements, err := s.PlacementProvider.GetPlacementsInTerm(ctx, termObjID, ruleStudents(rules, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rules {
+		if v := Evaluate(r, placements); v != nil {
+			violations = append(violations, v)
+		}
+	}
+
+	return violations, nil
+
+}
+
+func (s *ruleService) CheckPlacementOnDate(ctx context.Context, proposed *Placement, date time.Time) ([]*Violation, error) {
+	return s.checkPlacement(ctx, proposed, func(studentIDs []string) ([]*Placement, error) {
+		return s.PlacementProvider.GetPlacementsOnDate(ctx, date, studentIDs)
+	})
+}
+
+func (s *ruleService) CheckPlacementInTerm(ctx context.Context, proposed *Placement, termID primitive.ObjectID) ([]*Violation, error) {
+	return s.checkPlacement(ctx, proposed, func(studentIDs []string) ([]*Placement, error) {
+		return s.PlacementProvider.GetPlacementsInTerm(ctx, termID, studentIDs)
+	})
+}
+
+// checkPlacement returns the rules the proposed placement would break,
+// given where the other students of those rules are placed by load.
+func (s *ruleService) checkPlacement(ctx context.Context, proposed *Placement, load func(studentIDs []string) ([]*Placement, error)) ([]*Violation, error) {
+
+	orgID, err := s.RuleRepository.GetClassroomOrganizationID(ctx, proposed.ClassRoomID)
+	if err != nil {
+		return nil, err
+	}
+	if orgID == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClassroomNotFound, proposed.ClassRoomID.Hex())
+	}
+
+	rules, err := s.RuleRepository.GetRulesByStudent(ctx, *orgID, proposed.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	var others []*Placement
+	if studentIDs := ruleStudents(rules, proposed.StudentID); len(studentIDs) > 0 {
+		others, err = load(studentIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var violations []*Violation
+	for _, r := range rules {
+		if v := CheckPlacement(r, proposed, others); v != nil {
+			violations = append(violations, v)
+		}
+	}
+
+	return violations, nil
+
+}
+
+// ruleStudents lists the students named by the rules, except skip.
+func ruleStudents(rules []*Rule, skip string) []string {
+
+	seen := make(map[string]bool)
+	var ids []string
+	for _, r := range rules {
+		for _, id := range r.StudentIDs {
+			if id == skip || seen[id] {
+				continue
+			}
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+
+}
+
+func newViolation(r *Rule, studentIDs []string, format string, args ...interface{}) *Violation {
+	return &Violation{
+		RuleID:     r.ID,
+		Type:       r.Type,
+		Severity:   r.Severity,
+		StudentIDs: studentIDs,
+		Message:    fmt.Sprintf(format, args...),
+	}
+}
+
+// CheckPlacement reports whether placing a student as proposed breaks the
+// rule, given where the other students are placed. Students of the rule
+// without any placement are ignored, and a slot without a teacher does not
+// break a preferred-teacher rule yet.
+func CheckPlacement(r *Rule, proposed *Placement, others []*Placement) *Violation {
+
+	switch r.Type {
+	case TypePreferredTeacher:
+		if proposed.TeacherID != nil && r.TeacherID != nil && *proposed.TeacherID != *r.TeacherID {
+			return newViolation(r, []string{proposed.StudentID}, "student %s should stay with teacher %s", proposed.StudentID, *r.TeacherID)
+		}
+	case TypeKeepTogether, TypeKeepApart:
+		classrooms := placementsByStudent(others)
+		var broken []string
+		for _, id := range r.StudentIDs {
+			rooms, placed := classrooms[id]
+			if id == proposed.StudentID || !placed {
+				continue
+			}
+			together := rooms[proposed.ClassRoomID]
+			if together != (r.Type == TypeKeepTogether) {
+				broken = append(broken, id)
+			}
+		}
+		if len(broken) == 0 {
+			return nil
+		}
+		if r.Type == TypeKeepTogether {
+			return newViolation(r, append([]string{proposed.StudentID}, broken...), "student %s should be in the same classroom as %s", proposed.StudentID, strings.Join(broken, ", "))
+		}
+		return newViolation(r, append([]string{proposed.StudentID}, broken...), "student %s should not be in the same classroom as %s", proposed.StudentID, strings.Join(broken, ", "))
+	}
+
+	return nil
+
+}
+
+// Evaluate reports whether the placements break the rule. A student may
+// have several placements, e.g. after a transfer: kept-together students
+// must share at least one classroom, kept-apart students none, and a
+// preferred teacher must teach at least one of the student's slots.
+func Evaluate(r *Rule, placements []*Placement) *Violation {
+
+	classrooms := placementsByStudent(placements)
+
+	switch r.Type {
+	case TypePreferredTeacher:
+		if len(r.StudentIDs) == 0 || r.TeacherID == nil {
+			return nil
+		}
+		studentID := r.StudentIDs[0]
+		placed := false
+		for _, p := range placements {
+			if p.StudentID != studentID {
+				continue
+			}
+			placed = true
+			if p.TeacherID != nil && *p.TeacherID == *r.TeacherID {
+				return nil
+			}
+		}
+		if placed {
+			return newViolation(r, []string{studentID}, "student %s is not with teacher %s", studentID, *r.TeacherID)
+		}
+	case TypeKeepTogether:
+		var placed []string
+		var common map[primitive.ObjectID]bool
+		for _, id := range r.StudentIDs {
+			rooms, ok := classrooms[id]
+			if !ok {
+				continue
+			}
+			placed = append(placed, id)
+			if common == nil {
+				common = rooms
+				continue
+			}
+			shared := make(map[primitive.ObjectID]bool)
+			for room := range common {
+				if rooms[room] {
+					shared[room] = true
+				}
+			}
+			common = shared
+		}
+		if len(placed) > 1 && len(common) == 0 {
+			return newViolation(r, placed, "students %s are not in the same classroom", strings.Join(placed, ", "))
+		}
+	case TypeKeepApart:
+		students := make(map[primitive.ObjectID][]string)
+		for _, id := range r.StudentIDs {
+			for room := range classrooms[id] {
+				students[room] = append(students[room], id)
+			}
+		}
+		sharing := make(map[string]bool)
+		for _, ids := range students {
+			if len(ids) < 2 {
+				continue
+			}
+			for _, id := range ids {
+				sharing[id] = true
+			}
+		}
+		var broken []string
+		for _, id := range r.StudentIDs {
+			if sharing[id] {
+				broken = append(broken, id)
+			}
+		}
+		if len(broken) > 0 {
+			return newViolation(r, broken, "students %s share a classroom", strings.Join(broken, ", "))
+		}
+	}
+
+	return nil
+
+}
+
+// placementsByStudent maps each student to the classrooms they sit in.
+func placementsByStudent(placements []*Placement) map[string]map[primitive.ObjectID]bool {
+
+	classrooms := make(map[string]map[primitive.ObjectID]bool)
+	for _, p := range placements {
+		if classrooms[p.StudentID] == nil {
+			classrooms[p.StudentID] = make(map[primitive.ObjectID]bool)
+		}
+		classrooms[p.StudentID][p.ClassRoomID] = true
+	}
+
+	return classrooms
+
+}
@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is run once a day at Hour:Minute in the scheduler's location, or
+// every Every when it is added with AddIntervalJob.
+type Job struct {
+	Name   string
+	Hour   int
+	Minute int
+	Every  time.Duration
+	Run    func(ctx context.Context) error
+}
+
+// Locker makes sure a job runs on a single replica. runKey identifies one
+// scheduled occurrence so replicas that wake up late do not run it again.
+type Locker interface {
+	RunExclusive(ctx context.Context, name, runKey string, fn func(ctx context.Context) error) (bool, error)
+}
+
+type Scheduler struct {
+	Location *time.Location
+	Locker   Locker
+
+	mu      sync.Mutex
+	jobs    []*Job
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewScheduler creates a scheduler. With a nil locker every replica runs
+// every job.
+func NewScheduler(location *time.Location, locker Locker) *Scheduler {
+	if location == nil {
+		location = time.Local
+	}
+	return &Scheduler{
+		Location: location,
+		Locker:   locker,
+	}
+}
+
+// AddDailyJob registers a job. Jobs must be added before Start.
+func (s *Scheduler) AddDailyJob(job *Job) error {
+
+	if job.Name == "" {
+		return fmt.Errorf("job name is required")
+	}
+
+	if job.Hour < 0 || job.Hour > 23 || job.Minute < 0 || job.Minute > 59 {
+		return fmt.Errorf("job %s: invalid time %02d:%02d", job.Name, job.Hour, job.Minute)
+	}
+
+	job.Every = 0
+
+	return s.add(job)
+
+}
+
+// AddIntervalJob registers a job that runs every job.Every, on the multiples
+// of the interval. Jobs must be added before Start.
+func (s *Scheduler) AddIntervalJob(job *Job) error {
+
+	if job.Name == "" {
+		return fmt.Errorf("job name is required")
+	}
+
+	if job.Every <= 0 {
+		return fmt.Errorf("job %s: invalid interval %s", job.Name, job.Every)
+	}
+
+	return s.add(job)
+
+}
+
+func (s *Scheduler) add(job *Job) error {
+
+	if job.Run == nil {
+		return fmt.Errorf("job %s: run func is required", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("job %s: scheduler already started", job.Name)
+	}
+
+	s.jobs = append(s.jobs, job)
+	return nil
+
+}
+
+// Start runs every job in its own goroutine until Stop is called or ctx is
+// cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	s.started = true
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+
+}
+
+// Stop cancels all jobs and waits for running ones to return.
+func (s *Scheduler) Stop() {
+
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	s.wg.Wait()
+
+}
+
+func (s *Scheduler) loop(ctx context.Context, job *Job) {
+
+	defer s.wg.Done()
+
+	for {
+		now := time.Now().In(s.Location)
+		next := NextRun(now, job.Hour, job.Minute)
+		if job.Every > 0 {
+			next = NextTick(now, job.Every)
+		}
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.run(ctx, job, next)
+	}
+
+}
+
+func (s *Scheduler) run(ctx context.Context, job *Job, scheduledAt time.Time) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[ERROR] scheduled job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	started := time.Now()
+	log.Printf("Running scheduled job %s", job.Name)
+
+	if s.Locker != nil {
+		ran, err := s.Locker.RunExclusive(ctx, "job:"+job.Name, scheduledAt.Format(time.RFC3339), job.Run)
+		if err != nil {
+			log.Printf("[ERROR] scheduled job %s failed: %v", job.Name, err)
+			return
+		}
+		if !ran {
+			log.Printf("Skipped scheduled job %s, it runs on another instance", job.Name)
+			return
+		}
+	} else if err := job.Run(ctx); err != nil {
+		log.Printf("[ERROR] scheduled job %s failed: %v", job.Name, err)
+		return
+	}
+
+	log.Printf("Scheduled job %s finished in %s", job.Name, time.Since(started))
+
+}
+
+// NextRun returns the first hour:minute strictly after now.
+func NextRun(now time.Time, hour, minute int) time.Time {
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+
+}
+
+// NextTick returns the first multiple of every strictly after now.
+func NextTick(now time.Time, every time.Duration) time.Time {
+	return now.Truncate(every).Add(every)
+}
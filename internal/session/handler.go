@@ -0,0 +1,118 @@
+package session
+
+import (
+	"classroom-service/helper"
+	"classroom-service/internal/user"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	SessionService SessionService
+}
+
+func NewSessionHandler(sessionService SessionService) *SessionHandler {
+	return &SessionHandler{
+		SessionService: sessionService,
+	}
+}
+
+func sessionErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrSessionNotFound),
+		errors.Is(err, ErrClassroomNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, ErrSessionConflict):
+		return http.StatusConflict, helper.ErrConflict
+	case errors.Is(err, user.ErrOrganizationForbidden):
+		return http.StatusForbidden, helper.ErrForbidden
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *SessionHandler) SaveSession(c *gin.Context) {
+
+	var req SaveSessionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	session, err := h.SessionService.SaveSession(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := sessionErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Save Session Successfully", session)
+
+}
+
+func (h *SessionHandler) GetSessions(c *gin.Context) {
+
+	organizationID := c.Query("organization_id")
+	classroomID := c.Query("classroom_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	sessions, err := h.SessionService.GetSessions(ctx, organizationID, classroomID)
+
+	if err != nil {
+		statusCode, errorCode := sessionErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Sessions Successfully", sessions)
+
+}
+
+func (h *SessionHandler) DeleteSession(c *gin.Context) {
+
+	var req DeleteSessionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	err := h.SessionService.DeleteSession(c, &req)
+
+	if err != nil {
+		statusCode, errorCode := sessionErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete Session Successfully", nil)
+
+}
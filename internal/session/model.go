@@ -0,0 +1,41 @@
+package session
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is a named time window within a classroom day, e.g. morning and
+// afternoon. Sessions are configured for a whole organization or, when
+// ClassRoomID is set, for one classroom; a classroom with its own sessions
+// ignores the organization ones. Times are "HH:MM" in the center's local time.
+type Session struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id"`
+	OrganizationID string              `json:"organization_id" bson:"organization_id"`
+	ClassRoomID    *primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
+	Name           string              `json:"name" bson:"name"`
+	StartTime      string              `json:"start_time" bson:"start_time"`
+	EndTime        string              `json:"end_time" bson:"end_time"`
+	CreatedBy      string              `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// Overlaps reports whether the time windows of both sessions intersect.
+// Times are zero-padded "HH:MM", so they compare as strings.
+func (s *Session) Overlaps(other *Session) bool {
+	return s.StartTime < other.EndTime && other.StartTime < s.EndTime
+}
+
+// Conflicts reports whether two bookings on the same day collide. A nil
+// session stands for the whole day and collides with everything.
+func Conflicts(a, b *Session) bool {
+
+	if a == nil || b == nil {
+		return true
+	}
+
+	return a.ID == b.ID || a.Overlaps(b)
+
+}
@@ -0,0 +1,119 @@
+package session
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepository interface {
+	SaveSession(ctx context.Context, session *Session) error
+	GetSessionByID(ctx context.Context, id primitive.ObjectID) (*Session, error)
+	GetSessionsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*Session, error)
+	GetSessionsByOrganization(ctx context.Context, organizationID string) ([]*Session, error)
+	GetSessionsByClassroom(ctx context.Context, classroomID primitive.ObjectID) ([]*Session, error)
+	DeleteSession(ctx context.Context, id primitive.ObjectID) error
+	GetClassroomOrganizationID(ctx context.Context, classroomID primitive.ObjectID) (*string, error)
+}
+
+type sessionRepository struct {
+	sessionCollection   *mongo.Collection
+	classroomCollection *mongo.Collection
+}
+
+func NewSessionRepository(sessionCollection, classroomCollection *mongo.Collection) SessionRepository {
+	return &sessionRepository{
+		sessionCollection:   sessionCollection,
+		classroomCollection: classroomCollection,
+	}
+}
+
+func (r *sessionRepository) SaveSession(ctx context.Context, session *Session) error {
+
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := r.sessionCollection.ReplaceOne(ctx, bson.M{"_id": session.ID}, session, opts)
+	return err
+
+}
+
+func (r *sessionRepository) GetSessionByID(ctx context.Context, id primitive.ObjectID) (*Session, error) {
+
+	var session Session
+	err := r.sessionCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &session, nil
+
+}
+
+func (r *sessionRepository) GetSessionsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*Session, error) {
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return r.findSessions(ctx, bson.M{"_id": bson.M{"$in": ids}})
+
+}
+
+func (r *sessionRepository) GetSessionsByOrganization(ctx context.Context, organizationID string) ([]*Session, error) {
+	return r.findSessions(ctx, bson.M{"organization_id": organizationID, "class_room_id": nil})
+}
+
+func (r *sessionRepository) GetSessionsByClassroom(ctx context.Context, classroomID primitive.ObjectID) ([]*Session, error) {
+	return r.findSessions(ctx, bson.M{"class_room_id": classroomID})
+}
+
+func (r *sessionRepository) findSessions(ctx context.Context, filter bson.M) ([]*Session, error) {
+
+	opts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}})
+
+	cursor, err := r.sessionCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+
+}
+
+func (r *sessionRepository) DeleteSession(ctx context.Context, id primitive.ObjectID) error {
+
+	_, err := r.sessionCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+
+}
+
+func (r *sessionRepository) GetClassroomOrganizationID(ctx context.Context, classroomID primitive.ObjectID) (*string, error) {
+
+	opts := options.FindOne().SetProjection(bson.M{"organization_id": 1})
+
+	var classroom struct {
+		OrganizationID string `bson:"organization_id"`
+	}
+	err := r.classroomCollection.FindOne(ctx, bson.M{"_id": classroomID}, opts).Decode(&classroom)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &classroom.OrganizationID, nil
+
+}
@@ -0,0 +1,14 @@
+package session
+
+type SaveSessionRequest struct {
+	ID             string `json:"id"`
+	OrganizationID string `json:"organization_id"`
+	ClassroomID    string `json:"classroom_id"`
+	Name           string `json:"name"`
+	StartTime      string `json:"start_time"`
+	EndTime        string `json:"end_time"`
+}
+
+type DeleteSessionRequest struct {
+	ID string `json:"id"`
+}
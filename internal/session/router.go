@@ -0,0 +1,17 @@
+package session
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *SessionHandler) {
+	sessionGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Session
+		sessionGroup.POST("/sessions", handler.SaveSession)
+		sessionGroup.GET("/sessions", handler.GetSessions)
+		sessionGroup.POST("/remove/sessions", handler.DeleteSession)
+	}
+}
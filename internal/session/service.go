@@ -0,0 +1,354 @@
+package session
+
+import (
+	"classroom-service/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrSessionNotFound       = errors.New("session not found")
+	ErrClassroomNotFound     = errors.New("classroom not found")
+	ErrSessionNotInClassroom = errors.New("session is not configured for this classroom")
+	ErrSessionConflict       = errors.New("session overlaps another session")
+)
+
+type SessionService interface {
+	SaveSession(ctx context.Context, req *SaveSessionRequest, userID string) (*Session, error)
+	GetSessions(ctx context.Context, organizationID, classroomID string) ([]*Session, error)
+	DeleteSession(ctx context.Context, req *DeleteSessionRequest) error
+}
+
+type sessionService struct {
+	SessionRepository SessionRepository
+	UserService       user.UserService
+}
+
+func NewSessionService(sessionRepository SessionRepository, userService user.UserService) SessionService {
+	return &sessionService{
+		SessionRepository: sessionRepository,
+		UserService:       userService,
+	}
+}
+
+// EffectiveSessions returns the sessions that apply to a classroom: its own
+// when it has any, otherwise the ones of its organization.
+func EffectiveSessions(ctx context.Context, repo SessionRepository, classroomID primitive.ObjectID) ([]*Session, error) {
+
+	sessions, err := repo.GetSessionsByClassroom(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sessions) > 0 {
+		return sessions, nil
+	}
+
+	orgID, err := repo.GetClassroomOrganizationID(ctx, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if orgID == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClassroomNotFound, classroomID.Hex())
+	}
+
+	return repo.GetSessionsByOrganization(ctx, *orgID)
+
+}
+
+// ResolveForClassroom checks that sessionID is one of the classroom's
+// effective sessions and returns it. An empty id means the whole day and
+// resolves to nil.
+func ResolveForClassroom(ctx context.Context, repo SessionRepository, classroomID primitive.ObjectID, sessionID string) (*Session, error) {
+
+	if sessionID == "" {
+		return nil, nil
+	}
+
+	objSessionID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := EffectiveSessions(ctx, repo, classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range sessions {
+		if s.ID == objSessionID {
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrSessionNotInClassroom, sessionID)
+
+}
+
+// FindConflict returns the index of the first booking in others that
+// collides with target on the same day, or -1 when none does. Bookings
+// without a session, or whose session was deleted, take the whole day.
+func FindConflict(ctx context.Context, repo SessionRepository, target *primitive.ObjectID, others []*primitive.ObjectID) (int, error) {
+
+	if len(others) == 0 {
+		return -1, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(others)+1)
+	if target != nil {
+		ids = append(ids, *target)
+	}
+	for _, id := range others {
+		if id != nil {
+			ids = append(ids, *id)
+		}
+	}
+
+	sessions, err := repo.GetSessionsByIDs(ctx, ids)
+	if err != nil {
+		return -1, err
+	}
+
+	byID := make(map[primitive.ObjectID]*Session, len(sessions))
+	for _, s := range sessions {
+		byID[s.ID] = s
+	}
+
+	lookup := func(id *primitive.ObjectID) *Session {
+		if id == nil {
+			return nil
+		}
+		return byID[*id]
+	}
+
+	for i, id := range others {
+		if Conflicts(lookup(target), lookup(id)) {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+
+}
+
+func parseTimeOfDay(field, value string) (string, error) {
+
+	if value == "" {
+		return "", fmt.Errorf("%s is required", field)
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return "", fmt.Errorf("%s must be HH:MM", field)
+	}
+
+	return t.Format("15:04"), nil
+
+}
+
+func (s *sessionService) SaveSession(ctx context.Context, req *SaveSessionRequest, userID string) (*Session, error) {
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	startTime, err := parseTimeOfDay("start_time", req.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	endTime, err := parseTimeOfDay("end_time", req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	if endTime <= startTime {
+		return nil, errors.New("end_time must be after start_time")
+	}
+
+	session := &Session{
+		ID:        primitive.NewObjectID(),
+		Name:      name,
+		StartTime: startTime,
+		EndTime:   endTime,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	var existingOrganizationID string
+	if req.ID != "" {
+		objID, err := primitive.ObjectIDFromHex(req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := s.SessionRepository.GetSessionByID(ctx, objID)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing == nil {
+			return nil, ErrSessionNotFound
+		}
+
+		if _, err := user.ResolveOrganization(ctx, s.UserService, existing.OrganizationID); err != nil {
+			return nil, err
+		}
+
+		session.ID = existing.ID
+		session.CreatedBy = existing.CreatedBy
+		session.CreatedAt = existing.CreatedAt
+		existingOrganizationID = existing.OrganizationID
+	}
+
+	var siblings []*Session
+	if req.ClassroomID != "" {
+		objClassroomID, err := primitive.ObjectIDFromHex(req.ClassroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		orgID, err := s.SessionRepository.GetClassroomOrganizationID(ctx, objClassroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		if orgID == nil {
+			return nil, fmt.Errorf("%w: %s", ErrClassroomNotFound, req.ClassroomID)
+		}
+
+		if _, err := user.ResolveOrganization(ctx, s.UserService, *orgID); err != nil {
+			return nil, err
+		}
+
+		session.OrganizationID = *orgID
+		session.ClassRoomID = &objClassroomID
+
+		siblings, err = s.SessionRepository.GetSessionsByClassroom(ctx, objClassroomID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		orgID, err := user.ResolveOrganization(ctx, s.UserService, req.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		session.OrganizationID = orgID
+
+		siblings, err = s.SessionRepository.GetSessionsByOrganization(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.ID != "" && session.OrganizationID != existingOrganizationID {
+		return nil, errors.New("a session cannot be moved to another organization")
+	}
+
+	// Sessions of one classroom day must not overlap, otherwise a student
+	// or teacher could be booked twice at the same time.
+	for _, other := range siblings {
+		if other.ID == session.ID {
+			continue
+		}
+		if strings.EqualFold(other.Name, session.Name) {
+			return nil, fmt.Errorf("%w: name %q is already used", ErrSessionConflict, session.Name)
+		}
+		if session.Overlaps(other) {
+			return nil, fmt.Errorf("%w: %s (%s-%s)", ErrSessionConflict, other.Name, other.StartTime, other.EndTime)
+		}
+	}
+
+	if err := s.SessionRepository.SaveSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+
+}
+
+func (s *sessionService) GetSessions(ctx context.Context, organizationID, classroomID string) ([]*Session, error) {
+
+	var (
+		sessions []*Session
+		err      error
+	)
+
+	if classroomID != "" {
+		objClassroomID, err := primitive.ObjectIDFromHex(classroomID)
+		if err != nil {
+			return nil, err
+		}
+
+		orgID, err := s.SessionRepository.GetClassroomOrganizationID(ctx, objClassroomID)
+		if err != nil {
+			return nil, err
+		}
+		if orgID == nil {
+			return nil, fmt.Errorf("%w: %s", ErrClassroomNotFound, classroomID)
+		}
+
+		if _, err := user.ResolveOrganization(ctx, s.UserService, *orgID); err != nil {
+			return nil, err
+		}
+
+		sessions, err = EffectiveSessions(ctx, s.SessionRepository, objClassroomID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		organizationID, err = user.ResolveOrganization(ctx, s.UserService, organizationID)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions, err = s.SessionRepository.GetSessionsByOrganization(ctx, organizationID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sessions == nil {
+		sessions = make([]*Session, 0)
+	}
+
+	return sessions, nil
+
+}
+
+func (s *sessionService) DeleteSession(ctx context.Context, req *DeleteSessionRequest) error {
+
+	if req.ID == "" {
+		return errors.New("id is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(req.ID)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.SessionRepository.GetSessionByID(ctx, objID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return ErrSessionNotFound
+	}
+
+	if _, err := user.ResolveOrganization(ctx, s.UserService, existing.OrganizationID); err != nil {
+		return err
+	}
+
+	return s.SessionRepository.DeleteSession(ctx, objID)
+
+}
@@ -0,0 +1,108 @@
+package teacher
+
+import (
+	"classroom-service/helper"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeacherHandler struct {
+	TeacherService TeacherService
+}
+
+func NewTeacherHandler(teacherService TeacherService) *TeacherHandler {
+	return &TeacherHandler{
+		TeacherService: teacherService,
+	}
+}
+
+func teacherErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrTeacherProfileNotFound),
+		errors.Is(err, ErrTeacherNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *TeacherHandler) SaveTeacherProfile(c *gin.Context) {
+
+	var req SaveTeacherProfileRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	profile, err := h.TeacherService.SaveTeacherProfile(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := teacherErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Save Teacher Profile Successfully", profile)
+
+}
+
+func (h *TeacherHandler) GetTeacherProfile(c *gin.Context) {
+
+	teacherID := c.Param("teacher_id")
+	if teacherID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("teacher_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	profile, err := h.TeacherService.GetTeacherProfile(c, teacherID)
+
+	if err != nil {
+		statusCode, errorCode := teacherErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Teacher Profile Successfully", profile)
+
+}
+
+func (h *TeacherHandler) DeleteTeacherProfile(c *gin.Context) {
+
+	var req DeleteTeacherProfileRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	err := h.TeacherService.DeleteTeacherProfile(c, &req)
+
+	if err != nil {
+		statusCode, errorCode := teacherErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Delete Teacher Profile Successfully", nil)
+
+}
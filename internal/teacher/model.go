@@ -0,0 +1,133 @@
+package teacher
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TeacherProfile struct {
+	ID                 primitive.ObjectID      `json:"id" bson:"_id"`
+	TeacherID          string                  `json:"teacher_id" bson:"teacher_id"`
+	OrganizationID     string                  `json:"organization_id" bson:"organization_id"`
+	WeeklyAvailability []time.Weekday          `json:"weekly_availability" bson:"weekly_availability"`
+	Exceptions         []AvailabilityException `json:"exceptions" bson:"exceptions"`
+	AgeGroups          []string                `json:"age_groups" bson:"age_groups"`
+	Languages          []string                `json:"languages" bson:"languages"`
+	Certifications     []string                `json:"certifications" bson:"certifications"`
+	CreatedBy          string                  `json:"created_by" bson:"created_by"`
+	CreatedAt          time.Time               `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time               `json:"updated_at" bson:"updated_at"`
+}
+
+// AvailabilityException overrides the weekly pattern for one day, e.g. a
+// day off on a working weekday or an extra day on a weekend.
+type AvailabilityException struct {
+	Date      time.Time `json:"date" bson:"date"`
+	Available bool      `json:"available" bson:"available"`
+	Reason    *string   `json:"reason" bson:"reason"`
+}
+
+// ClassroomRequirements is what a classroom asks of the teachers placed in it.
+type ClassroomRequirements struct {
+	ClassroomID            primitive.ObjectID `bson:"_id"`
+	OrganizationID         string             `bson:"organization_id"`
+	AgeGroup               *string            `bson:"age_group"`
+	Languages              []string           `bson:"languages"`
+	RequiredCertifications []string           `bson:"required_certifications"`
+}
+
+// IsAvailableOn reports whether the teacher works on date. Exceptions win
+// over the weekly pattern and an empty pattern means every day.
+func (p *TeacherProfile) IsAvailableOn(date time.Time) bool {
+
+	for _, e := range p.Exceptions {
+		if sameDay(e.Date, date) {
+			return e.Available
+		}
+	}
+
+	if len(p.WeeklyAvailability) == 0 {
+		return true
+	}
+
+	for _, w := range p.WeeklyAvailability {
+		if w == date.Weekday() {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// MissingQualifications lists what the teacher lacks for the classroom.
+func (p *TeacherProfile) MissingQualifications(req *ClassroomRequirements) []string {
+
+	if req == nil {
+		return nil
+	}
+
+	var missing []string
+
+	if req.AgeGroup != nil && *req.AgeGroup != "" && !containsFold(p.AgeGroups, *req.AgeGroup) {
+		missing = append(missing, fmt.Sprintf("not qualified for age group %s", *req.AgeGroup))
+	}
+
+	if len(req.Languages) > 0 {
+		speaks := false
+		for _, l := range req.Languages {
+			if containsFold(p.Languages, l) {
+				speaks = true
+				break
+			}
+		}
+		if !speaks {
+			missing = append(missing, fmt.Sprintf("does not speak any of %s", strings.Join(req.Languages, ", ")))
+		}
+	}
+
+	for _, c := range req.RequiredCertifications {
+		if !containsFold(p.Certifications, c) {
+			missing = append(missing, fmt.Sprintf("missing certification %s", c))
+		}
+	}
+
+	return missing
+
+}
+
+// CheckEligibility returns the reasons a teacher cannot be placed in the
+// classroom, including availability when date is set. Teachers without a
+// profile are not constrained; every path that places teachers follows this
+// rule.
+func CheckEligibility(profile *TeacherProfile, req *ClassroomRequirements, date *time.Time) []string {
+
+	if profile == nil {
+		return nil
+	}
+
+	reasons := profile.MissingQualifications(req)
+
+	if date != nil && !profile.IsAvailableOn(*date) {
+		reasons = append(reasons, fmt.Sprintf("not available on %s", date.Format("2006-01-02")))
+	}
+
+	return reasons
+
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package teacher
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TeacherRepository interface {
+	SaveTeacherProfile(ctx context.Context, profile *TeacherProfile) error
+	GetTeacherProfileByTeacherID(ctx context.Context, teacherID string) (*TeacherProfile, error)
+	GetTeacherProfilesByTeacherIDs(ctx context.Context, teacherIDs []string) ([]*TeacherProfile, error)
+	GetTeacherProfilesByOrganization(ctx context.Context, organizationID string) ([]*TeacherProfile, error)
+	DeleteTeacherProfile(ctx context.Context, teacherID string) error
+	GetClassroomRequirements(ctx context.Context, classroomID primitive.ObjectID) (*ClassroomRequirements, error)
+}
+
+type teacherRepository struct {
+	teacherProfileCollection *mongo.Collection
+	classroomCollection      *mongo.Collection
+}
+
+func NewTeacherRepository(teacherProfileCollection, classroomCollection *mongo.Collection) TeacherRepository {
+	return &teacherRepository{
+		teacherProfileCollection: teacherProfileCollection,
+		classroomCollection:      classroomCollection,
+	}
+}
+
+func (r *teacherRepository) SaveTeacherProfile(ctx context.Context, profile *TeacherProfile) error {
+
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := r.teacherProfileCollection.ReplaceOne(ctx, bson.M{"teacher_id": profile.TeacherID}, profile, opts)
+	return err
+
+}
+
+func (r *teacherRepository) GetTeacherProfileByTeacherID(ctx context.Context, teacherID string) (*TeacherProfile, error) {
+
+	var profile TeacherProfile
+	err := r.teacherProfileCollection.FindOne(ctx, bson.M{"teacher_id": teacherID}).Decode(&profile)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &profile, nil
+
+}
+
+func (r *teacherRepository) GetTeacherProfilesByTeacherIDs(ctx context.Context, teacherIDs []string) ([]*TeacherProfile, error) {
+
+	if len(teacherIDs) == 0 {
+		return nil, nil
+	}
+
+	return r.findProfiles(ctx, bson.M{"teacher_id": bson.M{"$in": teacherIDs}})
+
+}
+
+func (r *teacherRepository) GetTeacherProfilesByOrganization(ctx context.Context, organizationID string) ([]*TeacherProfile, error) {
+	return r.findProfiles(ctx, bson.M{"organization_id": organizationID})
+}
+
+func (r *teacherRepository) findProfiles(ctx context.Context, filter bson.M) ([]*TeacherProfile, error) {
+
+	cursor, err := r.teacherProfileCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var profiles []*TeacherProfile
+	if err := cursor.All(ctx, &profiles); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+
+}
+
+func (r *teacherRepository) DeleteTeacherProfile(ctx context.Context, teacherID string) error {
+
+	_, err := r.teacherProfileCollection.DeleteOne(ctx, bson.M{"teacher_id": teacherID})
+	return err
+
+}
+
+func (r *teacherRepository) GetClassroomRequirements(ctx context.Context, classroomID primitive.ObjectID) (*ClassroomRequirements, error) {
+
+	opts := options.FindOne().SetProjection(bson.M{
+		"organization_id":         1,
+		"age_group":               1,
+		"languages":               1,
+		"required_certifications": 1,
+	})
+
+	var req ClassroomRequirements
+	err := r.classroomCollection.FindOne(ctx, bson.M{"_id": classroomID}, opts).Decode(&req)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &req, nil
+
+}
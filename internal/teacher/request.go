@@ -0,0 +1,21 @@
+package teacher
+
+type SaveTeacherProfileRequest struct {
+	TeacherID          string                         `json:"teacher_id"`
+	OrganizationID     string                         `json:"organization_id"`
+	WeeklyAvailability []int                          `json:"weekly_availability"`
+	Exceptions         []AvailabilityExceptionRequest `json:"exceptions"`
+	AgeGroups          []string                       `json:"age_groups"`
+	Languages          []string                       `json:"languages"`
+	Certifications     []string                       `json:"certifications"`
+}
+
+type AvailabilityExceptionRequest struct {
+	Date      string  `json:"date"`
+	Available bool    `json:"available"`
+	Reason    *string `json:"reason"`
+}
+
+type DeleteTeacherProfileRequest struct {
+	TeacherID string `json:"teacher_id"`
+}
@@ -0,0 +1,17 @@
+package teacher
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *TeacherHandler) {
+	teacherGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Teacher Profile
+		teacherGroup.POST("/teacher-profiles", handler.SaveTeacherProfile)
+		teacherGroup.GET("/teacher-profiles/:teacher_id", handler.GetTeacherProfile)
+		teacherGroup.POST("/remove/teacher-profiles", handler.DeleteTeacherProfile)
+	}
+}
@@ -0,0 +1,159 @@
+package teacher
+
+import (
+	"classroom-service/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrTeacherProfileNotFound = errors.New("teacher profile not found")
+	ErrTeacherNotFound        = errors.New("teacher not found")
+	ErrTeacherNotEligible     = errors.New("teacher is not eligible")
+)
+
+// NotEligibleError wraps ErrTeacherNotEligible with the reasons.
+func NotEligibleError(teacherID string, reasons []string) error {
+	return fmt.Errorf("%w (teacher_id=%s): %s", ErrTeacherNotEligible, teacherID, strings.Join(reasons, "; "))
+}
+
+type TeacherService interface {
+	SaveTeacherProfile(ctx context.Context, req *SaveTeacherProfileRequest, userID string) (*TeacherProfile, error)
+	GetTeacherProfile(ctx context.Context, teacherID string) (*TeacherProfile, error)
+	DeleteTeacherProfile(ctx context.Context, req *DeleteTeacherProfileRequest) error
+}
+
+type teacherService struct {
+	TeacherRepository TeacherRepository
+	UserService       user.UserService
+}
+
+func NewTeacherService(teacherRepository TeacherRepository, userService user.UserService) TeacherService {
+	return &teacherService{
+		TeacherRepository: teacherRepository,
+		UserService:       userService,
+	}
+}
+
+func (s *teacherService) SaveTeacherProfile(ctx context.Context, req *SaveTeacherProfileRequest, userID string) (*TeacherProfile, error) {
+
+	if req.TeacherID == "" {
+		return nil, errors.New("teacher_id is required")
+	}
+
+	weekly := make([]time.Weekday, 0, len(req.WeeklyAvailability))
+	for _, w := range req.WeeklyAvailability {
+		if w < int(time.Sunday) || w > int(time.Saturday) {
+			return nil, fmt.Errorf("invalid weekday %d, must be between 0 (sunday) and 6 (saturday)", w)
+		}
+		weekly = append(weekly, time.Weekday(w))
+	}
+
+	exceptions := make([]AvailabilityException, 0, len(req.Exceptions))
+	for _, e := range req.Exceptions {
+		if e.Date == "" {
+			return nil, errors.New("exception date is required")
+		}
+		dateParse, err := time.Parse("2006-01-02", e.Date)
+		if err != nil {
+			return nil, err
+		}
+		exceptions = append(exceptions, AvailabilityException{
+			Date:      dateParse,
+			Available: e.Available,
+			Reason:    e.Reason,
+		})
+	}
+
+	infor, err := s.UserService.GetTeacherInfor(ctx, req.TeacherID)
+	if err != nil || infor == nil {
+		return nil, ErrTeacherNotFound
+	}
+
+	orgID := req.OrganizationID
+	if orgID == "" {
+		currentUser, err := s.UserService.GetCurrentUser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if currentUser == nil || currentUser.OrganizationAdmin == nil {
+			return nil, errors.New("user not found")
+		}
+		orgID = currentUser.OrganizationAdmin.ID
+	}
+
+	existing, err := s.TeacherRepository.GetTeacherProfileByTeacherID(ctx, req.TeacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &TeacherProfile{
+		ID:                 primitive.NewObjectID(),
+		TeacherID:          req.TeacherID,
+		OrganizationID:     orgID,
+		WeeklyAvailability: weekly,
+		Exceptions:         exceptions,
+		AgeGroups:          req.AgeGroups,
+		Languages:          req.Languages,
+		Certifications:     req.Certifications,
+		CreatedBy:          userID,
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
+
+	if existing != nil {
+		profile.ID = existing.ID
+		profile.CreatedBy = existing.CreatedBy
+		profile.CreatedAt = existing.CreatedAt
+	}
+
+	if err := s.TeacherRepository.SaveTeacherProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+
+}
+
+func (s *teacherService) GetTeacherProfile(ctx context.Context, teacherID string) (*TeacherProfile, error) {
+
+	if teacherID == "" {
+		return nil, errors.New("teacher_id is required")
+	}
+
+	profile, err := s.TeacherRepository.GetTeacherProfileByTeacherID(ctx, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	if profile == nil {
+		return nil, ErrTeacherProfileNotFound
+	}
+
+	return profile, nil
+
+}
+
+func (s *teacherService) DeleteTeacherProfile(ctx context.Context, req *DeleteTeacherProfileRequest) error {
+
+	if req.TeacherID == "" {
+		return errors.New("teacher_id is required")
+	}
+
+	existing, err := s.TeacherRepository.GetTeacherProfileByTeacherID(ctx, req.TeacherID)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return ErrTeacherProfileNotFound
+	}
+
+	return s.TeacherRepository.DeleteTeacherProfile(ctx, req.TeacherID)
+
+}
@@ -8,11 +8,14 @@ type APIGateWayResponse[T any] struct {
 	Data       T      `json:"data"`
 }
 
+// UserInfor describes a user for display. DateOfBirth is only filled in
+// for students, when the gateway has it.
 type UserInfor struct {
 	UserID         string          `json:"user_id"`
 	UserName       string          `json:"user_name"`
 	Avartar        Avatar          `json:"avatar"`
 	OrganizationID string          `json:"organization_id"`
+	DateOfBirth    *time.Time      `json:"-"`
 	SeenStudents   map[string]bool `json:"-"`
 }
 
@@ -0,0 +1,57 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrOrganizationForbidden = errors.New("organization does not belong to the current user")
+
+// BelongsTo reports whether the user administers or is a member of the
+// organization.
+func (u *CurrentUser) BelongsTo(organizationID string) bool {
+
+	if u.OrganizationAdmin != nil && u.OrganizationAdmin.ID == organizationID {
+		return true
+	}
+
+	for _, id := range u.Organization {
+		if id == organizationID {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// ResolveOrganization returns the organization the current user acts for.
+// An empty organizationID stands for the organization they administer; any
+// other organization has to be one of theirs unless they are a super admin.
+// Services call it with the organization of a stored document before
+// changing it, so one tenant cannot touch another tenant's data by id.
+func ResolveOrganization(ctx context.Context, userService UserService, organizationID string) (string, error) {
+
+	currentUser, err := userService.GetCurrentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	if currentUser == nil {
+		return "", errors.New("user not found")
+	}
+
+	if organizationID == "" {
+		if currentUser.OrganizationAdmin == nil {
+			return "", errors.New("user not found")
+		}
+		return currentUser.OrganizationAdmin.ID, nil
+	}
+
+	if !currentUser.IsSuperAdmin && !currentUser.BelongsTo(organizationID) {
+		return "", fmt.Errorf("%w: %s", ErrOrganizationForbidden, organizationID)
+	}
+
+	return organizationID, nil
+
+}
@@ -173,6 +173,7 @@ func (u *userService) GetStudentInfor(ctx context.Context, studentID string) (*U
 		UserName:       fmt.Sprintf("%v", innerData["name"]),
 		OrganizationID: fmt.Sprintf("%v", innerData["organization_id"]),
 		Avartar:        avatar,
+		DateOfBirth:    castToDate(innerData["dob"], innerData["date_of_birth"]),
 	}, nil
 
 }
@@ -567,6 +568,23 @@ func castToInt64(v interface{}) int64 {
 	}
 }
 
+// castToDate parses the first of values that holds a date, either a plain
+// day or a full timestamp.
+func castToDate(values ...interface{}) *time.Time {
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok || s == "" {
+			continue
+		}
+		for _, layout := range []string{"2006-01-02", time.RFC3339} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return &t
+			}
+		}
+	}
+	return nil
+}
+
 func castToBool(v interface{}) bool {
 	switch val := v.(type) {
 	case bool:
@@ -0,0 +1,201 @@
+package waitlist
+
+import (
+	"classroom-service/helper"
+	"classroom-service/pkg/constants"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WaitlistHandler struct {
+	WaitlistService WaitlistService
+}
+
+func NewWaitlistHandler(waitlistService WaitlistService) *WaitlistHandler {
+	return &WaitlistHandler{
+		WaitlistService: waitlistService,
+	}
+}
+
+func waitlistErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrEntryNotFound),
+		errors.Is(err, ErrClassroomNotFound),
+		errors.Is(err, ErrStudentNotFound):
+		return http.StatusNotFound, helper.ErrNotFound
+	case errors.Is(err, ErrAlreadyWaiting),
+		errors.Is(err, ErrOfferExpired),
+		errors.Is(err, ErrSlotTaken):
+		return http.StatusConflict, helper.ErrConflict
+	case errors.Is(err, ErrNotOfferHolder):
+		return http.StatusForbidden, helper.ErrForbidden
+	default:
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	}
+}
+
+func (h *WaitlistHandler) Enqueue(c *gin.Context) {
+
+	var req EnqueueRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	entry, err := h.WaitlistService.Enqueue(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := waitlistErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Enqueue Waitlist Successfully", entry)
+
+}
+
+func (h *WaitlistHandler) Dequeue(c *gin.Context) {
+
+	var req WaitlistEntryRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.WaitlistService.Dequeue(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := waitlistErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Dequeue Waitlist Successfully", nil)
+
+}
+
+func (h *WaitlistHandler) GetWaitlist(c *gin.Context) {
+
+	classroomID := c.Query("classroom_id")
+	if classroomID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("classroom_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	termID := c.Query("term_id")
+	if termID == "" {
+		helper.SendError(c, http.StatusBadRequest, errors.New("term_id is required"), "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	entries, err := h.WaitlistService.GetWaitlist(ctx, classroomID, termID)
+
+	if err != nil {
+		statusCode, errorCode := waitlistErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Get Waitlist Successfully", entries)
+
+}
+
+func (h *WaitlistHandler) AcceptOffer(c *gin.Context) {
+
+	var req WaitlistEntryRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.WaitlistService.AcceptOffer(ctx, &req, userID.(string))
+
+	if err != nil {
+		statusCode, errorCode := waitlistErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Accept Waitlist Offer Successfully", nil)
+
+}
+
+func (h *WaitlistHandler) DeclineOffer(c *gin.Context) {
+
+	var req WaitlistEntryRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, "INVALID_REQUEST")
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.WaitlistService.DeclineOffer(ctx, &req)
+
+	if err != nil {
+		statusCode, errorCode := waitlistErrorStatus(err)
+		helper.SendError(c, statusCode, err, errorCode)
+		return
+	}
+
+	helper.SendSuccess(c, http.StatusOK, "Decline Waitlist Offer Successfully", nil)
+
+}
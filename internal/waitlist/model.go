@@ -0,0 +1,47 @@
+package waitlist
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatusWaiting  = "waiting"
+	StatusOffered  = "offered"
+	StatusAccepted = "accepted"
+	StatusDeclined = "declined"
+	StatusExpired  = "expired"
+)
+
+// WaitlistEntry is a student waiting for a slot in a classroom for a term.
+// Entries with a higher Priority are offered first, ties go to the earliest.
+type WaitlistEntry struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	ClassRoomID primitive.ObjectID `json:"class_room_id" bson:"class_room_id"`
+	TermID      primitive.ObjectID `json:"term_id" bson:"term_id"`
+	StudentID   string             `json:"student_id" bson:"student_id"`
+	Priority    int                `json:"priority" bson:"priority"`
+	Note        *string            `json:"note" bson:"note"`
+	Status      string             `json:"status" bson:"status"`
+	Offer       *SlotOffer         `json:"offer" bson:"offer"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// SlotOffer is a freed slot held for a waiting student until ExpiresAt. Date
+// is set when only one day was freed, otherwise the offer is for the slot of
+// the term template.
+type SlotOffer struct {
+	SlotNumber int                 `json:"slot_number" bson:"slot_number"`
+	SessionID  *primitive.ObjectID `json:"session_id" bson:"session_id"`
+	Date       *time.Time          `json:"date" bson:"date"`
+	OfferedAt  time.Time           `json:"offered_at" bson:"offered_at"`
+	ExpiresAt  time.Time           `json:"expires_at" bson:"expires_at"`
+}
+
+// IsExpired reports whether the offer can no longer be accepted at now.
+func (o *SlotOffer) IsExpired(now time.Time) bool {
+	return !now.Before(o.ExpiresAt)
+}
@@ -0,0 +1,258 @@
+package waitlist
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WaitlistRepository interface {
+	CreateEntry(ctx context.Context, entry *WaitlistEntry) error
+	GetEntryByID(ctx context.Context, id primitive.ObjectID) (*WaitlistEntry, error)
+	GetActiveEntryByStudent(ctx context.Context, classroomID, termID primitive.ObjectID, studentID string) (*WaitlistEntry, error)
+	GetActiveEntries(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*WaitlistEntry, error)
+	GetNextWaiting(ctx context.Context, classroomID, termID primitive.ObjectID) (*WaitlistEntry, error)
+	GetWaitingTermIDs(ctx context.Context, classroomID primitive.ObjectID) ([]primitive.ObjectID, error)
+	OfferToEntry(ctx context.Context, id primitive.ObjectID, offer *SlotOffer) (bool, error)
+	HasActiveOffer(ctx context.Context, classroomID, termID primitive.ObjectID, offer *SlotOffer) (bool, error)
+	UpdateEntryStatus(ctx context.Context, id primitive.ObjectID, from, to string) (bool, error)
+	GetExpiredOffers(ctx context.Context, now time.Time) ([]*WaitlistEntry, error)
+	DeleteEntry(ctx context.Context, id primitive.ObjectID) error
+	GetClassroomOrganizationID(ctx context.Context, classroomID primitive.ObjectID) (*string, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type waitlistRepository struct {
+	waitlistCollection  *mongo.Collection
+	classroomCollection *mongo.Collection
+}
+
+func NewWaitlistRepository(waitlistCollection, classroomCollection *mongo.Collection) WaitlistRepository {
+	return &waitlistRepository{
+		waitlistCollection:  waitlistCollection,
+		classroomCollection: classroomCollection,
+	}
+}
+
+// queueOrder is the order in which waiting students receive offers.
+var queueOrder = bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: 1}}
+
+func (r *waitlistRepository) CreateEntry(ctx context.Context, entry *WaitlistEntry) error {
+
+	_, err := r.waitlistCollection.InsertOne(ctx, entry)
+	return err
+
+}
+
+func (r *waitlistRepository) GetEntryByID(ctx context.Context, id primitive.ObjectID) (*WaitlistEntry, error) {
+	return r.findOne(ctx, bson.M{"_id": id}, nil)
+}
+
+func (r *waitlistRepository) GetActiveEntryByStudent(ctx context.Context, classroomID, termID primitive.ObjectID, studentID string) (*WaitlistEntry, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"student_id":    studentID,
+		"status":        bson.M{"$in": []string{StatusWaiting, StatusOffered}},
+	}
+
+	return r.findOne(ctx, filter, nil)
+
+}
+
+func (r *waitlistRepository) GetActiveEntries(ctx context.Context, classroomID, termID primitive.ObjectID) ([]*WaitlistEntry, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"status":        bson.M{"$in": []string{StatusWaiting, StatusOffered}},
+	}
+
+	return r.find(ctx, filter, options.Find().SetSort(queueOrder))
+
+}
+
+func (r *waitlistRepository) GetNextWaiting(ctx context.Context, classroomID, termID primitive.ObjectID) (*WaitlistEntry, error) {
+
+	filter := bson.M{
+		"class_room_id": classroomID,
+		"term_id":       termID,
+		"status":        StatusWaiting,
+	}
+
+	return r.findOne(ctx, filter, options.FindOne().SetSort(queueOrder))
+
+}
+
+func (r *waitlistRepository) GetWaitingTermIDs(ctx context.Context, classroomID primitive.ObjectID) ([]primitive.ObjectID, error) {
+
+	values, err := r.waitlistCollection.Distinct(ctx, "term_id", bson.M{
+		"class_room_id": classroomID,
+		"status":        StatusWaiting,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+
+}
+
+// OfferToEntry holds the offer for an entry that is still waiting. It
+// returns false when another process changed the entry first.
+func (r *waitlistRepository) OfferToEntry(ctx context.Context, id primitive.ObjectID, offer *SlotOffer) (bool, error) {
+
+	result, err := r.waitlistCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": StatusWaiting},
+		bson.M{"$set": bson.M{
+			"status":     StatusOffered,
+			"offer":      offer,
+			"updated_at": time.Now(),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+
+}
+
+func (r *waitlistRepository) HasActiveOffer(ctx context.Context, classroomID, termID primitive.ObjectID, offer *SlotOffer) (bool, error) {
+
+	filter := bson.M{
+		"class_room_id":     classroomID,
+		"term_id":           termID,
+		"status":            StatusOffered,
+		"offer.slot_number": offer.SlotNumber,
+		"offer.session_id":  offer.SessionID,
+		"offer.date":        offer.Date,
+		"offer.expires_at":  bson.M{"$gt": time.Now()},
+	}
+
+	count, err := r.waitlistCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+
+}
+
+// UpdateEntryStatus moves an entry from one status to another and reports
+// whether it was still in the expected status.
+func (r *waitlistRepository) UpdateEntryStatus(ctx context.Context, id primitive.ObjectID, from, to string) (bool, error) {
+
+	result, err := r.waitlistCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": from},
+		bson.M{"$set": bson.M{
+			"status":     to,
+			"updated_at": time.Now(),
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+
+}
+
+func (r *waitlistRepository) GetExpiredOffers(ctx context.Context, now time.Time) ([]*WaitlistEntry, error) {
+
+	filter := bson.M{
+		"status":           StatusOffered,
+		"offer.expires_at": bson.M{"$lte": now},
+	}
+
+	return r.find(ctx, filter, nil)
+
+}
+
+func (r *waitlistRepository) DeleteEntry(ctx context.Context, id primitive.ObjectID) error {
+
+	_, err := r.waitlistCollection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+
+}
+
+func (r *waitlistRepository) GetClassroomOrganizationID(ctx context.Context, classroomID primitive.ObjectID) (*string, error) {
+
+	opts := options.FindOne().SetProjection(bson.M{"organization_id": 1})
+
+	var classroom struct {
+		OrganizationID string `bson:"organization_id"`
+	}
+	err := r.classroomCollection.FindOne(ctx, bson.M{"_id": classroomID}, opts).Decode(&classroom)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &classroom.OrganizationID, nil
+
+}
+
+func (r *waitlistRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.waitlistCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "class_room_id", Value: 1}, {Key: "term_id", Value: 1}, {Key: "status", Value: 1}, {Key: "priority", Value: -1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "offer.expires_at", Value: 1}}},
+	})
+	return err
+
+}
+
+func (r *waitlistRepository) findOne(ctx context.Context, filter bson.M, opts *options.FindOneOptions) (*WaitlistEntry, error) {
+
+	if opts == nil {
+		opts = options.FindOne()
+	}
+
+	var entry WaitlistEntry
+	err := r.waitlistCollection.FindOne(ctx, filter, opts).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+
+}
+
+func (r *waitlistRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*WaitlistEntry, error) {
+
+	if opts == nil {
+		opts = options.Find()
+	}
+
+	cursor, err := r.waitlistCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*WaitlistEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+
+}
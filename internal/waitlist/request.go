@@ -0,0 +1,13 @@
+package waitlist
+
+type EnqueueRequest struct {
+	ClassroomID string  `json:"classroom_id"`
+	TermID      string  `json:"term_id"`
+	StudentID   string  `json:"student_id"`
+	Priority    int     `json:"priority"`
+	Note        *string `json:"note"`
+}
+
+type WaitlistEntryRequest struct {
+	ID string `json:"id"`
+}
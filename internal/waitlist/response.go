@@ -0,0 +1,9 @@
+package waitlist
+
+import "classroom-service/internal/user"
+
+type WaitlistEntryResponse struct {
+	Position int             `json:"position"`
+	Entry    *WaitlistEntry  `json:"entry"`
+	Student  *user.UserInfor `json:"student"`
+}
@@ -0,0 +1,19 @@
+package waitlist
+
+import (
+	"classroom-service/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *WaitlistHandler) {
+	waitlistGroup := r.Group("/api/v1/admin/classrooms", middleware.Secured())
+	{
+		// Waitlist
+		waitlistGroup.POST("/waitlist", handler.Enqueue)
+		waitlistGroup.GET("/waitlist", handler.GetWaitlist)
+		waitlistGroup.POST("/remove/waitlist", handler.Dequeue)
+		waitlistGroup.POST("/waitlist/accept", handler.AcceptOffer)
+		waitlistGroup.POST("/waitlist/decline", handler.DeclineOffer)
+	}
+}
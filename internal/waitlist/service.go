@@ -0,0 +1,547 @@
+package waitlist
+
+import (
+	"classroom-service/internal/assign"
+	"classroom-service/internal/event"
+	"classroom-service/internal/term"
+	"classroom-service/internal/user"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const DefaultOfferTTL = 48 * time.Hour
+
+var (
+	ErrEntryNotFound     = errors.New("waitlist entry not found")
+	ErrClassroomNotFound = errors.New("classroom not found")
+	ErrStudentNotFound   = errors.New("student not found")
+	ErrAlreadyWaiting    = errors.New("student is already on the waitlist")
+	ErrNoOpenOffer       = errors.New("waitlist entry has no open offer")
+	ErrOfferExpired      = errors.New("offer has expired")
+	ErrSlotTaken         = errors.New("offered slot has been taken")
+	ErrNotOfferHolder    = errors.New("offer belongs to another student")
+)
+
+type WaitlistService interface {
+	Enqueue(ctx context.Context, req *EnqueueRequest, userID string) (*WaitlistEntry, error)
+	Dequeue(ctx context.Context, req *WaitlistEntryRequest) error
+	GetWaitlist(ctx context.Context, classroomID, termID string) ([]*WaitlistEntryResponse, error)
+	AcceptOffer(ctx context.Context, req *WaitlistEntryRequest, userID string) error
+	DeclineOffer(ctx context.Context, req *WaitlistEntryRequest) error
+	ExpireOffers(ctx context.Context) (int, error)
+	// SlotReleased offers slots freed in the assign service.
+	assign.SlotReleaseListener
+}
+
+type waitlistService struct {
+	WaitlistRepository WaitlistRepository
+	AssignService      assign.AssignService
+	AssignRepository   assign.AssignRepository
+	UserService        user.UserService
+	TermService        term.TermService
+	EventPublisher     event.Publisher
+	OfferTTL           time.Duration
+}
+
+func NewWaitlistService(waitlistRepository WaitlistRepository,
+	assignService assign.AssignService,
+	assignRepository assign.AssignRepository,
+	userService user.UserService,
+	termService term.TermService,
+	publisher event.Publisher,
+	offerTTL time.Duration) WaitlistService {
+	if offerTTL <= 0 {
+		offerTTL = DefaultOfferTTL
+	}
+	return &waitlistService{
+		WaitlistRepository: waitlistRepository,
+		AssignService:      assignService,
+		AssignRepository:   assignRepository,
+		UserService:        userService,
+		TermService:        termService,
+		EventPublisher:     publisher,
+		OfferTTL:           offerTTL,
+	}
+}
+
+func offerEvent(eventType string, entry *WaitlistEntry, offer *SlotOffer) *event.Event {
+
+	data := map[string]interface{}{
+		"class_room_id": entry.ClassRoomID.Hex(),
+		"term_id":       entry.TermID.Hex(),
+		"student_id":    entry.StudentID,
+		"slot_number":   offer.SlotNumber,
+		"expires_at":    offer.ExpiresAt,
+	}
+
+	if offer.SessionID != nil {
+		data["session_id"] = offer.SessionID.Hex()
+	}
+
+	if offer.Date != nil {
+		data["date"] = offer.Date.Format("2006-01-02")
+	}
+
+	return event.New(eventType, "waitlist_entry", entry.ID.Hex(), data)
+
+}
+
+func (s *waitlistService) Enqueue(ctx context.Context, req *EnqueueRequest, userID string) (*WaitlistEntry, error) {
+
+	if req.ClassroomID == "" {
+		return nil, errors.New("classroom_id is required")
+	}
+
+	if req.TermID == "" {
+		return nil, errors.New("term_id is required")
+	}
+
+	if req.StudentID == "" {
+		return nil, errors.New("student_id is required")
+	}
+
+	objClassroomID, err := primitive.ObjectIDFromHex(req.ClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	objTermID, err := primitive.ObjectIDFromHex(req.TermID)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID, err := s.WaitlistRepository.GetClassroomOrganizationID(ctx, objClassroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if orgID == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClassroomNotFound, req.ClassroomID)
+	}
+
+	student, err := s.UserService.GetStudentInfor(ctx, req.StudentID)
+	if err != nil || student == nil {
+		return nil, fmt.Errorf("%w: %s", ErrStudentNotFound, req.StudentID)
+	}
+
+	existing, err := s.WaitlistRepository.GetActiveEntryByStudent(ctx, objClassroomID, objTermID, req.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		return nil, ErrAlreadyWaiting
+	}
+
+	entry := &WaitlistEntry{
+		ID:          primitive.NewObjectID(),
+		ClassRoomID: objClassroomID,
+		TermID:      objTermID,
+		StudentID:   req.StudentID,
+		Priority:    req.Priority,
+		Note:        req.Note,
+		Status:      StatusWaiting,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.WaitlistRepository.CreateEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+
+}
+
+func (s *waitlistService) getEntry(ctx context.Context, id string) (*WaitlistEntry, error) {
+
+	if id == "" {
+		return nil, errors.New("id is required")
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.WaitlistRepository.GetEntryByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry == nil {
+		return nil, ErrEntryNotFound
+	}
+
+	return entry, nil
+
+}
+
+func (s *waitlistService) Dequeue(ctx context.Context, req *WaitlistEntryRequest) error {
+
+	entry, err := s.getEntry(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.WaitlistRepository.DeleteEntry(ctx, entry.ID); err != nil {
+		return err
+	}
+
+	// A student leaving the list while holding an offer passes it on.
+	if entry.Status == StatusOffered && entry.Offer != nil && !entry.Offer.IsExpired(time.Now()) {
+		s.offerNext(ctx, entry.ClassRoomID, entry.TermID, entry.Offer)
+	}
+
+	return nil
+
+}
+
+func (s *waitlistService) GetWaitlist(ctx context.Context, classroomID, termID string) ([]*WaitlistEntryResponse, error) {
+
+	objClassroomID, err := primitive.ObjectIDFromHex(classroomID)
+	if err != nil {
+		return nil, err
+	}
+
+	objTermID, err := primitive.ObjectIDFromHex(termID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.WaitlistRepository.GetActiveEntries(ctx, objClassroomID, objTermID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*WaitlistEntryResponse, 0, len(entries))
+	for i, entry := range entries {
+		student, err := s.UserService.GetStudentInfor(ctx, entry.StudentID)
+		if err != nil || student == nil {
+			student = &user.UserInfor{
+				UserID:   entry.StudentID,
+				UserName: "Deleted",
+			}
+		}
+
+		responses = append(responses, &WaitlistEntryResponse{
+			Position: i + 1,
+			Entry:    entry,
+			Student:  student,
+		})
+	}
+
+	return responses, nil
+
+}
+
+func (s *waitlistService) AcceptOffer(ctx context.Context, req *WaitlistEntryRequest, userID string) error {
+
+	entry, err := s.getEntry(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if entry.Status != StatusOffered || entry.Offer == nil {
+		return ErrNoOpenOffer
+	}
+
+	if entry.Offer.IsExpired(time.Now()) {
+		s.expireEntry(ctx, entry)
+		return ErrOfferExpired
+	}
+
+	if err := s.checkOfferHolder(ctx, entry, userID); err != nil {
+		return err
+	}
+
+	// Claim the offer before assigning, so that concurrent accepts cannot
+	// both take the slot.
+	ok, err := s.WaitlistRepository.UpdateEntryStatus(ctx, entry.ID, StatusOffered, StatusAccepted)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoOpenOffer
+	}
+
+	if err := s.assignOffer(ctx, entry, userID); err != nil {
+		if _, undoErr := s.WaitlistRepository.UpdateEntryStatus(ctx, entry.ID, StatusAccepted, StatusOffered); undoErr != nil {
+			log.Printf("[ERROR] waitlist: cannot reopen offer of entry %s: %v", entry.ID.Hex(), undoErr)
+		}
+		return err
+	}
+
+	event.Emit(ctx, s.EventPublisher, offerEvent(event.WaitlistOfferAccepted, entry, entry.Offer).WithActor(userID))
+	return nil
+
+}
+
+// checkOfferHolder lets the offered student, whoever put them on the
+// waitlist (usually a guardian) and the admins of the classroom's
+// organization accept an offer.
+func (s *waitlistService) checkOfferHolder(ctx context.Context, entry *WaitlistEntry, userID string) error {
+
+	if userID == entry.StudentID || userID == entry.CreatedBy {
+		return nil
+	}
+
+	currentUser, err := s.UserService.GetCurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+	if currentUser != nil && currentUser.IsSuperAdmin {
+		return nil
+	}
+
+	orgID, err := s.WaitlistRepository.GetClassroomOrganizationID(ctx, entry.ClassRoomID)
+	if err != nil {
+		return err
+	}
+	if currentUser != nil && orgID != nil && currentUser.OrganizationAdmin != nil && currentUser.OrganizationAdmin.ID == *orgID {
+		return nil
+	}
+
+	return ErrNotOfferHolder
+
+}
+
+// assignOffer places the student in the offered day or template slot.
+func (s *waitlistService) assignOffer(ctx context.Context, entry *WaitlistEntry, userID string) error {
+
+	offer := entry.Offer
+
+	assignReq := &assign.UpdateAssginRequest{
+		StudentID:   &entry.StudentID,
+		ClassroomID: entry.ClassRoomID.Hex(),
+		TermID:      entry.TermID.Hex(),
+		SlotNumber:  offer.SlotNumber,
+	}
+
+	if offer.SessionID != nil {
+		assignReq.SessionID = offer.SessionID.Hex()
+	}
+
+	if offer.Date != nil {
+		// AssignSlot replaces the student of an occupied slot, so make sure
+		// nobody took the day in the meantime.
+		current, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, entry.ClassRoomID, offer.SlotNumber, offer.Date, offer.SessionID)
+		if err != nil {
+			return err
+		}
+
+		if current != nil && current.StudentID != nil {
+			return ErrSlotTaken
+		}
+
+		assignReq.Date = offer.Date.Format("2006-01-02")
+		_, err = s.AssignService.AssignSlot(ctx, assignReq, userID)
+		return err
+	}
+
+	_, err := s.AssignService.CreateAssignmentTemplate(ctx, assignReq, userID)
+	return err
+
+}
+
+func (s *waitlistService) DeclineOffer(ctx context.Context, req *WaitlistEntryRequest) error {
+
+	entry, err := s.getEntry(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if entry.Status != StatusOffered || entry.Offer == nil {
+		return ErrNoOpenOffer
+	}
+
+	ok, err := s.WaitlistRepository.UpdateEntryStatus(ctx, entry.ID, StatusOffered, StatusDeclined)
+	if err != nil {
+		return err
+	}
+
+	if ok && !entry.Offer.IsExpired(time.Now()) {
+		s.offerNext(ctx, entry.ClassRoomID, entry.TermID, entry.Offer)
+	}
+
+	return nil
+
+}
+
+// SlotReleased offers a freed slot to the next waiting student. A freed day
+// without a term is offered to the waitlist of whichever term contains it.
+func (s *waitlistService) SlotReleased(ctx context.Context, release *assign.SlotRelease) {
+
+	offer := &SlotOffer{
+		SlotNumber: release.SlotNumber,
+		SessionID:  release.SessionID,
+		Date:       release.Date,
+	}
+
+	if release.TermID != nil {
+		s.offerNext(ctx, release.ClassRoomID, *release.TermID, offer)
+		return
+	}
+
+	if release.Date == nil {
+		return
+	}
+
+	termIDs, err := s.WaitlistRepository.GetWaitingTermIDs(ctx, release.ClassRoomID)
+	if err != nil {
+		log.Printf("[ERROR] waitlist: cannot load terms for classroom %s: %v", release.ClassRoomID.Hex(), err)
+		return
+	}
+
+	day := release.Date.Format("2006-01-02")
+	for _, termID := range termIDs {
+		termInfor, err := s.TermService.GetTermByID(ctx, termID.Hex())
+		if err != nil || termInfor == nil {
+			log.Printf("[ERROR] waitlist: cannot load term %s: %v", termID.Hex(), err)
+			continue
+		}
+
+		if day >= termInfor.StartDate && day <= termInfor.EndDate {
+			s.offerNext(ctx, release.ClassRoomID, termID, offer)
+			return
+		}
+	}
+
+}
+
+// offerNext holds the slot for the first waiting student of the term, unless
+// an open offer for the same slot already exists. Failures are logged since
+// the slot has already been freed.
+func (s *waitlistService) offerNext(ctx context.Context, classroomID, termID primitive.ObjectID, slot *SlotOffer) {
+
+	pending, err := s.WaitlistRepository.HasActiveOffer(ctx, classroomID, termID, slot)
+	if err != nil {
+		log.Printf("[ERROR] waitlist: cannot check offers for classroom %s: %v", classroomID.Hex(), err)
+		return
+	}
+
+	if pending {
+		return
+	}
+
+	free, err := s.slotFree(ctx, classroomID, termID, slot)
+	if err != nil {
+		log.Printf("[ERROR] waitlist: cannot check slot %d of classroom %s: %v", slot.SlotNumber, classroomID.Hex(), err)
+		return
+	}
+
+	if !free {
+		return
+	}
+
+	for {
+		entry, err := s.WaitlistRepository.GetNextWaiting(ctx, classroomID, termID)
+		if err != nil {
+			log.Printf("[ERROR] waitlist: cannot load next entry for classroom %s: %v", classroomID.Hex(), err)
+			return
+		}
+
+		if entry == nil {
+			return
+		}
+
+		now := time.Now()
+		offer := &SlotOffer{
+			SlotNumber: slot.SlotNumber,
+			SessionID:  slot.SessionID,
+			Date:       slot.Date,
+			OfferedAt:  now,
+			ExpiresAt:  now.Add(s.OfferTTL),
+		}
+
+		ok, err := s.WaitlistRepository.OfferToEntry(ctx, entry.ID, offer)
+		if err != nil {
+			log.Printf("[ERROR] waitlist: cannot offer slot to entry %s: %v", entry.ID.Hex(), err)
+			return
+		}
+
+		// Another replica got to this entry first, try the next one.
+		if !ok {
+			continue
+		}
+
+		event.Emit(ctx, s.EventPublisher, offerEvent(event.WaitlistOffered, entry, offer))
+		return
+	}
+
+}
+
+// slotFree reports whether the slot of an offer still has room for a student.
+// A template slot counts as free while one of its templates has no student.
+func (s *waitlistService) slotFree(ctx context.Context, classroomID, termID primitive.ObjectID, slot *SlotOffer) (bool, error) {
+
+	if slot.Date != nil {
+		current, err := s.AssignRepository.GetAssignmentBySlotAndDate(ctx, classroomID, slot.SlotNumber, slot.Date, slot.SessionID)
+		if err != nil {
+			return false, err
+		}
+		return current == nil || current.StudentID == nil, nil
+	}
+
+	templates, err := s.AssignRepository.GetAssignmentTemplatesBySlot(ctx, classroomID, termID, slot.SlotNumber, slot.SessionID)
+	if err != nil {
+		return false, err
+	}
+
+	if len(templates) == 0 {
+		return true, nil
+	}
+
+	for _, t := range templates {
+		if t.StudentID == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+
+}
+
+// expireEntry closes an overdue offer and passes the slot on.
+func (s *waitlistService) expireEntry(ctx context.Context, entry *WaitlistEntry) bool {
+
+	ok, err := s.WaitlistRepository.UpdateEntryStatus(ctx, entry.ID, StatusOffered, StatusExpired)
+	if err != nil {
+		log.Printf("[ERROR] waitlist: cannot expire entry %s: %v", entry.ID.Hex(), err)
+		return false
+	}
+
+	if !ok {
+		return false
+	}
+
+	event.Emit(ctx, s.EventPublisher, offerEvent(event.WaitlistOfferExpired, entry, entry.Offer))
+	s.offerNext(ctx, entry.ClassRoomID, entry.TermID, entry.Offer)
+	return true
+
+}
+
+// ExpireOffers expires every overdue offer and returns how many it closed.
+func (s *waitlistService) ExpireOffers(ctx context.Context) (int, error) {
+
+	entries, err := s.WaitlistRepository.GetExpiredOffers(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, entry := range entries {
+		if s.expireEntry(ctx, entry) {
+			expired++
+		}
+	}
+
+	return expired, nil
+
+}